@@ -1,9 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/cozees/cook/pkg/cook/ast"
+	"github.com/cozees/cook/pkg/cook/parser"
 	"github.com/cozees/cook/pkg/runtime/args"
 	"github.com/cozees/cook/pkg/runtime/function"
 )
@@ -11,11 +15,18 @@ import (
 var mainFlags = &args.Flags{
 	FuncName: "cook",
 	Usage: `cook --VAR VALUE [TARGET ...]
-			cook help [@FUNCTION]`,
+			cook --watch --watch-path GLOB [--debounce DURATION] [TARGET ...]
+			cook [--file-mode MODE] [--keep-backup] [TARGET ...]
+			cook help [@FUNCTION | TARGET]
+			cook check [-c COOKFILE]
+			cook lint [-c COOKFILE] [--json]`,
 	ShortDesc: `Cook interpreter to execute cookfile.`,
 	Example: `cook --INPUT 1.32 sample_target
 	          cook sample_target
+			  cook --watch --watch-path 'src/**/*.go' build
 			  cook help
+			  cook check
+			  cook lint --json
 			  cook`,
 	Description: `Cook interpreter design to execute simple task defined in the a Cookfile.
 				  Each task can be define as target which can be contain mutiple statement.
@@ -25,10 +36,31 @@ var mainFlags = &args.Flags{
 }
 
 const (
-	helpDesc = `Print cook help to standard console if no function given otherwise print function help out instead.`
-	varDesc  = `Define dynamic global variable via argument. By default, a dynamic global variable can be provided via
+	helpDesc = `Print cook help to standard console if no function or target given otherwise print function help
+				or the parameters of the target declared in Cookfile instead.`
+	varDesc = `Define dynamic global variable via argument. By default, a dynamic global variable can be provided via
 				environment variable however its a read-only variable. Variable define via argument is allowed to be
 				change during execution.`
+	watchDesc = `Execute the targets then execute them again in a fresh context whenever a watched file is created,
+				 modified or removed. A run still executing when a change is detected is cancelled including any
+				 running external command. The Cookfile is always watched and it is read again on every run.`
+	watchPathDesc = `A glob pattern, using the same syntax as @find flag name, of the file to be watched. A directory
+					 watch every file inside it. The flag can be given multiple time and at least one is required.`
+	checkDesc = `Report syntax errors and mismatched types of the Cookfile without executing it. The type of a value
+				 is inferred from literals, type annotations of variables and functions and the result of built-in
+				 functions. If the Cookfile declare a target named check, the target is executed instead.`
+	lintDesc = `Report likely mistakes of the Cookfile without executing it, a call to an unknown target or function,
+				an unknown flag given to a built-in function, a variable which is assigned but never read, code
+				after exit, return, break or continue, a break or continue label which is not defined and a loop
+				variable which overwrite a variable of the enclosing scope. Each issue is written on its own
+				line as FILE:LINE:COLUMN: RULE: MESSAGE or as a JSON array with flag --json. The exit status is 1
+				if there is any issue. If the Cookfile declare a target named lint, the target is executed instead.`
+	debounceDesc = `How long to wait after the last change before running the targets again, e.g. 500ms or 2s.
+					The default is 300ms.`
+	fileModeDesc = `Permission of a new file created by a redirect or a built-in function in linux permission syntax,
+					e.g. 644 or u=rw,go=r. The flag override environment variable COOK_FILE_MODE.`
+	keepBackupDesc = `Keep the previous content of a file overwritten by a redirect or a built-in function in a file
+					  with suffix .bak. The flag override environment variable COOK_BACKUP.`
 )
 
 func PrintHelp(f *args.FunctionMeta) {
@@ -37,8 +69,71 @@ func PrintHelp(f *args.FunctionMeta) {
 		io.Copy(os.Stdout, rd)
 	} else {
 		io.Copy(os.Stdout, mainFlags.HelpFlagVisitor(false, "", func(fw args.FlagWriter) {
-			fw(12, "", "help", "", helpDesc)
-			fw(12, "", "[VARIABLE]", "", varDesc)
+			fw(13, "", "help", "", helpDesc)
+			fw(13, "", "[VARIABLE]", "", varDesc)
+			fw(13, "", "check", "", checkDesc)
+			fw(13, "", "lint", "", lintDesc)
+			fw(13, "", "watch", "", watchDesc)
+			fw(13, "", "watch-path", "", watchPathDesc)
+			fw(13, "", "debounce", "", debounceDesc)
+			fw(13, "", "file-mode", "", fileModeDesc)
+			fw(13, "", "keep-backup", "", keepBackupDesc)
 		}))
 	}
 }
+
+const targetDesc = `A parameter can be given on command line with the same syntax as a global variable or as a named
+				   argument when calling the target within Cookfile. A parameter without default value is required.`
+
+// PrintTargetHelp print the usage and the parameters of a target declared in cookfile
+func PrintTargetHelp(cookfile, name string) error {
+	cook, err := parser.NewParser().Parse(cookfile)
+	if err != nil {
+		return err
+	}
+	t := cook.GetTarget(name)
+	if t == nil {
+		return fmt.Errorf("target %s is not exist", name)
+	}
+	cli, call := &strings.Builder{}, &strings.Builder{}
+	fmt.Fprintf(cli, "cook [--VAR VALUE] %s", name)
+	fmt.Fprintf(call, "@%s", name)
+	width := 0
+	for _, tp := range t.Params {
+		value := "VALUE"
+		if tp.Type != 0 {
+			value = strings.ToUpper(tp.Type.String())
+		}
+		if tp.Default != nil {
+			fmt.Fprintf(cli, " [--%s %s]", tp.Name.Name, value)
+		} else {
+			fmt.Fprintf(cli, " --%s %s", tp.Name.Name, value)
+		}
+		fmt.Fprintf(call, " %s: %s", tp.Name.Name, value)
+		width = max(width, len(tp.Name.Name)+2)
+	}
+	flags := &args.Flags{
+		FuncName:    name,
+		ShortDesc:   "target declared at " + t.ErrPos(),
+		Usage:       cli.String(),
+		Description: targetDesc,
+		Example:     call.String(),
+	}
+	io.Copy(os.Stdout, flags.HelpFlagVisitor(false, "", func(fw args.FlagWriter) {
+		for _, tp := range t.Params {
+			fw(width, "", tp.Name.Name, "", paramDesc(tp))
+		}
+	}))
+	return nil
+}
+
+func paramDesc(tp *ast.TargetParam) string {
+	desc := "Any value"
+	if tp.Type != 0 {
+		desc = "A value of type " + tp.Type.String()
+	}
+	if tp.Default == nil {
+		return desc + ", required."
+	}
+	return desc + ", default " + tp.Default.String() + "."
+}
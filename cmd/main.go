@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
 
+	"github.com/cozees/cook/pkg/cook/ast"
 	"github.com/cozees/cook/pkg/cook/parser"
 	"github.com/cozees/cook/pkg/runtime/args"
 	"github.com/cozees/cook/pkg/runtime/function"
@@ -14,15 +16,46 @@ import (
 
 func main() {
 	opts, err := args.ParseMainArgument(os.Args[1:])
+	if topts := targetCommand(os.Args[1:]); topts != nil {
+		opts, err = topts, nil
+	}
+	if err == nil {
+		err = applyFileOptions(opts)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	} else if opts.IsHelp {
-		PrintHelp(opts.FuncMeta)
+		if len(opts.Targets) == 1 {
+			if err = PrintTargetHelp(opts.Cookfile, opts.Targets[0]); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		} else {
+			PrintHelp(opts.FuncMeta)
+		}
 		os.Exit(0)
 	} else if opts.FuncMeta != nil {
 		executeFunction(opts)
 		os.Exit(0)
+	} else if opts.Check {
+		if err = checkCookfile(opts.Cookfile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	} else if opts.Lint {
+		if err = lintCookfile(opts.Cookfile, opts.JSON); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	} else if opts.Watch {
+		if err = watchTargets(opts); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
 	p := parser.NewParser()
@@ -39,6 +72,75 @@ func main() {
 	}
 }
 
+// applyFileOptions override the permission of a new file and the backup of an overwritten file
+// given by environment variable with the flags.
+func applyFileOptions(opts *args.MainOptions) error {
+	if opts.FileMode != "" {
+		if err := function.SetFileMode(opts.FileMode); err != nil {
+			return fmt.Errorf("invalid file mode %s: %w", opts.FileMode, err)
+		}
+	}
+	if opts.Backup {
+		function.Backup = true
+	}
+	return nil
+}
+
+// targetCommand return the options to execute a target which has the same name as the command given
+// as the first argument, check or lint, if the Cookfile declare one. Such target take precedence over the
+// command thus a Cookfile written before the command was introduced keep working.
+func targetCommand(cargs []string) *args.MainOptions {
+	if len(cargs) == 0 || cargs[0] != "check" && cargs[0] != "lint" {
+		return nil
+	}
+	opts, err := args.ParseTargetArgument(cargs)
+	if err != nil {
+		return nil
+	}
+	cook, err := parser.NewParser().Parse(opts.Cookfile)
+	if err != nil || cook.GetTarget(cargs[0]) == nil {
+		return nil
+	}
+	return opts
+}
+
+// checkCookfile report syntax errors and mismatched types of the Cookfile without executing it
+func checkCookfile(cookfile string) error {
+	cook, err := parser.NewParser().Parse(cookfile)
+	if err != nil {
+		return err
+	}
+	return cook.Check()
+}
+
+// lintCookfile write the issues of the Cookfile to the standard output, one issue per line or a JSON
+// array if asJSON is true. An error is returned if there is any issue.
+func lintCookfile(cookfile string, asJSON bool) error {
+	cook, err := parser.NewParser().Parse(cookfile)
+	if err != nil {
+		return err
+	}
+	issues := cook.Lint()
+	if asJSON {
+		if issues == nil {
+			issues = []*ast.Issue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d issue(s)", len(issues))
+	}
+	return nil
+}
+
 func executeFunction(opts *args.MainOptions) {
 	fn := function.GetFunction(opts.FuncMeta.Name)
 	if fn == nil {
@@ -128,10 +230,14 @@ func executeFunction(opts *args.MainOptions) {
 		return nil
 	}
 	w := bufio.NewWriter(os.Stdout)
-	defer w.Flush()
 	if err = output(w, reflect.ValueOf(result)); err != nil {
+		w.Flush()
 		fmt.Fprintf(os.Stderr, "error while writing function @%s output: %s\n", opts.FuncMeta.Name, err.Error())
 		os.Exit(1)
 	}
 	w.WriteByte('\n')
+	w.Flush()
+	if sf, ok := fn.(function.StatusFunction); ok {
+		os.Exit(sf.ExitStatus(result))
+	}
 }
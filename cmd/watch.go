@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/cozees/cook/pkg/cook/ast"
+	"github.com/cozees/cook/pkg/cook/parser"
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/cozees/cook/pkg/runtime/watch"
+)
+
+// executeTargets parse the Cookfile then execute the targets given in opts or target all if
+// there is none.
+func executeTargets(goctx context.Context, opts *args.MainOptions) error {
+	cook, err := parser.NewParser().Parse(opts.Cookfile)
+	if err != nil {
+		return err
+	}
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = []string{ast.TargetAll}
+	}
+	return cook.ExecuteContext(goctx, opts.Args, targets...)
+}
+
+// watchTargets execute the targets then re-execute them each time a watched file is changed.
+// The Cookfile is always watched and parsed again on each run thus a change in the Cookfile
+// take effect right away. A run which still executing when a change is detected is cancelled,
+// including any running external command. It return nil when the process is interrupted or
+// the error which stop watching the files.
+func watchTargets(opts *args.MainOptions) error {
+	if len(opts.WatchPaths) == 0 {
+		return errors.New("--watch requires at least one --watch-path to know which file to watch")
+	}
+	debounce := opts.Debounce
+	if debounce == 0 {
+		debounce = watch.DefaultDebounce
+	}
+	sigctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w, err := watch.New(watch.DefaultInterval, append([]string{opts.Cookfile}, opts.WatchPaths...)...)
+	if err != nil {
+		return err
+	}
+	for {
+		runctx, cancel := context.WithCancel(sigctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if err := executeTargets(runctx, opts); err != nil && !errors.Is(err, context.Canceled) {
+				fmt.Fprintln(os.Stderr, err.Error())
+			}
+			if runctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, "cook: waiting for changes...")
+			}
+		}()
+		changed, err := w.Wait(sigctx, debounce)
+		cancel()
+		<-done
+		if err != nil {
+			if sigctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		files := changed[0]
+		if len(changed) > 1 {
+			files += fmt.Sprintf(" and %d more", len(changed)-1)
+		}
+		fmt.Fprintf(os.Stderr, "\n%s\ncook: %s changed at %s, restarting\n%s\n\n",
+			strings.Repeat("=", 72), files, time.Now().Format(time.TimeOnly), strings.Repeat("=", 72))
+	}
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -8,12 +9,15 @@ import (
 	"os/exec"
 	"reflect"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/cozees/cook/pkg/cook/token"
 	cookErrors "github.com/cozees/cook/pkg/errors"
 	"github.com/cozees/cook/pkg/runtime/args"
 	"github.com/cozees/cook/pkg/runtime/function"
+	"github.com/cozees/cook/pkg/runtime/glob"
 )
 
 type Node interface {
@@ -77,6 +81,8 @@ type (
 		*Base
 		X     Node
 		Types []token.Token
+		// Records is the name of record types, e.g. A is Package
+		Records []string
 	}
 
 	// A node represent type cast expression
@@ -99,6 +105,14 @@ type (
 		Values    []Node
 	}
 
+	// A node represent glob pattern in an array literal, it is expanded into the
+	// matching file paths when the array is evaluated.
+	Glob struct {
+		*Base
+		Strict  bool // no matching file is an error
+		Pattern Node // either a string literal or string interpolation
+	}
+
 	// A node represent map literal
 	MapLiteral struct {
 		*Base
@@ -164,6 +178,12 @@ type (
 		Op token.Token
 	}
 
+	// A node represent function literal, e.g. (a, b) => a + b
+	FuncLit struct {
+		*Base
+		Fn *Function
+	}
+
 	// A node represent call expression
 	Call struct {
 		*Base
@@ -178,6 +198,13 @@ type (
 		pipeBuiltInArgs *args.FunctionArg
 	}
 
+	// A node represent a named argument of a target call, e.g. @deploy env: "prod"
+	NamedArg struct {
+		*Base
+		Name string
+		X    Node
+	}
+
 	// A node represent pipe expression
 	Pipe struct {
 		*Base
@@ -271,17 +298,47 @@ func (bl *BasicLit) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
 // look into environment varaible and return it value if founded otherwise a nil and invalid kind is
 // return instead.
 func (id *Ident) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
-	v, k, _ = ctx.GetVariable(id.Name)
+	if v, k, _ = ctx.GetVariable(id.Name); k == reflect.Invalid {
+		// a declared function can be referred by its name as a value
+		if fn := ctx.GetFunction(id.Name); fn != nil {
+			return funcValue(fn.internalExecute), reflect.Func, nil
+		}
+		// field of a record, e.g. pkg.name
+		if strings.Contains(id.Name, ".") {
+			if r, ind, err := recordField(ctx, id.Name); err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", id.ErrPos(), err)
+			} else if r != nil {
+				v = r.values[ind]
+				return v, reflect.ValueOf(v).Kind(), nil
+			}
+		}
+	}
 	return
 }
 
+// NamedArg Evaluate always return an error as named argument is consumed by a target call directly
+func (na *NamedArg) Evaluate(ctx Context) (any, reflect.Kind, error) {
+	return nil, 0, fmt.Errorf("%s: named argument %s is only allowed when calling a target", na.ErrPos(), na.Name)
+}
+
+// FuncLit Evaluate return a function value which capture the current scope
+func (fl *FuncLit) Evaluate(ctx Context) (any, reflect.Kind, error) {
+	return fl.Fn.closure(ctx.Capture()), reflect.Func, nil
+}
+
 func (id *Ident) VariableName() string { return id.Name }
 
 func (id *Ident) Set(ctx Context, v any, k reflect.Kind, bubble func(v any, k reflect.Kind) error) (err error) {
-	if v != nil {
-		ctx.SetVariable(id.Name, v, k, bubble)
-	} else {
+	if v == nil {
 		err = fmt.Errorf("assign nil value to variable %s", id.Name)
+	} else if !strings.Contains(id.Name, ".") {
+		ctx.SetVariable(id.Name, v, k, bubble)
+	} else if r, ind, ferr := recordField(ctx, id.Name); ferr != nil {
+		err = fmt.Errorf("%s: %w", id.ErrPos(), ferr)
+	} else if r == nil {
+		err = fmt.Errorf("%s: variable %s is not defined", id.ErrPos(), id.Name[:strings.IndexByte(id.Name, '.')])
+	} else if ferr = r.set(ind, v, k); ferr != nil {
+		err = fmt.Errorf("%s: %w", id.ErrPos(), ferr)
 	}
 	return
 }
@@ -333,10 +390,10 @@ func (sf *SizeOf) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
 			return nil, reflect.Invalid, err
 		} else if k != reflect.String {
 			return nil, reflect.Invalid, fmt.Errorf("%s is valid string filepath", unary)
-		} else if stat, err := os.Stat(fp.(string)); err != nil {
+		} else if stat, err := os.Stat(function.ResolvePath(ctx, fp.(string))); err != nil {
 			return int64(-1), reflect.Int64, nil
 		} else if stat.IsDir() {
-			if fis, err := os.ReadDir(fp.(string)); err != nil {
+			if fis, err := os.ReadDir(function.ResolvePath(ctx, fp.(string))); err != nil {
 				return nil, reflect.Invalid, err
 			} else {
 				return int64(len(fis)), reflect.Int64, nil
@@ -356,6 +413,35 @@ func (sf *SizeOf) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
 	return v, reflect.Int64, nil
 }
 
+// match return true if a value of kind k satisfied one of the types
+func (it *IsType) match(ctx Context, v any, k reflect.Kind) bool {
+	if r, ok := v.(Record); ok && slices.ContainsFunc(it.Records, func(name string) bool { return ctx.GetRecord(name) == r.Type }) {
+		return true
+	}
+	bit := 0
+	for _, tok := range it.Types {
+		bit |= tok.Type()
+	}
+	var kbit int
+	switch k {
+	case reflect.Int64:
+		kbit = token.TINTEGER.Type()
+	case reflect.Float64:
+		kbit = token.TFLOAT.Type()
+	case reflect.String:
+		kbit = token.TSTRING.Type()
+	case reflect.Bool:
+		kbit = token.TBOOLEAN.Type()
+	case reflect.Array, reflect.Slice:
+		kbit = token.TARRAY.Type()
+	case reflect.Map:
+		kbit = token.TMAP.Type()
+	default:
+		kbit = token.TOBJECT.Type()
+	}
+	return bit&kbit == kbit
+}
+
 // IsType Evaluate return a boolean value. It return true if variable X value is satified
 // the given type Token.
 func (it *IsType) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
@@ -378,25 +464,8 @@ func (it *IsType) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
 		kbit := token.TMAP.Type()
 		return bit&kbit == kbit, reflect.Bool, nil
 	} else if _, ok := it.X.(*Ident); ok {
-		var kbit int
-		switch _, k, _ = it.X.Evaluate(ctx); k {
-		case reflect.Int64:
-			kbit = token.TINTEGER.Type()
-		case reflect.Float64:
-			kbit = token.TFLOAT.Type()
-		case reflect.String:
-			kbit = token.TSTRING.Type()
-		case reflect.Bool:
-			kbit = token.TBOOLEAN.Type()
-		case reflect.Array, reflect.Slice:
-			kbit = token.TARRAY.Type()
-		case reflect.Map:
-			kbit = token.TMAP.Type()
-		default:
-			kbit = token.TOBJECT.Type()
-		}
-		v = bit&kbit == kbit
-		k = reflect.Bool
+		v, k, _ = it.X.Evaluate(ctx)
+		v, k = it.match(ctx, v, k), reflect.Bool
 	} else {
 		return nil, 0, fmt.Errorf("%s of %s must be a literal value or variable", it, it.X)
 	}
@@ -406,48 +475,55 @@ func (it *IsType) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
 // TypeCast Evaluate return convertible value converted from string to string
 func (tc *TypeCast) Evaluate(ctx Context) (v any, k reflect.Kind, err error) {
 	iv, ik, _ := tc.X.Evaluate(ctx)
-	tk := tc.To.Kind()
-	if tk != ik {
-		switch tk {
-		case reflect.Int64:
-			if ik == reflect.Float64 {
-				return int64(iv.(float64)), tk, nil
-			} else if k == reflect.String {
-				if v, err = strconv.ParseInt(iv.(string), 10, 64); err == nil {
-					k = tk
-					return
-				}
+	if v, k, err = castValue(iv, ik, tc.To.Kind()); err != nil {
+		err = fmt.Errorf("%s %w", tc.ErrPos(), err)
+	}
+	return
+}
+
+// castValue convert value v of kind vk to kind tk, only a conversion between integer, float, boolean
+// and string is possible.
+func castValue(v any, vk, tk reflect.Kind) (any, reflect.Kind, error) {
+	if tk == vk {
+		return v, vk, nil
+	}
+	switch tk {
+	case reflect.Int64:
+		if vk == reflect.Float64 {
+			return int64(v.(float64)), tk, nil
+		} else if vk == reflect.String {
+			if i, err := strconv.ParseInt(v.(string), 10, 64); err == nil {
+				return i, tk, nil
 			}
-		case reflect.Float64:
-			if ik == reflect.Int64 {
-				return float64(iv.(int64)), tk, nil
-			} else if ik == reflect.String {
-				if v, err = strconv.ParseFloat(iv.(string), 64); err == nil {
-					k = tk
-					return
-				}
+		}
+	case reflect.Float64:
+		if vk == reflect.Int64 {
+			return float64(v.(int64)), tk, nil
+		} else if vk == reflect.String {
+			if f, err := strconv.ParseFloat(v.(string), 64); err == nil {
+				return f, tk, nil
 			}
-		case reflect.Bool:
-			if ik == reflect.String {
-				if v, err = strconv.ParseBool(iv.(string)); err == nil {
-					k = tk
-					return
-				}
+		}
+	case reflect.Bool:
+		if vk == reflect.String {
+			if b, err := strconv.ParseBool(v.(string)); err == nil {
+				return b, tk, nil
 			}
-		case reflect.String:
-			if ik == reflect.Int64 {
-				return strconv.FormatInt(iv.(int64), 10), tk, nil
-			} else if ik == reflect.Float64 {
-				return strconv.FormatFloat(iv.(float64), 'g', -1, 64), tk, nil
-			} else if ik == reflect.Bool {
-				return strconv.FormatBool(iv.(bool)), tk, nil
+		}
+	case reflect.String:
+		if vk == reflect.Int64 {
+			return strconv.FormatInt(v.(int64), 10), tk, nil
+		} else if vk == reflect.Float64 {
+			return strconv.FormatFloat(v.(float64), 'g', -1, 64), tk, nil
+		} else if vk == reflect.Bool {
+			return strconv.FormatBool(v.(bool)), tk, nil
+		} else if r, ok := v.(Record); ok {
+			if b, err := r.MarshalJSON(); err == nil {
+				return string(b), tk, nil
 			}
 		}
-		err = fmt.Errorf("%s cannot cast %v to type %s", tc.ErrPos(), iv, tk)
-	} else {
-		v, k = iv, ik
 	}
-	return
+	return nil, 0, fmt.Errorf("cannot cast %v to type %s", v, tk)
 }
 
 // Exit Evaluate will exit the execution with the given code.
@@ -476,7 +552,54 @@ func (al *ArrayLiteral) Evaluate(ctx Context) (v any, k reflect.Kind, err error)
 		if v, _, err = lv.Evaluate(ctx); err != nil {
 			return nil, 0, err
 		}
-		result = append(result, v)
+		if _, ok := lv.(*Glob); ok {
+			result = append(result, v.([]any)...)
+		} else {
+			result = append(result, v)
+		}
+	}
+	return result, reflect.Slice, nil
+}
+
+// globMeta is the special character which turn a string in an array literal into glob pattern
+const globMeta = "*?[{"
+
+// IsGlobPattern report whether x is a string literal which contain any glob special character.
+// A string interpolation is never a glob pattern unless it is marked strict explicitly.
+func IsGlobPattern(x Node) bool {
+	bl, ok := x.(*BasicLit)
+	return ok && bl.Kind == token.STRING && strings.ContainsAny(bl.Lit, globMeta)
+}
+
+// Glob Evaluate return an array of file path matching the pattern relative to the current
+// working directory at the time it is evaluated. A pattern which is invalid or does not match
+// any file is kept as is unless the glob is strict.
+func (g *Glob) Evaluate(ctx Context) (any, reflect.Kind, error) {
+	v, vk, err := g.Pattern.Evaluate(ctx)
+	if err != nil {
+		return nil, 0, err
+	} else if vk != reflect.String {
+		return nil, 0, fmt.Errorf("%s: glob pattern must be a string but got %s", g.ErrPos(), vk)
+	}
+	pattern := v.(string)
+	if !strings.ContainsAny(pattern, globMeta) {
+		if _, err = os.Lstat(function.ResolvePath(ctx, pattern)); g.Strict && err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", g.ErrPos(), err)
+		}
+		return []any{pattern}, reflect.Slice, nil
+	}
+	matches, err := glob.GlobIn(ctx.WorkingDir(), pattern)
+	switch {
+	case err != nil && g.Strict:
+		return nil, 0, fmt.Errorf("%s: invalid glob pattern %s: %w", g.ErrPos(), pattern, err)
+	case len(matches) == 0 && g.Strict:
+		return nil, 0, fmt.Errorf("%s: glob pattern %s does not match any file", g.ErrPos(), pattern)
+	case err != nil, len(matches) == 0:
+		return []any{pattern}, reflect.Slice, nil
+	}
+	result := make([]any, len(matches))
+	for i, m := range matches {
+		result[i] = m
 	}
 	return result, reflect.Slice, nil
 }
@@ -778,6 +901,36 @@ func (r *Interval) Evaluate(ctx Context) (any, reflect.Kind, error) {
 	}
 }
 
+// Contains report whether number v is within the interval, the step is ignored. A and B can be
+// given in any order.
+func (r *Interval) Contains(ctx Context, v any, vk reflect.Kind) (bool, error) {
+	if vk != reflect.Int64 && vk != reflect.Float64 {
+		return false, nil
+	}
+	bounds := [2]float64{}
+	for i, n := range []Node{r.A, r.B} {
+		b, bk, err := n.Evaluate(ctx)
+		if err != nil {
+			return false, err
+		} else if bk == reflect.String {
+			if b, bk, err = convertToNum(b.(string)); err != nil {
+				return false, err
+			}
+		}
+		if bk != reflect.Int64 && bk != reflect.Float64 {
+			return false, fmt.Errorf("interval required integer or float value")
+		}
+		// we know that b is either int64 or float64, ignore the error
+		bounds[i], _ = convertToFloat(ctx, b, bk)
+	}
+	f, _ := convertToFloat(ctx, v, vk)
+	lo, loInclude, hi, hiInclude := bounds[0], r.AInclude, bounds[1], r.BInclude
+	if lo > hi {
+		lo, loInclude, hi, hiInclude = hi, hiInclude, lo, loInclude
+	}
+	return (lo < f || loInclude && lo == f) && (f < hi || hiInclude && hi == f), nil
+}
+
 // OSysCheck Evaluate return true if current operating system is match against expression OS value
 func (osc *OSysCheck) Evaluate(ctx Context) (any, reflect.Kind, error) {
 	return osc.OS.String() == runtime.GOOS, reflect.Bool, nil
@@ -792,7 +945,7 @@ func (e *Exists) Evaluate(ctx Context) (any, reflect.Kind, error) {
 		} else if kind != reflect.String {
 			return nil, 0, fmt.Errorf("value %v cannot represent file path", fp)
 		} else {
-			_, err = os.Stat(fp.(string))
+			_, err = os.Stat(function.ResolvePath(ctx, fp.(string)))
 			return err == nil, reflect.Bool, nil
 		}
 	} else if ident, ok := e.X.(*Ident); ok {
@@ -814,6 +967,15 @@ func (e *Exists) Evaluate(ctx Context) (any, reflect.Kind, error) {
 	}
 }
 
+// commandError return the cancellation error instead of err if the command was killed because
+// the execution has been cancelled.
+func commandError(ctx Context, err error) error {
+	if cerr := ctx.Context().Err(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
 // Call Evaluate execute one of the following type an external command line, a target or a function
 func (c *Call) Evaluate(ctx Context) (any, reflect.Kind, error) {
 	if c.FuncLit != nil {
@@ -825,10 +987,12 @@ func (c *Call) Evaluate(ctx Context) (any, reflect.Kind, error) {
 		if args, err := c.args(ctx); err != nil {
 			return nil, 0, err
 		} else {
-			cmd := exec.Command(c.Name, args...)
-			dir, err := os.Getwd()
-			if err != nil {
-				return nil, 0, err
+			cmd := exec.CommandContext(ctx.Context(), c.Name, args...)
+			dir := ctx.WorkingDir()
+			if dir == "" {
+				if dir, err = os.Getwd(); err != nil {
+					return nil, 0, err
+				}
 			}
 			cmd.Dir = dir
 			if c.pipeCmdArgs != "" {
@@ -846,29 +1010,38 @@ func (c *Call) Evaluate(ctx Context) (any, reflect.Kind, error) {
 			if !c.OutputResult {
 				cmd.Stdout = os.Stdout
 				cmd.Stderr = os.Stderr
-				if err = cmd.Run(); err != nil {
-					return nil, 0, err
+				acquire := ctx.Release()
+				err = cmd.Run()
+				acquire()
+				if err != nil {
+					return nil, 0, commandError(ctx, err)
 				} else {
 					return "", reflect.String, nil
 				}
 			} else {
+				acquire := ctx.Release()
 				result, err := cmd.Output()
+				acquire()
 				if err != nil {
-					return nil, 0, err
+					return nil, 0, commandError(ctx, err)
 				} else {
 					return string(result), reflect.String, nil
 				}
 			}
 		}
 	case token.AT:
-		// priority target, built-in command, developer defined function
+		// priority function value, target, built-in command, developer defined function
+		if v, k, _ := ctx.GetVariable(c.Name); k == reflect.Func {
+			return v.(funcValue)(ctx, len(c.Args), func(i int) (any, reflect.Kind, error) {
+				return c.Args[i].Evaluate(ctx)
+			})
+		}
 		t := ctx.GetTarget(c.Name)
 		if t != nil {
 			if args, err := c.funcArgs(ctx); err != nil {
 				return nil, 0, err
 			} else {
-				t.Execute(ctx, args)
-				return nil, 0, nil
+				return nil, 0, t.Execute(ctx, args)
 			}
 		}
 		// command
@@ -876,8 +1049,18 @@ func (c *Call) Evaluate(ctx Context) (any, reflect.Kind, error) {
 		if f != nil {
 			if args, err := c.funcArgs(ctx); err != nil {
 				return nil, 0, err
+			} else if name := namedArgument(args); name != "" {
+				return nil, 0, fmt.Errorf("%s: named argument %s is only allowed when calling a target", c.ErrPos(), name)
 			} else {
-				if v, err := f.Apply(args); err != nil {
+				var v any
+				acquire := ctx.Release()
+				if rf, ok := f.(function.RuntimeFunction); ok {
+					v, err = rf.ApplyWithRuntime(ctx, args)
+				} else {
+					v, err = f.Apply(args)
+				}
+				acquire()
+				if err != nil {
 					return nil, 0, fmt.Errorf("%s: %w", c.ErrPos(), err)
 				} else {
 					return v, reflect.ValueOf(v).Kind(), nil
@@ -921,7 +1104,13 @@ func (c *Call) args(ctx Context) ([]string, error) {
 func (c *Call) funcArgs(ctx Context) ([]*args.FunctionArg, error) {
 	sargs := make([]*args.FunctionArg, 0, len(c.Args))
 	for _, arg := range c.Args {
-		if v, vk, err := arg.Evaluate(ctx); err != nil {
+		if na, ok := arg.(*NamedArg); ok {
+			if v, vk, err := na.X.Evaluate(ctx); err != nil {
+				return nil, err
+			} else {
+				sargs = append(sargs, &args.FunctionArg{Name: na.Name, Val: v, Kind: vk})
+			}
+		} else if v, vk, err := arg.Evaluate(ctx); err != nil {
 			return nil, err
 		} else {
 			switch vk {
@@ -938,6 +1127,16 @@ func (c *Call) funcArgs(ctx Context) ([]*args.FunctionArg, error) {
 	return sargs, nil
 }
 
+// namedArgument return the name of the first named argument or empty if there is none
+func namedArgument(fargs []*args.FunctionArg) string {
+	for _, fa := range fargs {
+		if fa.Name != "" {
+			return fa.Name
+		}
+	}
+	return ""
+}
+
 func (c *Call) setPipeArgument(ctx Context, v any, k reflect.Kind) (err error) {
 	if c.Kind == token.HASH {
 		if c.pipeCmdArgs, err = convertToString(ctx, v, k); err != nil {
@@ -986,7 +1185,7 @@ func (rf *ReadFrom) Evaluate(ctx Context) (any, reflect.Kind, error) {
 		return nil, 0, err
 	} else if k != reflect.String {
 		return nil, 0, fmt.Errorf("readfrom expression required string")
-	} else if b, err := os.ReadFile(v.(string)); err != nil {
+	} else if b, err := os.ReadFile(function.ResolvePath(ctx, v.(string))); err != nil {
 		return nil, 0, err
 	} else {
 		return string(b), reflect.String, nil
@@ -1008,56 +1207,40 @@ func (rt *RedirectTo) Evaluate(ctx Context) (any, reflect.Kind, error) {
 		return nil, 0, err
 	}
 
-	var b []byte
-	var ok bool
-	if vk == reflect.String {
-		b = []byte(v.(string))
-	} else if b, ok = v.([]byte); !ok {
-		goto tryReader
-	}
-	// write all b to each file
-	for _, f := range files {
-		_, err := os.Stat(f)
-		if rt.Append && !os.IsNotExist(err) {
-			if fs, err := os.OpenFile(f, os.O_APPEND|os.O_WRONLY, 0700); err != nil {
-				return nil, 0, err
-			} else if _, err = fs.Write(b); err != nil {
-				fs.Close()
-				return nil, 0, err
-			}
-		} else if err = os.WriteFile(f, b, 0700); err != nil {
-			return nil, 0, err
-		}
-	}
-	return nil, 0, nil
-
-tryReader:
 	var reader io.Reader
-	if r, ok := v.(io.ReadCloser); ok {
+	switch r := v.(type) {
+	case string:
+		reader = strings.NewReader(r)
+	case []byte:
+		reader = bytes.NewReader(r)
+	case io.ReadCloser:
 		defer r.Close()
 		reader = r
-	} else if r, ok := v.(io.Reader); ok {
+	case io.Reader:
 		reader = r
-	} else {
+	default:
 		return nil, 0, fmt.Errorf("write to file unsupport type %s", vk)
 	}
+	// each file is written to a temporary file then renamed so that a failure midway never
+	// leave a partially written file behind, except append and non-regular file written in place
 	var writer []io.Writer
 	for _, f := range files {
-		flags := os.O_WRONLY | os.O_CREATE
-		if rt.Append {
-			flags |= os.O_APPEND
-		} else {
-			flags |= os.O_TRUNC
-		}
-		w, err := os.OpenFile(f, flags, 0700)
+		af, err := function.CreateAtomicFile(function.ResolvePath(ctx, f), rt.Append, 0)
 		if err != nil {
 			return nil, 0, err
 		}
-		defer w.Close()
-		writer = append(writer, w)
+		defer af.Discard()
+		writer = append(writer, af)
 	}
-	_, err = io.Copy(io.MultiWriter(writer...), reader)
-	return nil, 0, err
+	if _, err = io.Copy(io.MultiWriter(writer...), reader); err != nil {
+		return nil, 0, err
+	}
+	for _, w := range writer {
+		if err = w.(*function.AtomicFile).Commit(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return nil, 0, nil
 }
 
 // Paran Evaluate execute inner node and return it's response
@@ -1199,11 +1382,11 @@ func (t *Transformation) Evaluate(ctx Context) (any, reflect.Kind, error) {
 					if vi == 0 {
 						return int64(i), reflect.Int64, nil
 					} else {
-						return indv.Interface(), indv.Kind(), nil
+						return indv.Interface(), kindOf(indv), nil
 					}
 				})
 				if err != nil {
-					return nil, 0, nil
+					return nil, 0, err
 				} else {
 					indv.Set(reflect.ValueOf(v))
 				}
@@ -1215,13 +1398,13 @@ func (t *Transformation) Evaluate(ctx Context) (any, reflect.Kind, error) {
 				indv := vv.MapIndex(key)
 				v, _, err := t.Fn.internalExecute(ctx, 2, func(vi int) (any, reflect.Kind, error) {
 					if vi == 0 {
-						return key.Interface(), key.Kind(), nil
+						return key.Interface(), kindOf(key), nil
 					} else {
-						return indv.Interface(), indv.Kind(), nil
+						return indv.Interface(), kindOf(indv), nil
 					}
 				})
 				if err != nil {
-					return nil, 0, nil
+					return nil, 0, err
 				} else {
 					vv.SetMapIndex(key, reflect.ValueOf(v))
 				}
@@ -1261,6 +1444,14 @@ func (t *Transformation) Evaluate(ctx Context) (any, reflect.Kind, error) {
 			tk = TransformMap
 			it = &iTransform{
 				Len: func() int { return vv.Len() },
+				Keys: func() []any {
+					keys := vv.MapKeys()
+					result := make([]any, len(keys))
+					for i, key := range keys {
+						result[i] = key.Interface()
+					}
+					return result
+				},
 				Source: func(ctx Context, i any) (any, reflect.Kind, error) {
 					v := vv.MapIndex(reflect.ValueOf(i))
 					if !v.IsValid() {
@@ -1299,6 +1490,7 @@ func (t *Transformation) Evaluate(ctx Context) (any, reflect.Kind, error) {
 			tk = TransformMap
 			it = &iTransform{
 				Len:    func() int { return ts.Len() },
+				Keys:   ts.Keys,
 				Source: ts.Transform,
 				Value: func(ctx Context, i, val any) (any, reflect.Kind, error) {
 					return t.Fn.internalExecute(ctx, 2, func(iv int) (any, reflect.Kind, error) {
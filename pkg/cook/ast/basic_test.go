@@ -2,12 +2,15 @@ package ast
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/cozees/cook/pkg/cook/token"
 	"github.com/stretchr/testify/assert"
@@ -83,6 +86,17 @@ func (wh *WrapHelper) String() string       { return "" }
 func (wh *WrapHelper) Visit(cb CodeBuilder) {}
 func wrapExpr(nodes ...Node) Node           { return &WrapHelper{nodes: nodes} }
 
+type readerNode struct {
+	*Base
+	r io.Reader
+}
+
+func (rn *readerNode) Evaluate(ctx Context) (any, reflect.Kind, error) {
+	return rn.r, reflect.Interface, nil
+}
+func (rn *readerNode) String() string       { return "" }
+func (rn *readerNode) Visit(cb CodeBuilder) {}
+
 func indexesNode(inds ...int) (nodes []Node) {
 	for _, ind := range inds {
 		nodes = append(nodes, &BasicLit{Lit: strconv.Itoa(ind), Kind: token.INTEGER})
@@ -613,6 +627,15 @@ func TestCallExprRedirect(t *testing.T) {
 	require.NoError(t, err)
 	verifyContentFile(t, file1, content+"\n"+content+"\n")
 	verifyContentFile(t, file2, content+"\n")
+	// a failure midway leave the file untouched
+	redirect.Append = false
+	redirect.Caller = &readerNode{r: io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(io.ErrUnexpectedEOF))}
+	_, _, err = redirect.Evaluate(ctx)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	verifyContentFile(t, file1, content+"\n"+content+"\n")
+	matches, err := filepath.Glob(".sample1.txt.tmp*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
 	// call read from
 	call := &Call{
 		Kind: token.AT,
@@ -808,3 +831,41 @@ func TestStringInterpolation(t *testing.T) {
 	assert.Equal(t, reflect.String, k)
 	assert.Equal(t, out, v)
 }
+
+func TestGlobLiteral(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer os.Chdir(wd)
+	glob := func(pattern string, strict bool) Node {
+		return &Glob{Base: dummyBase, Strict: strict, Pattern: &BasicLit{Lit: pattern, Kind: token.STRING, Mark: '\''}}
+	}
+	al := &ArrayLiteral{Values: []Node{il1, glob("**/*.{txt,md}", false), glob("*.go", false), sl2}}
+	// the pattern is not expanded until it is evaluated, without any match the pattern is kept
+	v, _, err := al.Evaluate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(12), "**/*.{txt,md}", "*.go", "sample"}, v)
+	for _, f := range []string{"a.txt", "b.go", filepath.Join("x", "c.md"), filepath.Join("x", "y", "d.txt")} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0700))
+		require.NoError(t, os.WriteFile(f, nil, 0600))
+	}
+	v, k, err := al.Evaluate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, reflect.Slice, k)
+	assert.Equal(t, []any{int64(12), "a.txt", filepath.Join("x", "c.md"), filepath.Join("x", "y", "d.txt"), "b.go", "sample"}, v)
+	// a string without special character is kept as is unless strict
+	v, _, err = (&ArrayLiteral{Values: []Node{glob("none.txt", false)}}).Evaluate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"none.txt"}, v)
+	_, _, err = (&ArrayLiteral{Values: []Node{glob("none.txt", true)}}).Evaluate(ctx)
+	assert.Error(t, err)
+	_, _, err = (&ArrayLiteral{Values: []Node{glob("*.none", true)}}).Evaluate(ctx)
+	assert.Error(t, err)
+	// an invalid pattern is kept as is unless strict
+	v, _, err = (&ArrayLiteral{Values: []Node{glob("[a", false)}}).Evaluate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"[a"}, v)
+	_, _, err = (&ArrayLiteral{Values: []Node{glob("[a", true)}}).Evaluate(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, "[12, !'*.go']", (&ArrayLiteral{Values: []Node{il1, glob("*.go", true)}}).String())
+}
@@ -0,0 +1,551 @@
+package ast
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cozees/cook/pkg/cook/token"
+	cookErrors "github.com/cozees/cook/pkg/errors"
+	"github.com/cozees/cook/pkg/runtime/function"
+)
+
+// checkVar is a variable known to the checker, kind is reflect.Invalid if it cannot be inferred
+type checkVar struct {
+	kind reflect.Kind
+	typ  token.Token // type annotation, ILLEGAL if there is none
+}
+
+type checkScope struct {
+	vars   map[string]*checkVar
+	parent *checkScope
+}
+
+func (cs *checkScope) lookup(name string) (*checkVar, bool) {
+	for s := cs; s != nil; s = s.parent {
+		if cv, ok := s.vars[name]; ok {
+			return cv, s == cs
+		}
+	}
+	return nil, false
+}
+
+// checker walk the Cookfile and infer the kind of expressions from literals, type annotations and
+// the result type of built-in functions. The checker is conservative, a kind which cannot be known
+// before the Cookfile is executed is never reported.
+type checker struct {
+	cook  *cook
+	scope *checkScope
+	// result is the annotated result type of the function being checked
+	result token.Token
+	inFn   bool
+	errs   *cookErrors.CookError
+}
+
+// Check report mismatched types found in the Cookfile without executing it. The returned error
+// is a *errors.CookError holding every mismatch found.
+func (c *cook) Check() error {
+	ck := &checker{cook: c, scope: &checkScope{vars: make(map[string]*checkVar)}}
+	ck.block(c.Insts, false)
+	// global variables may be modified by any target or function thus only the annotated one
+	// has a known kind.
+	global := ck.typed()
+	targets := slices.Concat(c.initializeTargets, c.targetIndexes, c.finalizeTargets)
+	if c.targetAll != nil {
+		targets = append(targets, c.targetAll)
+	}
+	for _, t := range targets {
+		ck.scope = &checkScope{vars: make(map[string]*checkVar), parent: global}
+		for _, tp := range t.Params {
+			// a value given to a parameter is cast to its type, any value become an array of one element
+			if k := ck.expr(tp.Default); tp.Type != token.ILLEGAL && tp.Type != token.TARRAY && !castable(k, tp.Type.Kind()) {
+				ck.errorf(tp.Default, "cannot cast %s to %s", kindName(k), tp.Type)
+			}
+			ck.scope.vars[tp.Name.Name] = &checkVar{kind: tp.Type.Kind(), typ: tp.Type}
+		}
+		ck.block(t.Insts, false)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.fns)) {
+		ck.scope = global
+		ck.function(c.fns[name])
+	}
+	if ck.errs == nil {
+		return nil
+	}
+	return ck.errs
+}
+
+func (c *checker) errorf(n interface{ ErrPos() string }, format string, args ...any) {
+	if c.errs == nil {
+		c.errs = &cookErrors.CookError{}
+	}
+	c.errs.StackError(fmt.Errorf(n.ErrPos()+": "+format, args...))
+}
+
+// typed return a scope holding only the annotated variables visible from the current scope
+func (c *checker) typed() *checkScope {
+	ts := &checkScope{vars: make(map[string]*checkVar)}
+	for s := c.scope; s != nil; s = s.parent {
+		for name, cv := range s.vars {
+			if _, ok := ts.vars[name]; !ok && cv.typ != token.ILLEGAL {
+				ts.vars[name] = cv
+			}
+		}
+	}
+	return ts
+}
+
+// assignable report an error if value of kind k cannot be assigned to typ
+func (c *checker) assignable(n Node, typ token.Token, k reflect.Kind) bool {
+	switch tk := typ.Kind(); {
+	case typ == token.ILLEGAL || k == reflect.Invalid || tk == k:
+	case tk == reflect.Float64 && k == reflect.Int64:
+	default:
+		c.errorf(n, "cannot use %s value as %s", kindName(k), typ)
+		return false
+	}
+	return true
+}
+
+func (c *checker) function(fn *Function) {
+	scope, result, inFn := c.scope, c.result, c.inFn
+	defer func() { c.scope, c.result, c.inFn = scope, result, inFn }()
+	c.scope = &checkScope{vars: make(map[string]*checkVar), parent: c.typed()}
+	c.result, c.inFn = fn.Result, true
+	for i, arg := range fn.Args {
+		typ := fn.ArgType(i)
+		c.scope.vars[arg.Name] = &checkVar{kind: typ.Kind(), typ: typ}
+	}
+	if fn.Lambda == token.LAMBDA {
+		c.assignable(fn.X, fn.Result, c.expr(fn.X))
+	} else {
+		c.block(fn.Insts, false)
+	}
+}
+
+// block check the statements of a block, a nested block may not be executed or executed many
+// times thus variables of the enclosing scopes assigned within the block lose their kind.
+func (c *checker) block(bs *BlockStatement, nested bool) {
+	if bs == nil {
+		return
+	}
+	if nested {
+		c.scope = &checkScope{vars: make(map[string]*checkVar), parent: c.scope}
+		defer func() { c.scope = c.scope.parent }()
+		assigned(bs, func(name string) {
+			if cv, _ := c.scope.lookup(name); cv != nil && cv.typ == token.ILLEGAL {
+				cv.kind = reflect.Invalid
+			}
+		})
+	}
+	for _, stmt := range bs.Stmts {
+		c.statement(stmt)
+	}
+}
+
+func (c *checker) statement(stmt Statement) {
+	switch s := stmt.(type) {
+	case *BlockStatement:
+		c.block(s, true)
+	case *AssignStatement:
+		c.assign(s)
+	case *ExprWrapperStatement:
+		c.expr(s.X)
+	case *ReturnStatement:
+		if k := c.expr(s.X); c.inFn {
+			c.assignable(s.X, c.result, k)
+		}
+	case *IfStatement:
+		for s != nil {
+			if k := c.expr(s.Cond); k != reflect.Invalid && k != reflect.Bool {
+				c.errorf(s.Cond, "condition must be a boolean but got %s", kindName(k))
+			}
+			c.block(s.Insts, true)
+			if s.Else == nil {
+				break
+			} else if s.Else.IfStmt == nil {
+				c.block(s.Else.Insts, true)
+				break
+			}
+			s = s.Else.IfStmt
+		}
+	case *ForStatement:
+		ik := reflect.Invalid
+		if s.Range != nil {
+			c.expr(s.Range)
+			ik = reflect.Int64
+		} else if s.Oprnd != nil && c.expr(s.Oprnd) == reflect.Slice {
+			ik = reflect.Int64
+		}
+		c.scope = &checkScope{vars: make(map[string]*checkVar), parent: c.scope}
+		if s.I != nil {
+			c.scope.vars[s.I.Name] = &checkVar{kind: ik}
+		}
+		if s.Value != nil {
+			c.scope.vars[s.Value.Name] = &checkVar{}
+		}
+		c.block(s.Insts, true)
+		c.scope = c.scope.parent
+	case *SwitchStatement:
+		if s.X != nil {
+			c.expr(s.X)
+		}
+		for _, cc := range s.Cases {
+			c.block(cc.Insts, true)
+		}
+		c.block(s.Default, true)
+	case *WorkInStatement:
+		if k := c.expr(s.Dir); k != reflect.Invalid && k != reflect.String {
+			c.errorf(s.Dir, "working directory must be a string but got %s", kindName(k))
+		}
+		c.block(s.Insts, true)
+	case *WhileStatement:
+		c.block(s.Insts, true)
+		if k := c.expr(s.Cond); k != reflect.Invalid && k != reflect.Bool {
+			c.errorf(s.Cond, "condition must be a boolean but got %s", kindName(k))
+		}
+	case *RetryStatement:
+		if k := c.expr(s.Attempts); k != reflect.Invalid && k != reflect.Int64 {
+			c.errorf(s.Attempts, "retry attempts must be an integer but got %s", kindName(k))
+		}
+		for _, x := range []Node{s.Every, s.Timeout} {
+			switch k := c.expr(x); k {
+			case reflect.Invalid, reflect.Int64, reflect.Float64, reflect.String:
+			default:
+				c.errorf(x, "duration must be a string or a number but got %s", kindName(k))
+			}
+		}
+		c.block(s.Insts, true)
+	case *DeferStatement:
+		c.block(s.block(), true)
+	}
+}
+
+func (c *checker) assign(as *AssignStatement) {
+	k := c.expr(as.Value)
+	id, ok := as.Ident.(*Ident)
+	if !ok || strings.Contains(id.Name, ".") {
+		c.expr(as.Ident)
+		return
+	}
+	cv, local := c.scope.lookup(id.Name)
+	switch _, merge := as.Value.(*MergeMap); {
+	case as.Op == token.ASSIGN:
+	case cv == nil:
+		return
+	case merge:
+		k = cv.kind
+	case token.ADD_ASSIGN <= as.Op && as.Op <= token.REM_ASSIGN:
+		// compound assignment, e.g. A += 1
+		k = c.binary(as.Value, cv.kind, as.Op-(token.ADD_ASSIGN-token.ADD), k)
+	default:
+		k = reflect.Invalid
+	}
+	switch {
+	case as.Type != token.ILLEGAL:
+		if cv != nil && cv.typ != token.ILLEGAL && cv.typ != as.Type {
+			c.errorf(as, "variable %s is already declared as %s", id.Name, cv.typ)
+		} else if c.assignable(as.Value, as.Type, k) {
+			c.scope.vars[id.Name] = &checkVar{kind: as.Type.Kind(), typ: as.Type}
+		}
+	case cv != nil && cv.typ != token.ILLEGAL:
+		c.assignable(as.Value, cv.typ, k)
+	case cv != nil && local:
+		cv.kind = k
+	case cv != nil:
+		// value of the enclosing scope is assigned only if the block is executed
+		cv.kind = reflect.Invalid
+	default:
+		c.scope.vars[id.Name] = &checkVar{kind: k}
+	}
+}
+
+// expr check the expression n and return the kind of its value or reflect.Invalid if the kind is
+// unknown until the Cookfile is executed.
+func (c *checker) expr(n Node) reflect.Kind {
+	switch x := n.(type) {
+	case nil:
+	case *BasicLit:
+		return x.Kind.Kind()
+	case *StringInterpolation:
+		return reflect.String
+	case *Ident:
+		if cv, _ := c.scope.lookup(x.Name); cv != nil {
+			return cv.kind
+		}
+	case *Paren:
+		return c.expr(x.Inner)
+	case *Unary:
+		return c.unary(x)
+	case *Binary:
+		return c.binary(x, c.expr(x.L), x.Op, c.expr(x.R))
+	case *Conditional:
+		if k := c.expr(x.Cond); k != reflect.Invalid && k != reflect.Bool {
+			c.errorf(x.Cond, "condition must be a boolean but got %s", kindName(k))
+		}
+		if kt, kf := c.expr(x.True), c.expr(x.False); kt == kf {
+			return kt
+		}
+	case *Fallback:
+		c.expr(x.Primary)
+		c.expr(x.Default)
+	case *SizeOf:
+		c.expr(x.X)
+		return reflect.Int64
+	case *IsType:
+		c.expr(x.X)
+		return reflect.Bool
+	case *TypeCast:
+		if k, tk := c.expr(x.X), x.To.Kind(); !castable(k, tk) {
+			c.errorf(x, "cannot cast %s to %s", kindName(k), x.To)
+		} else {
+			return tk
+		}
+	case *ArrayLiteral:
+		for _, v := range x.Values {
+			c.expr(v)
+		}
+		return reflect.Slice
+	case *MapLiteral:
+		for i := range x.Keys {
+			c.expr(x.Keys[i])
+			c.expr(x.Values[i])
+		}
+		return reflect.Map
+	case *RecordLit:
+		for _, v := range x.Values {
+			c.expr(v)
+		}
+		return reflect.Struct
+	case *OSysCheck, *Exists:
+		return reflect.Bool
+	case *FuncLit:
+		c.function(x.Fn)
+		return reflect.Func
+	case *Index:
+		c.expr(x.X)
+		c.expr(x.Index)
+	case *Transformation:
+		c.function(x.Fn)
+	case *Collection:
+		c.expr(x.Init)
+		if x.Fn != nil {
+			c.function(x.Fn)
+		}
+	case *Call:
+		return c.call(x, false)
+	case *Pipe:
+		c.call(x.X, false)
+		if y, ok := x.Y.(*Call); ok {
+			return c.call(y, true)
+		}
+		c.expr(x.Y)
+	case *RedirectTo:
+		c.expr(x.Caller)
+	}
+	return reflect.Invalid
+}
+
+func (c *checker) unary(un *Unary) reflect.Kind {
+	k := c.expr(un.X)
+	switch un.Op {
+	case token.NOT:
+		return reflect.Bool
+	case token.FD:
+		return reflect.String
+	case token.ADD, token.SUB:
+		switch k {
+		case reflect.Invalid, reflect.String:
+			return reflect.Invalid
+		case reflect.Int64, reflect.Float64:
+			return k
+		}
+	case token.XOR:
+		if k == reflect.Invalid || k == reflect.Int64 {
+			return k
+		}
+	}
+	c.errorf(un, "operator %s is not supported for %s", un.Op, kindName(k))
+	return reflect.Invalid
+}
+
+// binary return the kind of the result of operator op, it mirror addOperator, numOperator and
+// logicOperator.
+func (c *checker) binary(n Node, kl reflect.Kind, op token.Token, kr reflect.Kind) reflect.Kind {
+	number := func(k reflect.Kind) bool { return k == reflect.Int64 || k == reflect.Float64 }
+	str := func(k reflect.Kind) bool { return number(k) || k == reflect.String }
+	switch {
+	case token.EQL <= op && op <= token.GEQ, op == token.LAND, op == token.LOR:
+		if kl == reflect.Invalid || kr == reflect.Invalid {
+			return reflect.Bool
+		}
+	case kl == reflect.Invalid || kr == reflect.Invalid:
+		return reflect.Invalid
+	}
+	switch {
+	case op == token.ADD:
+		switch {
+		case kl == reflect.Slice || kr == reflect.Slice:
+			return reflect.Slice
+		case kl == reflect.String || kr == reflect.String:
+			other := kl
+			if kl == reflect.String {
+				other = kr
+			}
+			if str(other) || other == reflect.Bool || other == reflect.Struct {
+				return reflect.String
+			}
+		case number(kl) && number(kr):
+			if kl == reflect.Float64 || kr == reflect.Float64 {
+				return reflect.Float64
+			}
+			return reflect.Int64
+		}
+	case token.ADD < op && op < token.LAND:
+		switch {
+		case kl == reflect.Float64 || kr == reflect.Float64:
+			if op < token.REM && str(kl) && str(kr) {
+				return reflect.Float64
+			}
+		case kl == reflect.Int64 || kr == reflect.Int64:
+			if kl != reflect.Float64 && kr != reflect.Float64 && str(kl) && str(kr) {
+				return reflect.Int64
+			}
+		}
+	case token.EQL <= op && op <= token.GEQ:
+		switch {
+		case number(kl) && number(kr), kl == kr && (kl == reflect.String || op == token.EQL || op == token.NEQ):
+			return reflect.Bool
+		}
+	case op == token.LAND || op == token.LOR:
+		if kl == reflect.Bool && kr == reflect.Bool {
+			return reflect.Bool
+		}
+	}
+	c.errorf(n, "operator %s is not supported for %s and %s", op, kindName(kl), kindName(kr))
+	return reflect.Invalid
+}
+
+// call check the arguments of a function declared in Cookfile and return the kind of its result.
+// The result of a built-in function is given by its flags. A piped call receive an extra argument
+// from the previous call.
+func (c *checker) call(call *Call, piped bool) reflect.Kind {
+	kinds := make([]reflect.Kind, len(call.Args))
+	for i, arg := range call.Args {
+		if na, ok := arg.(*NamedArg); ok {
+			c.expr(na.X)
+		} else {
+			kinds[i] = c.expr(arg)
+		}
+	}
+	if call.FuncLit != nil {
+		c.function(call.FuncLit)
+		return call.FuncLit.Result.Kind()
+	} else if call.Kind != token.AT {
+		return reflect.String
+	}
+	// the same priority as Call Evaluate, function value, target, built-in then declared function
+	if cv, _ := c.scope.lookup(call.Name); cv != nil || strings.Contains(call.Name, ".") {
+		return reflect.Invalid
+	} else if _, ok := c.cook.targets[call.Name]; ok {
+		return reflect.Invalid
+	} else if f := function.GetFunction(call.Name); f != nil {
+		return f.Flags().Return
+	}
+	fn := c.cook.fns[call.Name]
+	if fn == nil {
+		return reflect.Invalid
+	}
+	if n := len(call.Args); !piped && n != len(fn.Args) {
+		c.errorf(call, "function %s require %d argument but got %d", fn.Name, len(fn.Args), n)
+	}
+	for i, arg := range call.Args {
+		c.assignable(arg, fn.ArgType(i), kinds[i])
+	}
+	return fn.Result.Kind()
+}
+
+// castable report whether a value of kind k may be cast to kind tk, see castValue
+func castable(k, tk reflect.Kind) bool {
+	switch {
+	case k == reflect.Invalid, k == tk:
+		return true
+	case tk == reflect.Int64, tk == reflect.Float64:
+		return k == reflect.Int64 || k == reflect.Float64 || k == reflect.String
+	case tk == reflect.Bool:
+		return k == reflect.String
+	case tk == reflect.String:
+		return k == reflect.Int64 || k == reflect.Float64 || k == reflect.Bool || k == reflect.Struct
+	}
+	return false
+}
+
+// assigned call fn with the name of each variable assigned within the block and its nested blocks
+func assigned(bs *BlockStatement, fn func(name string)) {
+	if bs == nil {
+		return
+	}
+	for _, stmt := range bs.Stmts {
+		switch s := stmt.(type) {
+		case *BlockStatement:
+			assigned(s, fn)
+		case *AssignStatement:
+			fn(s.Ident.VariableName())
+		case *ExprWrapperStatement:
+			if idc, ok := s.X.(*IncDec); ok {
+				if id, ok := idc.X.(*Ident); ok {
+					fn(id.Name)
+				}
+			}
+		case *IfStatement:
+			for s != nil {
+				assigned(s.Insts, fn)
+				if s.Else == nil {
+					break
+				}
+				assigned(s.Else.Insts, fn)
+				s = s.Else.IfStmt
+			}
+		case *ForStatement:
+			assigned(s.Insts, fn)
+		case *SwitchStatement:
+			for _, cc := range s.Cases {
+				assigned(cc.Insts, fn)
+			}
+			assigned(s.Default, fn)
+		case *WorkInStatement:
+			assigned(s.Insts, fn)
+		case *WhileStatement:
+			assigned(s.Insts, fn)
+		case *RetryStatement:
+			assigned(s.Insts, fn)
+		case *DeferStatement:
+			assigned(s.block(), fn)
+		}
+	}
+}
+
+// kindName return the name of the Cook type of kind k
+func kindName(k reflect.Kind) string {
+	switch k {
+	case reflect.Int64:
+		return "integer"
+	case reflect.Float64:
+		return "float"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		return "array"
+	case reflect.Map:
+		return "map"
+	case reflect.Struct:
+		return "record"
+	case reflect.Func:
+		return "function"
+	}
+	return "unknown"
+}
@@ -87,7 +87,9 @@ func (it *IsType) String() string              { return codeOf(it) }
 func (tc *TypeCast) String() string            { return codeOf(tc) }
 func (e *Exit) String() string                 { return codeOf(e) }
 func (al *ArrayLiteral) String() string        { return codeOf(al) }
+func (g *Glob) String() string                 { return codeOf(g) }
 func (ml *MapLiteral) String() string          { return codeOf(ml) }
+func (rl *RecordLit) String() string           { return codeOf(rl) }
 func (mm *MergeMap) String() string            { return codeOf(mm) }
 func (d *Delete) String() string               { return codeOf(d) }
 func (ix *Index) String() string               { return codeOf(ix) }
@@ -96,6 +98,8 @@ func (r *Interval) String() string             { return codeOf(r) }
 func (osc *OSysCheck) String() string          { return codeOf(osc) }
 func (e *Exists) String() string               { return codeOf(e) }
 func (c *Call) String() string                 { return codeOf(c) }
+func (na *NamedArg) String() string            { return codeOf(na) }
+func (fl *FuncLit) String() string             { return codeOf(fl) }
 func (pp *Pipe) String() string                { return codeOf(pp) }
 func (rf *ReadFrom) String() string            { return codeOf(rf) }
 func (rt *RedirectTo) String() string          { return codeOf(rt) }
@@ -139,14 +143,24 @@ func (sf *SizeOf) Visit(cb CodeBuilder) {
 }
 
 func (it *IsType) Visit(cb CodeBuilder) {
-	it.X.Visit(cb)
-	cb.WriteString(" is ")
+	// X is nil when use as a pattern of switch case
+	if it.X != nil {
+		it.X.Visit(cb)
+		cb.WriteByte(' ')
+	}
+	cb.WriteString("is ")
 	for i, t := range it.Types {
 		if i > 0 {
 			cb.WriteString(" | ")
 		}
 		cb.WriteString(t.String())
 	}
+	for i, name := range it.Records {
+		if i > 0 || len(it.Types) > 0 {
+			cb.WriteString(" | ")
+		}
+		cb.WriteString(name)
+	}
 }
 
 func (tc *TypeCast) Visit(cb CodeBuilder) {
@@ -185,6 +199,13 @@ func (al *ArrayLiteral) Visit(cb CodeBuilder) {
 	}
 }
 
+func (g *Glob) Visit(cb CodeBuilder) {
+	if g.Strict {
+		cb.WriteByte('!')
+	}
+	g.Pattern.Visit(cb)
+}
+
 func (ml *MapLiteral) Visit(cb CodeBuilder) {
 	cb.WriteByte('{')
 	if ml.Multiline {
@@ -212,6 +233,20 @@ func (ml *MapLiteral) Visit(cb CodeBuilder) {
 	cb.WriteByte('}')
 }
 
+func (rl *RecordLit) Visit(cb CodeBuilder) {
+	cb.WriteString(rl.Name)
+	cb.WriteByte('{')
+	for i, f := range rl.Fields {
+		if i > 0 {
+			cb.WriteString(", ")
+		}
+		f.Visit(cb)
+		cb.WriteString(": ")
+		rl.Values[i].Visit(cb)
+	}
+	cb.WriteByte('}')
+}
+
 func (mm *MergeMap) Visit(cb CodeBuilder) {
 	if mm.Op != token.ILLEGAL {
 		cb.WriteString(mm.Op.String())
@@ -279,6 +314,8 @@ func (e *Exists) Visit(cb CodeBuilder) {
 	cb.WriteString(" exists")
 }
 
+func (fl *FuncLit) Visit(cb CodeBuilder) { fl.Fn.Visit(cb) }
+
 func (c *Call) Visit(cb CodeBuilder) {
 	cb.WriteString(c.Kind.String())
 	cb.WriteString(c.Name)
@@ -293,6 +330,12 @@ func (c *Call) Visit(cb CodeBuilder) {
 	}
 }
 
+func (na *NamedArg) Visit(cb CodeBuilder) {
+	cb.WriteString(na.Name)
+	cb.WriteString(": ")
+	na.X.Visit(cb)
+}
+
 func (pp *Pipe) Visit(cb CodeBuilder) {
 	pp.X.Visit(cb)
 	if pp.Y != nil {
@@ -391,6 +434,12 @@ func (as *AssignStatement) String() string         { return codeOf(as) }
 func (bs *BlockStatement) String() string          { return codeOf(bs) }
 func (ews *ExprWrapperStatement) String() string   { return codeOf(ews) }
 func (rs *ReturnStatement) String() string         { return codeOf(rs) }
+func (wis *WorkInStatement) String() string        { return codeOf(wis) }
+func (sst *SwitchStatement) String() string        { return codeOf(sst) }
+func (cc *CaseClause) String() string              { return codeOf(cc) }
+func (ds *DeferStatement) String() string          { return codeOf(ds) }
+func (ws *WhileStatement) String() string          { return codeOf(ws) }
+func (rs *RetryStatement) String() string          { return codeOf(rs) }
 
 func (fst *ForStatement) Visit(cb CodeBuilder) {
 	cb.WriteString("for")
@@ -415,6 +464,27 @@ func (fst *ForStatement) Visit(cb CodeBuilder) {
 	fst.Insts.Visit(cb)
 }
 
+func (ws *WhileStatement) Visit(cb CodeBuilder) {
+	if ws.Do {
+		cb.WriteString("do")
+	} else {
+		cb.WriteString("while")
+	}
+	if ws.Label != "" {
+		cb.WriteByte(':')
+		cb.WriteString(ws.Label)
+	}
+	if ws.Do {
+		ws.Insts.Visit(cb)
+		cb.WriteString(" while ")
+		ws.Cond.Visit(cb)
+	} else {
+		cb.WriteByte(' ')
+		ws.Cond.Visit(cb)
+		ws.Insts.Visit(cb)
+	}
+}
+
 func (ifst *IfStatement) Visit(cb CodeBuilder) {
 	cb.WriteString("if ")
 	ifst.Cond.Visit(cb)
@@ -424,6 +494,81 @@ func (ifst *IfStatement) Visit(cb CodeBuilder) {
 	}
 }
 
+func (wis *WorkInStatement) Visit(cb CodeBuilder) {
+	cb.WriteString("workin ")
+	wis.Dir.Visit(cb)
+	wis.Insts.Visit(cb)
+}
+
+func (rs *RetryStatement) Visit(cb CodeBuilder) {
+	cb.WriteString("retry ")
+	rs.Attempts.Visit(cb)
+	if rs.Every != nil {
+		cb.WriteString(" every ")
+		rs.Every.Visit(cb)
+	}
+	if rs.Timeout != nil {
+		cb.WriteString(" timeout ")
+		rs.Timeout.Visit(cb)
+	}
+	rs.Insts.Visit(cb)
+}
+
+func (ds *DeferStatement) Visit(cb CodeBuilder) {
+	cb.WriteString("defer")
+	if _, ok := ds.Stmt.(*BlockStatement); !ok {
+		cb.WriteByte(' ')
+	}
+	ds.Stmt.Visit(cb)
+}
+
+func (sst *SwitchStatement) Visit(cb CodeBuilder) {
+	cb.WriteString("switch ")
+	if sst.X != nil {
+		sst.X.Visit(cb)
+		cb.WriteByte(' ')
+	}
+	cb.WriteString("{\n")
+	for _, cc := range sst.Cases {
+		cc.Visit(cb)
+	}
+	if sst.Default != nil {
+		cb.WriteIndent()
+		cb.WriteString("default:\n")
+		visitCaseBody(cb, sst.Default)
+	}
+	cb.WriteIndent()
+	cb.WriteByte('}')
+}
+
+func (cc *CaseClause) Visit(cb CodeBuilder) {
+	cb.WriteIndent()
+	cb.WriteString("case ")
+	for i, pattern := range cc.Patterns {
+		if i > 0 {
+			cb.WriteString(", ")
+		}
+		if r, ok := pattern.(*Interval); ok {
+			cb.WriteString("in ")
+			r.Visit(cb)
+		} else {
+			pattern.Visit(cb)
+		}
+	}
+	cb.WriteString(":\n")
+	visitCaseBody(cb, cc.Insts)
+}
+
+func visitCaseBody(cb CodeBuilder, bs *BlockStatement) {
+	cb.IdentBy(1)
+	for _, stmt := range bs.Stmts {
+		cb.WriteIndent()
+		stmt.Visit(cb)
+		cb.WriteByte('\n')
+	}
+	cb.IdentBy(-1)
+}
+
 func (efst *ElseStatement) Visit(cb CodeBuilder) {
 	cb.WriteString(" else")
 	if efst.IfStmt != nil {
@@ -457,6 +602,10 @@ func (bs *BlockStatement) Visit(cb CodeBuilder) {
 
 func (as *AssignStatement) Visit(cb CodeBuilder) {
 	as.Ident.Visit(cb)
+	if as.Type != token.ILLEGAL {
+		cb.WriteString(": ")
+		cb.WriteString(as.Type.String())
+	}
 	cb.WriteByte(' ')
 	cb.WriteString(as.Op.String())
 	cb.WriteByte(' ')
@@ -485,6 +634,9 @@ func (t *Target) String() string    { return codeOf(t) }
 func (fn *Function) String() string { return codeOf(fn) }
 
 func (c *cook) Visit(cb CodeBuilder) {
+	for _, rt := range c.recordIndexes {
+		rt.Visit(cb)
+	}
 	c.Insts.Visit(cb)
 	// TODO: how to handle multiple file ??
 	for _, t := range c.initializeTargets {
@@ -513,6 +665,16 @@ func (t *Target) Visit(cb CodeBuilder) {
 		cb.WriteByte('\n')
 	}
 	cb.WriteString(t.name)
+	if len(t.Params) > 0 {
+		cb.WriteByte('(')
+		for i, tp := range t.Params {
+			if i > 0 {
+				cb.WriteString(", ")
+			}
+			tp.Visit(cb)
+		}
+		cb.WriteByte(')')
+	}
 	if t.all {
 		cb.WriteString(": *\n")
 	} else {
@@ -522,6 +684,49 @@ func (t *Target) Visit(cb CodeBuilder) {
 	}
 }
 
+func (tp *TargetParam) Visit(cb CodeBuilder) {
+	tp.Name.Visit(cb)
+	if tp.Type != token.ILLEGAL {
+		cb.WriteString(": ")
+		cb.WriteString(tp.Type.String())
+	}
+	if tp.Default != nil {
+		cb.WriteString(" = ")
+		tp.Default.Visit(cb)
+	}
+}
+
+func (rt *RecordType) Visit(cb CodeBuilder) {
+	cb.WriteString("record ")
+	cb.WriteString(rt.Name)
+	cb.WriteString(" {")
+	for i, f := range rt.Fields {
+		if i > 0 {
+			cb.WriteByte(',')
+		}
+		cb.WriteByte(' ')
+		f.Visit(cb)
+	}
+	cb.WriteString(" }\n")
+}
+
+func (rf *RecordField) Visit(cb CodeBuilder) {
+	rf.Name.Visit(cb)
+	switch rf.Type {
+	case token.ILLEGAL:
+	case token.IDENT:
+		cb.WriteString(": ")
+		cb.WriteString(rf.Record)
+	default:
+		cb.WriteString(": ")
+		cb.WriteString(rf.Type.String())
+	}
+	if rf.Default != nil {
+		cb.WriteString(" = ")
+		rf.Default.Visit(cb)
+	}
+}
+
 func (fn *Function) Visit(cb CodeBuilder) {
 	if fn.Name != "" {
 		cb.WriteString(fn.Name)
@@ -532,8 +737,16 @@ func (fn *Function) Visit(cb CodeBuilder) {
 			cb.WriteString(", ")
 		}
 		arg.Visit(cb)
+		if typ := fn.ArgType(i); typ != token.ILLEGAL {
+			cb.WriteString(": ")
+			cb.WriteString(typ.String())
+		}
 	}
 	cb.WriteByte(')')
+	if fn.Result != token.ILLEGAL {
+		cb.WriteString(": ")
+		cb.WriteString(fn.Result.String())
+	}
 	if fn.Lambda == token.LAMBDA {
 		cb.WriteString(" => ")
 		fn.X.Visit(cb)
@@ -0,0 +1,423 @@
+package ast
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cozees/cook/pkg/cook/token"
+)
+
+// CollectionOp is an operation apply on each element of an array or a map
+type CollectionOp string
+
+const (
+	Filter  CollectionOp = "filter"
+	Reduce  CollectionOp = "reduce"
+	Sort    CollectionOp = "sort"
+	Group   CollectionOp = "group"
+	Flatten CollectionOp = "flatten"
+	Zip     CollectionOp = "zip"
+	Any     CollectionOp = "any"
+	All     CollectionOp = "all"
+)
+
+// IsCollectionOp return true if name is one of collection operation
+func IsCollectionOp(name string) bool {
+	switch CollectionOp(name) {
+	case Filter, Reduce, Sort, Group, Flatten, Zip, Any, All:
+		return true
+	}
+	return false
+}
+
+// A node represent a collection operation such as "filter A(i, v) => v > 2"
+type Collection struct {
+	*Base
+	Op   CollectionOp
+	X    []*Ident
+	Init Node // initial value of reduce accumulator
+	Fn   *Function
+}
+
+func (c *Collection) String() string { return codeOf(c) }
+
+func (c *Collection) Visit(cb CodeBuilder) {
+	cb.WriteString(string(c.Op))
+	for _, x := range c.X {
+		cb.WriteByte(' ')
+		x.Visit(cb)
+	}
+	switch {
+	case c.Fn == nil:
+		return
+	case c.Init == nil:
+		c.Fn.Visit(cb)
+		return
+	}
+	cb.WriteByte('(')
+	for i, arg := range c.Fn.Args {
+		if i > 0 {
+			cb.WriteString(", ")
+		}
+		arg.Visit(cb)
+		if i == 0 {
+			cb.WriteString(" = ")
+			c.Init.Visit(cb)
+		} else if typ := c.Fn.ArgType(i); typ != token.ILLEGAL {
+			cb.WriteString(": ")
+			cb.WriteString(typ.String())
+		}
+	}
+	cb.WriteByte(')')
+	if c.Fn.Result != token.ILLEGAL {
+		cb.WriteString(": ")
+		cb.WriteString(c.Fn.Result.String())
+	}
+	if c.Fn.Lambda == token.LAMBDA {
+		cb.WriteString(" => ")
+		c.Fn.X.Visit(cb)
+	} else {
+		c.Fn.Insts.Visit(cb)
+	}
+}
+
+// element is an index or key and its value of an array or a map
+type element struct {
+	key   any
+	kkind reflect.Kind
+	val   any
+	vkind reflect.Kind
+}
+
+func kindOf(v reflect.Value) reflect.Kind {
+	if v.Kind() == reflect.Interface {
+		return v.Elem().Kind()
+	}
+	return v.Kind()
+}
+
+// eachElement call fn on each element of an array, a map or a transformation of them until fn return true.
+func eachElement(ctx Context, v any, vk reflect.Kind, fn func(e *element) (bool, error)) error {
+	switch vk {
+	case reflect.Slice:
+		vv := reflect.ValueOf(v)
+		for i := 0; i < vv.Len(); i++ {
+			iv := vv.Index(i)
+			if stop, err := fn(&element{int64(i), reflect.Int64, iv.Interface(), kindOf(iv)}); err != nil || stop {
+				return err
+			}
+		}
+	case reflect.Map:
+		vv := reflect.ValueOf(v)
+		for _, key := range vv.MapKeys() {
+			iv := vv.MapIndex(key)
+			if stop, err := fn(&element{key.Interface(), kindOf(key), iv.Interface(), kindOf(iv)}); err != nil || stop {
+				return err
+			}
+		}
+	case TransformSlice, TransformMap:
+		it := v.(*iTransform)
+		var keys []any
+		if vk == TransformMap {
+			keys = it.Keys()
+		} else {
+			keys = make([]any, it.Len())
+			for i := range keys {
+				keys[i] = int64(i)
+			}
+		}
+		for _, key := range keys {
+			val, kind, err := it.Transform(ctx, key)
+			if err != nil {
+				return err
+			}
+			if stop, err := fn(&element{key, reflect.ValueOf(key).Kind(), val, kind}); err != nil || stop {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("collection operation can only apply on array or map variable")
+	}
+	return nil
+}
+
+// call execute the function of the operation with the given element, if acc is not nil
+// it is given as the first argument.
+func (c *Collection) call(ctx Context, acc, e *element) (any, reflect.Kind, error) {
+	numArgs := 2
+	if acc != nil {
+		numArgs++
+	}
+	v, k, err := c.Fn.internalExecute(ctx, numArgs, func(i int) (any, reflect.Kind, error) {
+		if acc != nil {
+			if i == 0 {
+				return acc.val, acc.vkind, nil
+			}
+			i--
+		}
+		if i == 0 {
+			return e.key, e.kkind, nil
+		}
+		return e.val, e.vkind, nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", c.ErrPos(), err)
+	}
+	return v, k, nil
+}
+
+// predicate execute the function which must return a boolean
+func (c *Collection) predicate(ctx Context, e *element) (bool, error) {
+	v, _, err := c.call(ctx, nil, e)
+	if err != nil {
+		return false, err
+	} else if b, ok := v.(bool); !ok {
+		return false, fmt.Errorf("%s: function of %s must return a boolean, got %v", c.ErrPos(), c.Op, v)
+	} else {
+		return b, nil
+	}
+}
+
+func (c *Collection) Evaluate(ctx Context) (any, reflect.Kind, error) {
+	if c.Op == Zip {
+		return c.zip(ctx)
+	}
+	v, vk, err := c.X[0].Evaluate(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	isMap := vk == reflect.Map || vk == TransformMap
+	switch c.Op {
+	case Filter:
+		var result any
+		if isMap {
+			m := make(map[any]any)
+			result = m
+			err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+				ok, err := c.predicate(ctx, e)
+				if ok {
+					m[e.key] = e.val
+				}
+				return false, err
+			})
+		} else {
+			a := make([]any, 0)
+			err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+				ok, err := c.predicate(ctx, e)
+				if ok {
+					a = append(a, e.val)
+				}
+				return false, err
+			})
+			result = a
+		}
+		if err != nil {
+			return nil, 0, err
+		} else if isMap {
+			return result, reflect.Map, nil
+		}
+		return result, reflect.Slice, nil
+	case Reduce:
+		var acc *element
+		if c.Init != nil {
+			iv, ik, err := c.Init.Evaluate(ctx)
+			if err != nil {
+				return nil, 0, err
+			}
+			acc = &element{val: iv, vkind: ik}
+		}
+		err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+			if acc == nil {
+				// without initial value the first element is the accumulator
+				acc = &element{val: e.val, vkind: e.vkind}
+				return false, nil
+			}
+			v, k, err := c.call(ctx, acc, e)
+			acc.val, acc.vkind = v, k
+			return false, err
+		})
+		if err != nil {
+			return nil, 0, err
+		} else if acc == nil {
+			return nil, 0, fmt.Errorf("%s: reduce on empty array or map require an initial value", c.ErrPos())
+		}
+		return acc.val, acc.vkind, nil
+	case Sort:
+		type sortKey struct {
+			key any
+			val any
+		}
+		var keys []*sortKey
+		err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+			k, _, err := c.call(ctx, nil, e)
+			keys = append(keys, &sortKey{k, e.val})
+			return false, err
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		slices.SortStableFunc(keys, func(a, b *sortKey) int {
+			if r, cerr := compareKey(a.key, b.key); cerr != nil {
+				err = cerr
+				return 0
+			} else {
+				return r
+			}
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", c.ErrPos(), err)
+		}
+		result := make([]any, len(keys))
+		for i, sk := range keys {
+			result[i] = sk.val
+		}
+		return result, reflect.Slice, nil
+	case Group:
+		result := make(map[any]any)
+		err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+			k, kk, err := c.call(ctx, nil, e)
+			if err != nil {
+				return false, err
+			}
+			switch kk {
+			case reflect.Int64, reflect.Float64, reflect.String, reflect.Bool:
+			default:
+				return false, fmt.Errorf("%s: group key %v is not an integer, float, string or boolean", c.ErrPos(), k)
+			}
+			if g, ok := result[k]; ok {
+				result[k] = append(g.([]any), e.val)
+			} else {
+				result[k] = []any{e.val}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return result, reflect.Map, nil
+	case Flatten:
+		result := make([]any, 0)
+		err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+			val, kind := e.val, e.vkind
+			if c.Fn != nil {
+				if val, kind, err = c.call(ctx, nil, e); err != nil {
+					return false, err
+				}
+			}
+			if kind == reflect.Slice || kind == TransformSlice {
+				return false, eachElement(ctx, val, kind, func(ie *element) (bool, error) {
+					result = append(result, ie.val)
+					return false, nil
+				})
+			}
+			result = append(result, val)
+			return false, nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return result, reflect.Slice, nil
+	case Any, All:
+		// any stop at the first true while all stop at the first false
+		found := c.Op == All
+		err = eachElement(ctx, v, vk, func(e *element) (bool, error) {
+			ok, err := c.predicate(ctx, e)
+			if err == nil && ok == (c.Op == Any) {
+				found = ok
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return found, reflect.Bool, nil
+	default:
+		panic("cook internal error: unknown collection operation " + string(c.Op))
+	}
+}
+
+// zip combine element at the same index of each array into an array, the result is a transform
+// array which is evaluate lazily thus it reflect later change of the original arrays.
+func (c *Collection) zip(ctx Context) (any, reflect.Kind, error) {
+	its := make([]*iTransform, len(c.X))
+	for i, x := range c.X {
+		v, vk, err := x.Evaluate(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch vk {
+		case reflect.Slice:
+			vv := reflect.ValueOf(v)
+			its[i] = &iTransform{
+				Len: vv.Len,
+				Source: func(ctx Context, i any) (any, reflect.Kind, error) {
+					iv := vv.Index(int(i.(int64)))
+					return iv.Interface(), kindOf(iv), nil
+				},
+				Value: func(ctx Context, i, val any) (any, reflect.Kind, error) {
+					return val, reflect.ValueOf(val).Kind(), nil
+				},
+			}
+		case TransformSlice:
+			its[i] = v.(*iTransform)
+		default:
+			return nil, 0, fmt.Errorf("%s: zip operand %s is not an array", c.ErrPos(), x.Name)
+		}
+	}
+	length := func() int {
+		n := its[0].Len()
+		for _, it := range its[1:] {
+			n = min(n, it.Len())
+		}
+		return n
+	}
+	return &iTransform{
+		Len: length,
+		Source: func(ctx Context, i any) (any, reflect.Kind, error) {
+			if ind := i.(int64); ind < 0 || ind >= int64(length()) {
+				return nil, 0, fmt.Errorf("index %d out of range", ind)
+			}
+			result := make([]any, len(its))
+			for j, it := range its {
+				v, _, err := it.Transform(ctx, i)
+				if err != nil {
+					return nil, 0, err
+				}
+				result[j] = v
+			}
+			return result, reflect.Slice, nil
+		},
+		Value: func(ctx Context, i, val any) (any, reflect.Kind, error) {
+			return val, reflect.Slice, nil
+		},
+	}, TransformSlice, nil
+}
+
+// compareKey compare sort key a and b which must be both number or string
+func compareKey(a, b any) (int, error) {
+	switch av := a.(type) {
+	case int64:
+		switch bv := b.(type) {
+		case int64:
+			return cmp.Compare(av, bv), nil
+		case float64:
+			return cmp.Compare(float64(av), bv), nil
+		}
+	case float64:
+		switch bv := b.(type) {
+		case int64:
+			return cmp.Compare(av, float64(bv)), nil
+		case float64:
+			return cmp.Compare(av, bv), nil
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), nil
+		}
+	}
+	return 0, fmt.Errorf("sort key %v and %v are not comparable", a, b)
+}
@@ -38,6 +38,19 @@ func stringOf(ctx Context, ns ...Node) (ses []string, err error) {
 	return
 }
 
+// assignable return v if it's a value of type typ, an integer is widened to a float. Any value is
+// accepted if typ is ILLEGAL.
+func assignable(typ token.Token, v any, k reflect.Kind) (any, reflect.Kind, error) {
+	switch tk := typ.Kind(); {
+	case typ == token.ILLEGAL || tk == k:
+		return v, k, nil
+	case tk == reflect.Float64 && k == reflect.Int64:
+		return float64(v.(int64)), tk, nil
+	default:
+		return nil, 0, fmt.Errorf("value %v is not a %s", v, typ)
+	}
+}
+
 func convertToNum(s string) (any, reflect.Kind, error) {
 	if iv, err := strconv.ParseInt(s, 10, 64); err == nil {
 		return iv, reflect.Int64, nil
@@ -84,6 +97,12 @@ func convertToString(ctx Context, val any, kind reflect.Kind) (string, error) {
 		return strconv.FormatBool(val.(bool)), nil
 	case reflect.String:
 		return val.(string), nil
+	case reflect.Struct:
+		if r, ok := val.(Record); ok {
+			b, err := r.MarshalJSON()
+			return string(b), err
+		}
+		return "", fmt.Errorf("value %v cannot cast to string", val)
 	default:
 		if b, ok := val.([]byte); ok {
 			return string(b), nil
@@ -1,10 +1,13 @@
 package ast
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/cozees/cook/pkg/runtime/function"
 )
@@ -27,6 +30,16 @@ type xScope struct {
 	hasChild     bool
 	returnResult *ivar
 	vars         map[string]*ivar
+	// target is true if the scope is the top scope of a target
+	target bool
+	// function is true if the scope is the top scope of a function call
+	function bool
+	// module is the Cookfile or library which own the root scope
+	module *cook
+	// functions to be called when the scope exit
+	exits []func() error
+	// copied is true if vars is a copy of some variables of the parents, see snapshot
+	copied bool
 }
 
 func (xs *xScope) GetVariable(name string) (value any, kind reflect.Kind, fromEnv bool) {
@@ -50,12 +63,17 @@ tryEnv:
 
 func (xs *xScope) SetVariable(name string, value any, kind reflect.Kind, bubble func(v any, k reflect.Kind) error) bool {
 	switch kind {
-	case reflect.Int64, reflect.Float64, reflect.Bool, reflect.String, reflect.Slice, reflect.Map, TransformSlice, TransformMap:
+	case reflect.Int64, reflect.Float64, reflect.Bool, reflect.String, reflect.Slice, reflect.Map, reflect.Func, reflect.Struct, TransformSlice, TransformMap:
 	default:
 		panic(fmt.Sprintf("cook internal error: variable '%s' value: %v has an invalid type %s", name, value, kind))
 	}
 
-	if iv, ok := xs.vars[name]; ok {
+	if xs.copied {
+		if iv, ok := xs.vars[name]; ok {
+			iv.value, iv.kind = value, kind
+		}
+		return xs.parent.SetVariable(name, value, kind, bubble)
+	} else if iv, ok := xs.vars[name]; ok {
 		iv.value, iv.kind = value, kind
 		if iv.bubble != nil {
 			iv.bubble(value, kind)
@@ -69,8 +87,26 @@ func (xs *xScope) SetVariable(name string, value any, kind reflect.Kind, bubble
 	return true
 }
 
+// snapshot return a child scope of xs holding a copy of the given variables, the later changes of
+// the variables are not seen through the child scope while an assignment made through the child
+// scope is still applied to the variable of xs.
+func (xs *xScope) snapshot(names []string) *xScope {
+	cp := &xScope{parent: xs, vars: make(map[string]*ivar, len(names)), copied: true}
+	for _, name := range names {
+		if v, k, _ := xs.GetVariable(name); k != reflect.Invalid {
+			cp.vars[name] = &ivar{value: v, kind: k}
+		}
+	}
+	return cp
+}
+
 func (xs *xScope) SetReturnValue(v any, kind reflect.Kind) {
-	xs.returnResult = &ivar{value: v, kind: kind}
+	// return statement may be inside a nested block of the function
+	scope := xs
+	for !scope.function && scope.parent != nil {
+		scope = scope.parent
+	}
+	scope.returnResult = &ivar{value: v, kind: kind}
 }
 
 func (xs *xScope) GetReturnValue() (v any, kind reflect.Kind) {
@@ -83,8 +119,22 @@ func (xs *xScope) GetReturnValue() (v any, kind reflect.Kind) {
 
 type Context interface {
 	Scope
+	function.Runtime
 	EnterBlock(forLoop bool, loopLabel string) (Scope, int)
 	ExitBlock(index int)
+	EnterDir(dir string)
+	ExitDir()
+	// Capture return the current scope to be captured by a function literal
+	Capture() Scope
+	// EnterScope make scope the current scope until the returned function is called
+	EnterScope(scope Scope) (restore func())
+	// EnterContext make goctx the context returned by Context until the returned function is called
+	EnterContext(goctx context.Context) (restore func())
+	// Release let another goroutine execute statements until the returned function is called
+	Release() (acquire func())
+	// Defer register fn to be called when the enclosing target or function exit, fn see the value
+	// of vars and the working directory as of the time it is registered
+	Defer(vars []string, fn func() error)
 	ShouldBreak(fromLoop bool) bool
 	ResetBreakContinue()
 	Break(label string) error
@@ -92,6 +142,7 @@ type Context interface {
 	GetCommand(name string) function.Function
 	GetTarget(name string) *Target
 	GetFunction(name string) *Function
+	GetRecord(name string) *RecordType
 }
 
 type xContext struct {
@@ -102,9 +153,22 @@ type xContext struct {
 	continueAt int
 	breakAt    int
 	loops      []int
+	// working directory of workin block, the last one is the current
+	dirs []string
+	// cancel the execution of statement and external command
+	goctx context.Context
+	// locked is true if the context hold the lock of the cook while executing statements
+	locked bool
 }
 
 func (xc *xContext) GetVariable(name string) (value any, kind reflect.Kind, fromEnv bool) {
+	// qualified name of an imported library, e.g. go.VERSION
+	if ns, lname, ok := strings.Cut(name, "."); ok {
+		if m := xc.module().modules[ns]; m != nil {
+			value, kind = m.export(lname)
+		}
+		return
+	}
 	return xc.scope.GetVariable(name)
 }
 
@@ -120,16 +184,49 @@ func (xc *xContext) GetReturnValue() (v any, kind reflect.Kind) {
 	return xc.scope.GetReturnValue()
 }
 
-func (xc *xContext) GetFunction(name string) *Function        { return xc.cook.fns[name] }
+func (xc *xContext) GetFunction(name string) *Function        { return xc.module().fns[name] }
 func (xc *xContext) GetCommand(name string) function.Function { return function.GetFunction(name) }
 func (xc *xContext) GetTarget(name string) *Target {
-	if ind, ok := xc.cook.targets[name]; ok && len(xc.cook.targetIndexes) > 0 {
-		return xc.cook.targetIndexes[ind]
+	m := xc.module()
+	// qualified name of a target of an imported library, e.g. go.build
+	if ns, lname, ok := strings.Cut(name, "."); ok {
+		if m = m.modules[ns]; m == nil || !isExported(lname) {
+			return nil
+		}
+		name = lname
+	}
+	if ind, ok := m.targets[name]; ok && len(m.targetIndexes) > 0 {
+		return m.targetIndexes[ind]
 	} else {
 		return nil
 	}
 }
 
+func (xc *xContext) GetRecord(name string) *RecordType {
+	m := xc.module()
+	// qualified name of a record of an imported library, e.g. go.Package
+	if ns, lname, ok := strings.Cut(name, "."); ok {
+		if m = m.modules[ns]; m == nil || !isExported(lname) {
+			return nil
+		}
+		name = lname
+	}
+	return m.records[name]
+}
+
+// module return the Cookfile or imported library which the current scope belong to, the statement
+// of a library function or target is executed within a child of the library root scope.
+func (xc *xContext) module() *cook {
+	scope := xc.scope
+	for scope.parent != nil {
+		scope = scope.parent
+	}
+	if scope.module != nil {
+		return scope.module
+	}
+	return xc.cook
+}
+
 func (xc *xContext) EnterBlock(forLoop bool, loopLabel string) (Scope, int) {
 	xc.scope = &xScope{parent: xc.scope, vars: make(map[string]*ivar)}
 	loopIndex := -1
@@ -152,9 +249,166 @@ func (xc *xContext) ExitBlock(index int) {
 	if xc.scope.parent == nil {
 		panic("exit block call on outter block")
 	}
+	runExits(xc.scope.exits)
 	xc.scope = xc.scope.parent
 }
 
+func (xc *xContext) Capture() Scope { return xc.scope }
+
+func (xc *xContext) EnterScope(scope Scope) func() {
+	prev := xc.scope
+	xc.scope = scope.(*xScope)
+	return func() { xc.scope = prev }
+}
+
+func (xc *xContext) EnterContext(goctx context.Context) func() {
+	prev := xc.goctx
+	xc.goctx = goctx
+	return func() { xc.goctx = prev }
+}
+
+func (xc *xContext) EnterDir(dir string) { xc.dirs = append(xc.dirs, dir) }
+
+func (xc *xContext) ExitDir() {
+	if len(xc.dirs) == 0 {
+		panic("exit working directory call outside of workin block")
+	}
+	xc.dirs = xc.dirs[:len(xc.dirs)-1]
+}
+
+func (xc *xContext) WorkingDir() string {
+	if n := len(xc.dirs); n > 0 {
+		return xc.dirs[n-1]
+	}
+	return ""
+}
+
+func (xc *xContext) Context() context.Context {
+	if xc.goctx == nil {
+		return context.Background()
+	}
+	return xc.goctx
+}
+
+func (xc *xContext) OnExit(global bool, fn func() error) {
+	// fn is registered by a built-in function thus it's run without the lock as well
+	exit := func() error {
+		defer xc.Release()()
+		return fn()
+	}
+	if !global {
+		for scope := xc.scope; scope != nil; scope = scope.parent {
+			if scope.target {
+				scope.exits = append(scope.exits, exit)
+				return
+			}
+		}
+	}
+	xc.cook.exits = append(xc.cook.exits, exit)
+}
+
+// Release is called while a built-in function or an external command is running, it does nothing
+// if xc does not hold the lock.
+func (xc *xContext) Release() (acquire func()) {
+	if !xc.locked {
+		return func() {}
+	}
+	xc.cook.lock.Unlock()
+	return xc.cook.lock.Lock
+}
+
+// Defer register fn on the nearest target or function scope, fn is called in the reverse order
+// of registration when the scope exit even if the execution is cancelled. fn see the working
+// directory and the value of vars as of the time it is registered, e.g. the variables of the
+// enclosing loops, while the other variables are read when fn is called. A function registered
+// outside of any target or function is called after the finalize targets.
+func (xc *xContext) Defer(vars []string, fn func() error) {
+	scope, dir := xc.scope.snapshot(vars), xc.WorkingDir()
+	exit := func() error {
+		if xc.goctx != nil {
+			defer xc.EnterContext(context.WithoutCancel(xc.goctx))()
+		}
+		defer xc.EnterScope(scope)()
+		if dir != "" {
+			xc.EnterDir(dir)
+			defer xc.ExitDir()
+		}
+		return fn()
+	}
+	for owner := xc.scope; owner != nil; owner = owner.parent {
+		if owner.target || owner.function {
+			owner.exits = append(owner.exits, exit)
+			return
+		}
+	}
+	xc.cook.exits = append(xc.cook.exits, exit)
+}
+
+// Detach return a context which share only the global variables, the working directory and the
+// context of xc as of the time it is called.
+func (xc *xContext) Detach() function.Runtime {
+	root := xc.scope
+	for root.parent != nil {
+		root = root.parent
+	}
+	return &xContext{
+		scope:      root,
+		cook:       xc.cook,
+		continueAt: -1,
+		breakAt:    -1,
+		dirs:       slices.Clone(xc.dirs),
+		goctx:      xc.goctx,
+	}
+}
+
+// Call execute a function declared in Cookfile, given by its name, or a function value with a new
+// context which share only the global variables and the working directory of xc. Call acquire the
+// lock of the cook thus it must be called from a built-in function or another goroutine.
+func (xc *xContext) Call(fn any, args ...any) (any, error) {
+	var fv funcValue
+	switch f := fn.(type) {
+	case string:
+		if decl := xc.module().fns[f]; decl != nil {
+			fv = decl.internalExecute
+		} else {
+			return nil, fmt.Errorf("function %s is not exist", f)
+		}
+	case funcValue:
+		fv = f
+	default:
+		return nil, fmt.Errorf("%v is not a function", fn)
+	}
+	root := xc.scope
+	for root.parent != nil {
+		root = root.parent
+	}
+	ctx := &xContext{
+		scope:      &xScope{parent: root, vars: make(map[string]*ivar)},
+		cook:       xc.cook,
+		continueAt: -1,
+		breakAt:    -1,
+		dirs:       slices.Clone(xc.dirs),
+		goctx:      xc.goctx,
+		locked:     true,
+	}
+	xc.cook.lock.Lock()
+	defer xc.cook.lock.Unlock()
+	v, _, err := fv(ctx, len(args), func(i int) (any, reflect.Kind, error) {
+		return args[i], reflect.ValueOf(args[i]).Kind(), nil
+	})
+	return v, err
+}
+
+// runExits call exit functions in reverse order of registration, error is displayed as warning
+// since the exit function is meant for clean up purpose.
+func runExits(exits []func() error) {
+	for i := len(exits) - 1; i >= 0; i-- {
+		if err := exits[i](); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+	}
+}
+
 func (xc *xContext) ShouldBreak(fromLoop bool) bool {
 	if len(xc.loopsLabel) > 0 {
 		loop := xc.currentLoop()
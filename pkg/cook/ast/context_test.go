@@ -3,6 +3,7 @@ package ast
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cozees/cook/pkg/cook/token"
 	"github.com/stretchr/testify/assert"
@@ -36,3 +37,37 @@ func TestVariableReference(t *testing.T) {
 		[]any{2.3, 9.2},
 	}
 }
+
+func TestDetachCall(t *testing.T) {
+	c := NewCook().(*cook)
+	ctx := c.renewContext()
+	c.lock.Lock()
+	ctx.locked = true
+	ctx.EnterDir("web")
+	rt := ctx.Detach()
+	ctx.ExitDir()
+	dir := ""
+	fn := funcValue(func(fctx Context, _ int, _ argumentSetter) (any, reflect.Kind, error) {
+		dir = fctx.WorkingDir()
+		return "done", reflect.String, nil
+	})
+	done := make(chan any)
+	go func() {
+		v, _ := rt.Call(fn)
+		done <- v
+	}()
+	// the function must wait until the statements release the lock
+	select {
+	case <-done:
+		t.Fatal("function is called while the statements are executing")
+	case <-time.After(50 * time.Millisecond):
+	}
+	acquire := ctx.Release()
+	assert.Equal(t, "done", <-done)
+	acquire()
+	assert.Equal(t, "web", dir)
+	c.lock.Unlock()
+
+	_, err := rt.Call("missing")
+	assert.Error(t, err)
+}
@@ -1,8 +1,17 @@
 package ast
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"time"
+
+	"github.com/cozees/cook/pkg/cook/token"
+	"github.com/cozees/cook/pkg/runtime/function"
+	"github.com/cozees/cook/pkg/runtime/glob"
 )
 
 type ForStatement struct {
@@ -174,6 +183,40 @@ func (fst *ForStatement) executeInsts(ctx Context, ikind reflect.Kind, vkind ref
 	return sb, -1, nil, nil
 }
 
+// WhileStatement execute its block as long as Cond is true, Cond is evaluated after the block
+// rather than before if Do is true thus the block is executed at least once.
+type WhileStatement struct {
+	*Base
+	Label string
+	Cond  Node
+	Do    bool
+	Insts *BlockStatement
+}
+
+func (ws *WhileStatement) Evaluate(ctx Context) error {
+	_, lid := ctx.EnterBlock(true, ws.Label)
+	defer ctx.ExitBlock(lid)
+	for first := ws.Do; ; first = false {
+		if err := ctx.Context().Err(); err != nil {
+			return err
+		} else if !first {
+			if v, vk, err := ws.Cond.Evaluate(ctx); err != nil {
+				return err
+			} else if vk != reflect.Bool {
+				return fmt.Errorf("%s: while condition must be a boolean but got %s", ws.Cond.ErrPos(), vk)
+			} else if !v.(bool) {
+				return nil
+			}
+		}
+		if err := ws.Insts.Evaluate(ctx); err != nil {
+			return err
+		} else if ctx.ShouldBreak(true) {
+			return nil
+		}
+		ctx.ResetBreakContinue()
+	}
+}
+
 type IfStatement struct {
 	*Base
 	Cond  Node
@@ -207,3 +250,218 @@ func (efst *ElseStatement) Evaluate(ctx Context) error {
 		return efst.Insts.Evaluate(ctx)
 	}
 }
+
+// SwitchStatement execute the block of the first case which match the value of X. Without X,
+// each pattern of a case is a condition which must be evaluated to a boolean.
+type SwitchStatement struct {
+	*Base
+	X       Node
+	Cases   []*CaseClause
+	Default *BlockStatement
+}
+
+// CaseClause is a case of switch statement, it match if any of its patterns match. A pattern is
+// either a value, an IsType without X, an Interval, an OSysCheck or an Unary of token.FD which
+// match a string against a glob pattern.
+type CaseClause struct {
+	*Base
+	Patterns []Node
+	Insts    *BlockStatement
+}
+
+func (sst *SwitchStatement) Evaluate(ctx Context) error {
+	var v any
+	var vk reflect.Kind
+	if sst.X != nil {
+		var err error
+		if v, vk, err = sst.X.Evaluate(ctx); err != nil {
+			return err
+		}
+	}
+	for _, cc := range sst.Cases {
+		for _, pattern := range cc.Patterns {
+			if ok, err := sst.match(ctx, pattern, v, vk); err != nil {
+				return fmt.Errorf("%s: %w", cc.ErrPos(), err)
+			} else if ok {
+				return cc.Insts.Evaluate(ctx)
+			}
+		}
+	}
+	if sst.Default != nil {
+		return sst.Default.Evaluate(ctx)
+	}
+	return nil
+}
+
+func (sst *SwitchStatement) match(ctx Context, pattern Node, v any, vk reflect.Kind) (bool, error) {
+	if sst.X != nil {
+		switch pn := pattern.(type) {
+		case *IsType:
+			return pn.match(ctx, v, vk), nil
+		case *Interval:
+			return pn.Contains(ctx, v, vk)
+		case *Unary:
+			if pn.Op == token.FD {
+				p, pk, err := pn.X.Evaluate(ctx)
+				if err != nil {
+					return false, err
+				} else if pk != reflect.String {
+					return false, fmt.Errorf("glob pattern %v must be a string", p)
+				} else if vk != reflect.String {
+					return false, nil
+				}
+				return glob.Match(p.(string), v.(string))
+			}
+		}
+	}
+	p, pk, err := pattern.Evaluate(ctx)
+	switch {
+	case err != nil:
+		return false, err
+	case sst.X == nil:
+		if pk != reflect.Bool {
+			return false, fmt.Errorf("case %s must be a boolean condition", pattern)
+		}
+		return p.(bool), nil
+	}
+	if _, ok := pattern.(*OSysCheck); ok {
+		return p.(bool), nil
+	}
+	isNum := func(k reflect.Kind) bool { return k == reflect.Int64 || k == reflect.Float64 }
+	switch {
+	case isNum(vk) && isNum(pk), vk == pk && (vk == reflect.String || vk == reflect.Bool):
+		b, _, err := logicOperator(ctx, token.EQL, v, p, vk, pk)
+		if err != nil {
+			return false, err
+		}
+		return b.(bool), nil
+	case vk == pk:
+		return reflect.DeepEqual(v, p), nil
+	default:
+		return false, nil
+	}
+}
+
+// WorkInStatement execute its block with Dir as the working directory. The directory is kept on
+// the context thus the process working directory is left untouched.
+type WorkInStatement struct {
+	*Base
+	Dir   Node
+	Insts *BlockStatement
+}
+
+func (wis *WorkInStatement) Evaluate(ctx Context) error {
+	v, vk, err := wis.Dir.Evaluate(ctx)
+	if err != nil {
+		return err
+	} else if vk != reflect.String {
+		return fmt.Errorf("%s: workin directory must be a string but got %s", wis.ErrPos(), vk)
+	}
+	// keep an absolute path so the block is not affected by a process wide @workin
+	dir, err := filepath.Abs(function.ResolvePath(ctx, v.(string)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", wis.ErrPos(), err)
+	}
+	if stat, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("%s: %w", wis.ErrPos(), err)
+	} else if !stat.IsDir() {
+		return fmt.Errorf("%s: %s is not a directory", wis.ErrPos(), dir)
+	}
+	ctx.EnterDir(dir)
+	defer ctx.ExitDir()
+	return wis.Insts.Evaluate(ctx)
+}
+
+// DeferStatement register Stmt to be executed when the enclosing target or function exit, including
+// when it fail. The statement is executed with the working directory of where it is deferred.
+type DeferStatement struct {
+	*Base
+	Stmt Statement
+	// LoopVars is the variables of the enclosing loops, their value is kept as of the time the
+	// statement is deferred thus each iteration see its own value.
+	LoopVars []string
+}
+
+// block return the deferred statement as a block
+func (ds *DeferStatement) block() *BlockStatement {
+	if bs, ok := ds.Stmt.(*BlockStatement); ok {
+		return bs
+	}
+	return &BlockStatement{Base: ds.Base, Stmts: []Statement{ds.Stmt}}
+}
+
+func (ds *DeferStatement) Evaluate(ctx Context) error {
+	ctx.Defer(ds.LoopVars, func() error {
+		if err := ds.Stmt.Evaluate(ctx); err != nil && !errors.Is(err, errReturn) {
+			return err
+		}
+		return nil
+	})
+	return nil
+}
+
+// RetryStatement execute its block again until it complete without error, at most Attempts times.
+// Every is the delay between two attempts while Timeout limit the time of all attempts, any of
+// them can be nil.
+type RetryStatement struct {
+	*Base
+	Attempts Node
+	Every    Node
+	Timeout  Node
+	Insts    *BlockStatement
+}
+
+func (rs *RetryStatement) Evaluate(ctx Context) error {
+	v, vk, err := rs.Attempts.Evaluate(ctx)
+	if err != nil {
+		return err
+	} else if vk != reflect.Int64 || v.(int64) < 1 {
+		return fmt.Errorf("%s: retry attempts must be a positive integer but got %v", rs.Attempts.ErrPos(), v)
+	}
+	attempts := v.(int64)
+	every, err := duration(ctx, rs.Every)
+	if err != nil {
+		return err
+	}
+	timeout, err := duration(ctx, rs.Timeout)
+	if err != nil {
+		return err
+	}
+	parent := ctx.Context()
+	if timeout > 0 {
+		goctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+		defer ctx.EnterContext(goctx)()
+	}
+	for i := int64(1); ; i++ {
+		err = rs.Insts.Evaluate(ctx)
+		if err == nil || errors.Is(err, errReturn) || parent.Err() != nil {
+			return err
+		} else if i == attempts {
+			return fmt.Errorf("%s: all %d attempts failed: %w", rs.ErrPos(), attempts, err)
+		}
+		fmt.Fprintf(os.Stderr, "warning: attempt %d of %d failed: %s\n", i, attempts, err)
+		function.Sleep(ctx.Context(), every)
+		if parent.Err() != nil {
+			return parent.Err()
+		} else if ctx.Context().Err() != nil {
+			return fmt.Errorf("%s: retry timed out after %s: %w", rs.ErrPos(), timeout, err)
+		}
+	}
+}
+
+// duration evaluate x as a duration, a nil x is a zero duration
+func duration(ctx Context, x Node) (time.Duration, error) {
+	if x == nil {
+		return 0, nil
+	}
+	v, _, err := x.Evaluate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	d, err := function.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", x.ErrPos(), err)
+	}
+	return d, nil
+}
@@ -1,12 +1,15 @@
 package ast
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/cozees/cook/pkg/cook/token"
 	"github.com/cozees/cook/pkg/runtime/args"
@@ -22,9 +25,23 @@ type Cook interface {
 	Code
 	Block() *BlockStatement
 	AddFunction(fn *Function)
+	// AddRecord declare a record type, the name of a record must be unique within a Cookfile
+	AddRecord(rt *RecordType) error
 	AddTarget(base *Base, name string) (*Target, error)
+	// GetTarget return a target declared in the Cookfile or nil if it is not exist
+	GetTarget(name string) *Target
+	// AddModule make exported functions, targets and global variables of lib accessible
+	// under the namespace name, e.g. @name.test or name.VERSION
+	AddModule(name string, lib Cook) error
+	// Check report mismatched types of the Cookfile without executing it
+	Check() error
+	// Lint report likely mistakes of the Cookfile without executing it
+	Lint() []*Issue
 	Execute(pargs map[string]any) error
 	ExecuteWithTarget(pargs map[string]any, names ...string) error
+	// ExecuteContext is the same as ExecuteWithTarget however the execution and any running
+	// external command are stopped once goctx is cancelled.
+	ExecuteContext(goctx context.Context, pargs map[string]any, names ...string) error
 	Scope() Scope
 }
 
@@ -34,17 +51,32 @@ type cook struct {
 	targets       map[string]int
 	targetIndexes []*Target
 	fns           map[string]*Function
+	records       map[string]*RecordType
+	recordIndexes []*RecordType
 
 	initializeTargets Targets
 	finalizeTargets   Targets
 	targetAll         *Target
 	Insts             *BlockStatement
+	// functions to be called after finalize target
+	exits []func() error
+	// lock is held by the goroutine executing the statements, it's released while a built-in function
+	// or an external command is running so that a function called from another goroutine, e.g. by a
+	// route of @serve, is executed one at a time with the statements.
+	lock sync.Mutex
+
+	// imported library by its namespace
+	modules map[string]*cook
+	// root scope of the library which hold its global variables
+	root *xScope
 }
 
 func NewCook() Cook {
 	return &cook{
 		targets: make(map[string]int),
 		fns:     make(map[string]*Function),
+		records: make(map[string]*RecordType),
+		modules: make(map[string]*cook),
 		Insts:   &BlockStatement{root: true, plain: true},
 	}
 }
@@ -77,7 +109,7 @@ func (c *cook) AddTarget(base *Base, name string) (*Target, error) {
 	}
 
 	if ti, ok := c.targets[name]; !ok {
-		t := &Target{Base: base, name: name, Insts: &BlockStatement{Base: base}}
+		t := &Target{Base: base, name: name, Insts: &BlockStatement{Base: base}, module: c}
 		c.targetIndexes = append(c.targetIndexes, t)
 		c.targets[name] = len(c.targetIndexes) - 1
 		return t, nil
@@ -90,6 +122,72 @@ func (c *cook) AddTarget(base *Base, name string) (*Target, error) {
 
 func (c *cook) AddFunction(fn *Function) { c.fns[fn.Name] = fn }
 
+func (c *cook) AddRecord(rt *RecordType) error {
+	if prev, ok := c.records[rt.Name]; ok {
+		pos := prev.Position()
+		return fmt.Errorf("record %s already exist, previously define at %d:%d", rt.Name, pos.Line, pos.Column)
+	}
+	c.records[rt.Name] = rt
+	c.recordIndexes = append(c.recordIndexes, rt)
+	return nil
+}
+
+func (c *cook) GetTarget(name string) *Target {
+	if ind, ok := c.targets[name]; ok {
+		return c.targetIndexes[ind]
+	} else if name == TargetAll {
+		return c.targetAll
+	}
+	return nil
+}
+
+func (c *cook) AddModule(name string, lib Cook) error {
+	if _, ok := c.modules[name]; ok {
+		return fmt.Errorf("namespace %s is already used by another import", name)
+	}
+	c.modules[name] = lib.(*cook)
+	return nil
+}
+
+// loadModules evaluate the global statements of each imported library within its own root scope,
+// a library imported by multiple Cookfile is loaded only once.
+func (c *cook) loadModules(ctx Context, loaded map[*cook]bool) error {
+	for _, m := range c.modules {
+		if loaded[m] {
+			continue
+		}
+		loaded[m] = true
+		m.root = &xScope{vars: make(map[string]*ivar), module: m}
+		if err := m.loadModules(ctx, loaded); err != nil {
+			return err
+		}
+		restore := ctx.EnterScope(m.root)
+		err := m.Insts.Evaluate(ctx)
+		restore()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isExported report whether name of a global variable, function or target is accessible from
+// the importing Cookfile, a name begin with underscore is private to the library.
+func isExported(name string) bool { return name != "" && name[0] != '_' }
+
+// export return an exported global variable or function of the library as a value
+func (c *cook) export(name string) (any, reflect.Kind) {
+	if c.root == nil || !isExported(name) {
+		return nil, reflect.Invalid
+	}
+	if iv, ok := c.root.vars[name]; ok {
+		return iv.value, iv.kind
+	} else if fn, ok := c.fns[name]; ok {
+		return fn.closure(c.root), reflect.Func
+	}
+	return nil, reflect.Invalid
+}
+
 func (c *cook) Execute(pargs map[string]any) error {
 	if c.targetAll == nil {
 		return errors.New("default target all is not defined")
@@ -97,11 +195,28 @@ func (c *cook) Execute(pargs map[string]any) error {
 	return c.ExecuteWithTarget(pargs, TargetAll)
 }
 
-func (c *cook) ExecuteWithTarget(pargs map[string]any, names ...string) (err error) {
+func (c *cook) ExecuteWithTarget(pargs map[string]any, names ...string) error {
+	return c.ExecuteContext(context.Background(), pargs, names...)
+}
+
+func (c *cook) ExecuteContext(goctx context.Context, pargs map[string]any, names ...string) (err error) {
 	c.ctx = c.renewContext()
+	c.ctx.goctx = goctx
+	c.lock.Lock()
+	c.ctx.locked = true
+	defer c.lock.Unlock()
+	// release resource registered by runtime function, it is deferred first thus
+	// it's run after finalize target
+	defer func() {
+		runExits(c.exits)
+		c.exits = nil
+	}()
 	for name, v := range pargs {
 		c.ctx.scope.SetVariable(name, v, reflect.ValueOf(v).Kind(), nil)
 	}
+	if err = c.loadModules(c.ctx, make(map[*cook]bool)); err != nil {
+		return err
+	}
 	// execute outter statement
 	if c.Insts != nil {
 		if err = c.Insts.Evaluate(c.ctx); err != nil {
@@ -117,6 +232,8 @@ func (c *cook) ExecuteWithTarget(pargs map[string]any, names ...string) (err err
 	}
 	// defer for finalize
 	defer func() {
+		// finalize target is a clean up thus it must run even if the execution is cancelled
+		c.ctx.goctx = context.WithoutCancel(goctx)
 		for _, final := range c.finalizeTargets {
 			if ferr := final.Execute(c.ctx, nil); ferr != nil {
 				// igore the error from finalize display warning instead
@@ -135,12 +252,12 @@ func (c *cook) ExecuteWithTarget(pargs map[string]any, names ...string) (err err
 		if c.targetAll.all {
 			for _, t := range c.targetIndexes {
 				c.ctx.EnterBlock(false, "")
-				if err = t.Execute(c.ctx, nil); err != nil {
+				if err = t.Execute(c.ctx, t.cliArgs(pargs)); err != nil {
 					return err
 				}
 				c.ctx.ExitBlock(-1)
 			}
-		} else if err = c.targetAll.Execute(c.ctx, nil); err != nil {
+		} else if err = c.targetAll.Execute(c.ctx, c.targetAll.cliArgs(pargs)); err != nil {
 			return err
 		}
 		c.ctx.ExitBlock(-1)
@@ -151,8 +268,12 @@ func (c *cook) ExecuteWithTarget(pargs map[string]any, names ...string) (err err
 				fmt.Println("warning: target all was include among other, it won't be executed.")
 				continue
 			}
+			t := c.ctx.GetTarget(name)
+			if t == nil {
+				return fmt.Errorf("target %s is not exist", name)
+			}
 			c.ctx.EnterBlock(false, "")
-			if err = c.ctx.GetTarget(name).Execute(c.ctx, nil); err != nil {
+			if err = t.Execute(c.ctx, t.cliArgs(pargs)); err != nil {
 				return err
 			}
 			c.ctx.ExitBlock(-1)
@@ -163,7 +284,7 @@ func (c *cook) ExecuteWithTarget(pargs map[string]any, names ...string) (err err
 
 func (c *cook) renewContext() *xContext {
 	return &xContext{
-		scope:      &xScope{vars: make(map[string]*ivar)},
+		scope:      &xScope{vars: make(map[string]*ivar), module: c},
 		cook:       c,
 		continueAt: -1,
 		breakAt:    -1,
@@ -190,11 +311,25 @@ func (t Targets) notExisted(fname string) error {
 
 type Target struct {
 	*Base
-	all   bool
-	Insts *BlockStatement
-	name  string
+	all    bool
+	Insts  *BlockStatement
+	Params []*TargetParam
+	name   string
+	// module is the Cookfile or library which declare the target
+	module *cook
+}
+
+// TargetParam is a named parameter of a target, e.g. deploy(env = "staging", replicas: integer = 1):
+type TargetParam struct {
+	Name *Ident
+	// Type is one of TINTEGER, TFLOAT, TBOOLEAN, TSTRING, TARRAY or TMAP, ILLEGAL if any type is accepted
+	Type token.Token
+	// Default is nil if the parameter is required
+	Default Node
 }
 
+func (t *Target) Name() string { return t.name }
+
 func (t *Target) SetCallAll() {
 	if t.name != TargetAll {
 		panic("cook internal error: set call all on a none all target")
@@ -202,14 +337,107 @@ func (t *Target) SetCallAll() {
 	t.all = true
 }
 
-func (t *Target) Execute(ctx Context, args []*args.FunctionArg) error {
+func (t *Target) Execute(ctx Context, fargs []*args.FunctionArg) error {
+	// a target of an imported library is executed within the library root scope
+	if xc, ok := ctx.(*xContext); ok && t.module != nil && t.module.root != nil && xc.module() != t.module {
+		defer ctx.EnterScope(t.module.root)()
+	}
 	scope, _ := ctx.EnterBlock(false, "")
 	defer ctx.ExitBlock(-1)
-	for i, fa := range args {
-		scope.SetVariable(strconv.Itoa(i+1), fa.Val, fa.Kind, nil)
+	if xs, ok := scope.(*xScope); ok {
+		xs.target = true
+	}
+	n := 0
+	for _, fa := range fargs {
+		if fa.Name == "" {
+			n++
+			scope.SetVariable(strconv.Itoa(n), fa.Val, fa.Kind, nil)
+		}
+	}
+	scope.SetVariable("0", int64(n), reflect.Int64, nil)
+	if err := t.bindParams(ctx, scope, fargs); err != nil {
+		return err
+	}
+	// return statement end the target early
+	if err := t.Insts.Evaluate(ctx); !errors.Is(err, errReturn) {
+		return err
+	}
+	return nil
+}
+
+// bindParams set each parameter of the target to the positional argument at the same position or
+// the named argument given by the parameter name otherwise its default value.
+func (t *Target) bindParams(ctx Context, scope Scope, fargs []*args.FunctionArg) error {
+	given := make([]*args.FunctionArg, len(t.Params))
+	i := 0
+	for _, fa := range fargs {
+		if fa.Name == "" {
+			if i < len(given) {
+				given[i] = fa
+			}
+			i++
+		}
+	}
+	for _, fa := range fargs {
+		if fa.Name == "" {
+			continue
+		}
+		ind := slices.IndexFunc(t.Params, func(tp *TargetParam) bool { return tp.Name.Name == fa.Name })
+		if ind == -1 {
+			return fmt.Errorf("%s: target %s has no parameter %s", t.ErrPos(), t.name, fa.Name)
+		} else if given[ind] != nil {
+			return fmt.Errorf("%s: parameter %s of target %s is given more than once", t.ErrPos(), fa.Name, t.name)
+		}
+		given[ind] = fa
+	}
+	for i, tp := range t.Params {
+		var v any
+		var k reflect.Kind
+		var err error
+		if given[i] != nil {
+			v, k = given[i].Val, given[i].Kind
+		} else if tp.Default == nil {
+			return fmt.Errorf("%s: target %s require parameter %s", t.ErrPos(), t.name, tp.Name.Name)
+		} else if v, k, err = tp.Default.Evaluate(ctx); err != nil {
+			return err
+		}
+		if tp.Type != token.ILLEGAL {
+			if v, k, err = tp.convert(v, k); err != nil {
+				return fmt.Errorf("%s: parameter %s of target %s %w", tp.Name.ErrPos(), tp.Name.Name, t.name, err)
+			}
+		}
+		scope.SetVariable(tp.Name.Name, v, k, nil)
+	}
+	return nil
+}
+
+// convert cast v to the type of the parameter, a value given to an array parameter which is not
+// an array, e.g. a single flag on command line, become an array of one element.
+func (tp *TargetParam) convert(v any, k reflect.Kind) (any, reflect.Kind, error) {
+	switch tk := tp.Type.Kind(); {
+	case tk == k:
+		return v, k, nil
+	case tk == reflect.Slice && k != reflect.Map:
+		return []any{v}, reflect.Slice, nil
+	case tk == reflect.Slice, tk == reflect.Map:
+		return nil, 0, fmt.Errorf("value %v is not a %s", v, tp.Type)
+	default:
+		if v, k, err := castValue(v, k, tk); err == nil {
+			return v, k, nil
+		}
+		return nil, 0, fmt.Errorf("value %v cannot be cast to %s", v, tp.Type)
+	}
+}
+
+// cliArgs return the named argument of each parameter from variables given on the command line
+func (t *Target) cliArgs(pargs map[string]any) []*args.FunctionArg {
+	var fargs []*args.FunctionArg
+	for _, tp := range t.Params {
+		if v, ok := pargs[tp.Name.Name]; ok {
+			fargs = append(fargs, &args.FunctionArg{Name: tp.Name.Name, Val: v, Kind: reflect.ValueOf(v).Kind()})
+		}
 	}
-	scope.SetVariable("0", int64(len(args)), reflect.Int64, nil)
-	return t.Insts.Evaluate(ctx)
+	return fargs
 }
 
 func (t *Target) Vist(cb CodeBuilder) {
@@ -227,6 +455,18 @@ type Function struct {
 	Lambda token.Token
 	X      Node
 	Args   []*Ident
+	// ArgTypes is the type annotation of each argument, nil if none of the argument is annotated
+	ArgTypes []token.Token
+	// Result is the type annotation of the returned value, ILLEGAL if there is none
+	Result token.Token
+}
+
+// ArgType return the type annotation of the argument at index i or ILLEGAL if it's not annotated
+func (fn *Function) ArgType(i int) token.Token {
+	if i < len(fn.ArgTypes) {
+		return fn.ArgTypes[i]
+	}
+	return token.ILLEGAL
 }
 
 func (fn *Function) Execute(ctx Context, pargs []Node) (v any, kind reflect.Kind, err error) {
@@ -245,17 +485,28 @@ func (fn *Function) internalExecute(ctx Context, numArgs int, farg argumentSette
 
 	scope, _ := ctx.EnterBlock(false, "")
 	defer ctx.ExitBlock(-1)
+	if xs, ok := scope.(*xScope); ok {
+		xs.function = true
+	}
 	for i := 0; i < numArgs; i++ {
 		if v, k, err := farg(i); err != nil {
 			return nil, 0, err
+		} else if v, k, err = assignable(fn.ArgType(i), v, k); err != nil {
+			return nil, 0, fmt.Errorf("argument %s of function %s %w", fn.Args[i].Name, fn.Name, err)
 		} else {
 			scope.SetVariable(fn.Args[i].Name, v, k, nil)
 		}
 	}
 	if fn.Lambda == token.LAMBDA {
-		return fn.X.Evaluate(ctx)
-	} else if err = fn.Insts.Evaluate(ctx); err == nil {
+		v, kind, err = fn.X.Evaluate(ctx)
+	} else if err = fn.Insts.Evaluate(ctx); err == nil || errors.Is(err, errReturn) {
 		v, kind = ctx.GetReturnValue()
+		err = nil
+	}
+	if err == nil && fn.Result != token.ILLEGAL {
+		if v, kind, err = assignable(fn.Result, v, kind); err != nil {
+			return nil, 0, fmt.Errorf("result of function %s %w", fn.Name, err)
+		}
 	}
 	return v, kind, err
 }
@@ -16,6 +16,7 @@ const (
 
 type iTransform struct {
 	Len    func() int
+	Keys   func() []any // only available on transform map
 	Source func(ctx Context, i any) (any, reflect.Kind, error)
 	Value  func(ctx Context, i, val any) (any, reflect.Kind, error)
 }
@@ -28,6 +29,30 @@ func (it *iTransform) Transform(ctx Context, index any) (any, reflect.Kind, erro
 	}
 }
 
+// funcValue is a value of a function, either a function declared in Cookfile or a function literal
+// which capture the scope where it is created. The kind of the value is reflect.Func.
+type funcValue func(ctx Context, numArgs int, farg argumentSetter) (any, reflect.Kind, error)
+
+// closure return a function value which execute fn within a child of the captured scope
+func (fn *Function) closure(scope Scope) funcValue {
+	return func(ctx Context, numArgs int, farg argumentSetter) (any, reflect.Kind, error) {
+		// argument is evaluated in the scope of the caller
+		vals, kinds := make([]any, numArgs), make([]reflect.Kind, numArgs)
+		for i := range numArgs {
+			v, k, err := farg(i)
+			if err != nil {
+				return nil, 0, err
+			}
+			vals[i], kinds[i] = v, k
+		}
+		restore := ctx.EnterScope(scope)
+		defer restore()
+		return fn.internalExecute(ctx, numArgs, func(i int) (any, reflect.Kind, error) {
+			return vals[i], kinds[i], nil
+		})
+	}
+}
+
 type StringInterpolation struct {
 	*Base
 	mark  byte
@@ -0,0 +1,413 @@
+package ast
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cozees/cook/pkg/cook/token"
+	"github.com/cozees/cook/pkg/runtime/function"
+)
+
+// The rules reported by Lint
+const (
+	RuleUndefinedCall  = "undefined-call"
+	RuleUnknownFlag    = "unknown-flag"
+	RuleUnusedVariable = "unused-variable"
+	RuleUnreachable    = "unreachable-code"
+	RuleUndefinedLabel = "undefined-label"
+	RuleShadowedLoop   = "shadowed-loop-variable"
+)
+
+// Issue is a problem found by Lint, it does not stop the Cookfile from being executed but it is
+// likely a mistake.
+type Issue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	offset  int
+}
+
+func (is *Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", is.File, is.Line, is.Column, is.Rule, is.Message)
+}
+
+type linter struct {
+	cook   *cook
+	issues []*Issue
+	// every name declared as a variable, an argument or a parameter
+	declared map[string]bool
+	// read is the name of variables which is read, assigns is the first plain assignment of a variable
+	read    map[string]bool
+	assigns map[string]*AssignStatement
+	calls   []*Call
+	// scopes hold the names visible from the current block, labels the label of enclosing loops
+	scopes []map[string]bool
+	labels []string
+}
+
+// Lint report unknown calls, unknown flags of built-in functions, variables which are assigned but
+// never read, code after exit, return, break or continue, undefined loop labels and loop variables
+// which overwrite a variable of the enclosing scope. The issues are sorted by their position.
+func (c *cook) Lint() []*Issue {
+	l := &linter{
+		cook:     c,
+		declared: make(map[string]bool),
+		read:     make(map[string]bool),
+		assigns:  make(map[string]*AssignStatement),
+	}
+	targets := slices.Concat(c.initializeTargets, c.targetIndexes, c.finalizeTargets)
+	if c.targetAll != nil {
+		targets = append(targets, c.targetAll)
+	}
+	fns := slices.Collect(maps.Values(c.fns))
+	for _, rt := range c.recordIndexes {
+		for _, f := range rt.Fields {
+			l.inspect(f.Default)
+		}
+	}
+
+	// global scope is visible from every target and function
+	l.scopes = []map[string]bool{{}}
+	l.block(c.Insts, false)
+	for _, t := range targets {
+		scope := make(map[string]bool)
+		for _, tp := range t.Params {
+			l.inspect(tp.Default)
+			scope[tp.Name.Name] = true
+			l.declared[tp.Name.Name] = true
+		}
+		l.scopes = append(l.scopes, scope)
+		l.block(t.Insts, false)
+		l.scopes = l.scopes[:1]
+	}
+	for _, fn := range fns {
+		l.function(fn)
+	}
+
+	for _, call := range l.calls {
+		l.call(call)
+	}
+	for name, as := range l.assigns {
+		if !l.read[name] && name != "_" {
+			l.report(as.Ident, RuleUnusedVariable, "variable %s is assigned but never read", name)
+		}
+	}
+	slices.SortFunc(l.issues, func(a, b *Issue) int {
+		if r := cmp.Compare(a.File, b.File); r != 0 {
+			return r
+		}
+		return cmp.Compare(a.offset, b.offset)
+	})
+	return l.issues
+}
+
+func (l *linter) report(n interface{ Position() token.Position }, rule, format string, args ...any) {
+	pos := n.Position()
+	l.issues = append(l.issues, &Issue{
+		File:    pos.Filename,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+		offset:  pos.Offset,
+	})
+}
+
+func (l *linter) declare(name string) {
+	l.declared[name] = true
+	l.scopes[len(l.scopes)-1][name] = true
+}
+
+func (l *linter) visible(name string) bool {
+	for _, scope := range l.scopes {
+		if scope[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// function lint the body of fn, a label of the loop enclosing a function literal cannot be used
+// inside the function.
+func (l *linter) function(fn *Function) {
+	scopes, labels := l.scopes, l.labels
+	defer func() { l.scopes, l.labels = scopes, labels }()
+	scope := make(map[string]bool)
+	for _, arg := range fn.Args {
+		scope[arg.Name] = true
+		l.declared[arg.Name] = true
+	}
+	l.scopes, l.labels = append(slices.Clone(scopes), scope), nil
+	if fn.Lambda == token.LAMBDA {
+		l.inspect(fn.X)
+	} else {
+		l.block(fn.Insts, false)
+	}
+}
+
+func (l *linter) block(bs *BlockStatement, nested bool) {
+	if bs == nil {
+		return
+	}
+	if nested {
+		l.scopes = append(l.scopes, make(map[string]bool))
+		defer func() { l.scopes = l.scopes[:len(l.scopes)-1] }()
+	}
+	for i, stmt := range bs.Stmts {
+		l.statement(stmt)
+		if i+1 < len(bs.Stmts) && terminate(stmt) {
+			if n, ok := bs.Stmts[i+1].(interface{ Position() token.Position }); ok {
+				l.report(n, RuleUnreachable, "unreachable code")
+			}
+			break
+		}
+	}
+}
+
+// terminate report whether no statement is executed after stmt within the same block
+func terminate(stmt Statement) bool {
+	switch s := stmt.(type) {
+	case *ReturnStatement, *BreakContinueStatement:
+		return true
+	case *ExprWrapperStatement:
+		_, ok := s.X.(*Exit)
+		return ok
+	}
+	return false
+}
+
+func (l *linter) statement(stmt Statement) {
+	switch s := stmt.(type) {
+	case *BlockStatement:
+		l.block(s, true)
+	case *AssignStatement:
+		l.inspect(s.Value)
+		if id, ok := s.Ident.(*Ident); ok && !strings.Contains(id.Name, ".") {
+			if _, ok := l.assigns[id.Name]; !ok && s.Op == token.ASSIGN {
+				l.assigns[id.Name] = s
+			}
+			if !l.visible(id.Name) {
+				l.declare(id.Name)
+			}
+		} else {
+			l.inspect(s.Ident)
+		}
+	case *ExprWrapperStatement:
+		l.inspect(s.X)
+	case *ReturnStatement:
+		l.inspect(s.X)
+	case *BreakContinueStatement:
+		if s.Label != "" && !slices.Contains(l.labels, s.Label) {
+			l.report(s, RuleUndefinedLabel, "label %s is not defined by any enclosing for loop", s.Label)
+		}
+	case *IfStatement:
+		for s != nil {
+			l.inspect(s.Cond)
+			l.block(s.Insts, true)
+			if s.Else == nil {
+				break
+			} else if s.Else.IfStmt == nil {
+				l.block(s.Else.Insts, true)
+				break
+			}
+			s = s.Else.IfStmt
+		}
+	case *ForStatement:
+		l.inspect(s.Oprnd)
+		if s.Range != nil {
+			l.inspect(s.Range)
+		}
+		l.scopes = append(l.scopes, make(map[string]bool))
+		for _, v := range []*Ident{s.I, s.Value} {
+			if v == nil {
+				continue
+			} else if l.visible(v.Name) {
+				l.report(v, RuleShadowedLoop, "loop variable %s overwrite variable %s of the enclosing scope", v.Name, v.Name)
+			}
+			l.declare(v.Name)
+		}
+		l.labels = append(l.labels, s.Label)
+		l.block(s.Insts, true)
+		l.labels = l.labels[:len(l.labels)-1]
+		l.scopes = l.scopes[:len(l.scopes)-1]
+	case *SwitchStatement:
+		l.inspect(s.X)
+		for _, cc := range s.Cases {
+			for _, p := range cc.Patterns {
+				l.inspect(p)
+			}
+			l.block(cc.Insts, true)
+		}
+		l.block(s.Default, true)
+	case *WorkInStatement:
+		l.inspect(s.Dir)
+		l.block(s.Insts, true)
+	case *WhileStatement:
+		l.inspect(s.Cond)
+		l.labels = append(l.labels, s.Label)
+		l.block(s.Insts, true)
+		l.labels = l.labels[:len(l.labels)-1]
+	case *RetryStatement:
+		l.inspect(s.Attempts)
+		l.inspect(s.Every)
+		l.inspect(s.Timeout)
+		l.block(s.Insts, true)
+	case *DeferStatement:
+		// the deferred statement is executed once the target or function exit, outside of any loop
+		labels := l.labels
+		l.labels = nil
+		l.block(s.block(), true)
+		l.labels = labels
+	}
+}
+
+// inspect record the variables read and the calls made by expression n
+func (l *linter) inspect(n Node) {
+	switch x := n.(type) {
+	case nil:
+	case *Ident:
+		head, _, _ := strings.Cut(x.Name, ".")
+		l.read[head] = true
+	case *StringInterpolation:
+		for _, node := range x.nodes {
+			l.inspect(node)
+		}
+	case *Paren:
+		l.inspect(x.Inner)
+	case *Unary:
+		l.inspect(x.X)
+	case *Binary:
+		l.inspect(x.L)
+		l.inspect(x.R)
+	case *Conditional:
+		l.inspect(x.Cond)
+		l.inspect(x.True)
+		l.inspect(x.False)
+	case *Fallback:
+		l.inspect(x.Primary)
+		l.inspect(x.Default)
+	case *SizeOf:
+		l.inspect(x.X)
+	case *IsType:
+		l.inspect(x.X)
+	case *TypeCast:
+		l.inspect(x.X)
+	case *Exit:
+		l.inspect(x.ExitCode)
+	case *ArrayLiteral:
+		for _, v := range x.Values {
+			l.inspect(v)
+		}
+	case *Glob:
+		l.inspect(x.Pattern)
+	case *MapLiteral:
+		for i := range x.Keys {
+			l.inspect(x.Keys[i])
+			l.inspect(x.Values[i])
+		}
+	case *RecordLit:
+		for _, v := range x.Values {
+			l.inspect(v)
+		}
+	case *MergeMap:
+		l.inspect(x.Value)
+	case *Delete:
+		if x.X != nil {
+			l.inspect(x.X)
+		}
+		l.inspect(x.End)
+		for _, ix := range x.Indexes {
+			l.inspect(ix)
+		}
+	case *Index:
+		l.inspect(x.X)
+		l.inspect(x.Index)
+	case *SubValue:
+		l.inspect(x.X)
+		if x.Range != nil {
+			l.inspect(x.Range)
+		}
+	case *Interval:
+		l.inspect(x.A)
+		l.inspect(x.B)
+		l.inspect(x.Step)
+	case *Exists:
+		// exists of a command or a built-in function does not read a variable
+		if x.Op != token.AT && x.Op != token.HASH {
+			l.inspect(x.X)
+		}
+	case *FuncLit:
+		l.function(x.Fn)
+	case *Transformation:
+		l.inspect(x.Ident)
+		l.function(x.Fn)
+	case *Collection:
+		for _, id := range x.X {
+			l.inspect(id)
+		}
+		l.inspect(x.Init)
+		if x.Fn != nil {
+			l.function(x.Fn)
+		}
+	case *Call:
+		if x.Kind == token.AT {
+			l.read[x.Name] = true
+			l.calls = append(l.calls, x)
+		}
+		for _, arg := range x.Args {
+			l.inspect(arg)
+		}
+		if x.FuncLit != nil {
+			l.function(x.FuncLit)
+		}
+	case *NamedArg:
+		l.inspect(x.X)
+	case *Pipe:
+		l.inspect(x.X)
+		l.inspect(x.Y)
+	case *ReadFrom:
+		l.inspect(x.File)
+	case *RedirectTo:
+		l.inspect(x.Caller)
+		for _, f := range x.Files {
+			l.inspect(f)
+		}
+	}
+}
+
+// call report a call to an unknown target or function and an unknown flag given to a built-in
+// function, the same priority as Call Evaluate is used to resolve the name.
+func (l *linter) call(call *Call) {
+	switch {
+	case call.FuncLit != nil, l.declared[call.Name]:
+		return
+	case strings.Contains(call.Name, "."):
+		ns, name, _ := strings.Cut(call.Name, ".")
+		if m := l.cook.modules[ns]; m != nil {
+			if _, ok := m.targets[name]; !isExported(name) || !ok && m.fns[name] == nil {
+				l.report(call, RuleUndefinedCall, "target or function %s is not exist", call.Name)
+			}
+		}
+		return
+	}
+	if _, ok := l.cook.targets[call.Name]; ok || call.Name == TargetAll && l.cook.targetAll != nil {
+		return
+	} else if f := function.GetFunction(call.Name); f != nil {
+		args := make([]any, len(call.Args))
+		for i, arg := range call.Args {
+			if bl, ok := arg.(*BasicLit); ok && bl.Kind == token.STRING {
+				args[i] = bl.Lit
+			}
+		}
+		if err := f.Flags().CheckArgs(args); err != nil {
+			l.report(call, RuleUnknownFlag, "@%s: %s", call.Name, err)
+		}
+	} else if l.cook.fns[call.Name] == nil {
+		l.report(call, RuleUndefinedCall, "target or function %s is not exist", call.Name)
+	}
+}
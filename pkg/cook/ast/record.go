@@ -0,0 +1,189 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cozees/cook/pkg/cook/token"
+)
+
+// RecordType is a record declaration, e.g. record Package { name: string, version: string, deps: array }
+type RecordType struct {
+	*Base
+	Name   string
+	Fields []*RecordField
+}
+
+// RecordField is a typed field of a record
+type RecordField struct {
+	Name *Ident
+	// Type is one of TINTEGER, TFLOAT, TBOOLEAN, TSTRING, TARRAY or TMAP, IDENT if the field hold
+	// a record given by Record or ILLEGAL if any type is accepted
+	Type   token.Token
+	Record string
+	// Default is nil if the field is required
+	Default Node
+}
+
+// A node represent record constructor, e.g. Package{name: "cook", version: "1.0"}
+type RecordLit struct {
+	*Base
+	Name   string
+	Fields []*Ident
+	Values []Node
+}
+
+// Record is a value of a record type, the field values are shared among the copies of the record
+// the same way as the element of a map or an array.
+type Record struct {
+	Type   *RecordType
+	values []any
+}
+
+// Field return the index of the field given by name or -1 if the record does not have the field
+func (rt *RecordType) Field(name string) int {
+	for i, f := range rt.Fields {
+		if f.Name.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// convert check that v is a value of the field type, an integer is accepted by a float field.
+func (rf *RecordField) convert(v any, k reflect.Kind) (any, reflect.Kind, error) {
+	if rf.Type == token.IDENT {
+		if r, ok := v.(Record); ok && r.Type.Name == rf.Record {
+			return v, k, nil
+		}
+		return nil, 0, fmt.Errorf("value %v is not a %s", v, rf.Record)
+	}
+	return assignable(rf.Type, v, k)
+}
+
+// RecordLit Evaluate return a new record, a field which is not given take its default value.
+func (rl *RecordLit) Evaluate(ctx Context) (any, reflect.Kind, error) {
+	rt := ctx.GetRecord(rl.Name)
+	if rt == nil {
+		return nil, 0, fmt.Errorf("%s: record %s is not declared", rl.ErrPos(), rl.Name)
+	}
+	r := Record{Type: rt, values: make([]any, len(rt.Fields))}
+	given := make([]bool, len(rt.Fields))
+	for i, f := range rl.Fields {
+		ind := rt.Field(f.Name)
+		if ind == -1 {
+			return nil, 0, fmt.Errorf("%s: record %s has no field %s", f.ErrPos(), rt.Name, f.Name)
+		}
+		v, k, err := rl.Values[i].Evaluate(ctx)
+		if err != nil {
+			return nil, 0, err
+		} else if err = r.set(ind, v, k); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", f.ErrPos(), err)
+		}
+		given[ind] = true
+	}
+	for i, f := range rt.Fields {
+		if given[i] {
+			continue
+		} else if f.Default == nil {
+			return nil, 0, fmt.Errorf("%s: record %s require field %s", rl.ErrPos(), rt.Name, f.Name.Name)
+		} else if v, k, err := f.Default.Evaluate(ctx); err != nil {
+			return nil, 0, err
+		} else if err = r.set(i, v, k); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", f.Name.ErrPos(), err)
+		}
+	}
+	return r, reflect.Struct, nil
+}
+
+func (r Record) set(i int, v any, k reflect.Kind) (err error) {
+	f := r.Type.Fields[i]
+	if v, _, err = f.convert(v, k); err != nil {
+		return fmt.Errorf("field %s of record %s %w", f.Name.Name, r.Type.Name, err)
+	}
+	r.values[i] = v
+	return nil
+}
+
+// recordField return the record and the index of its field given by a selector such as pkg.name
+// or pkg.owner.name. The head of the selector can also be a global variable of an imported library,
+// e.g. go.DEFAULT.name. A nil record is returned if the head variable does not exist.
+func recordField(ctx Context, selector string) (*Record, int, error) {
+	parts := strings.Split(selector, ".")
+	var v any
+	k, i := reflect.Invalid, 1
+	for ; i < len(parts) && k == reflect.Invalid; i++ {
+		v, k, _ = ctx.GetVariable(strings.Join(parts[:i], "."))
+	}
+	if k == reflect.Invalid {
+		return nil, -1, nil
+	}
+	for i--; ; i++ {
+		r, ok := v.(Record)
+		if !ok {
+			return nil, -1, fmt.Errorf("%s is not a record", strings.Join(parts[:i], "."))
+		}
+		ind := r.Type.Field(parts[i])
+		if ind == -1 {
+			return nil, -1, fmt.Errorf("record %s has no field %s", r.Type.Name, parts[i])
+		} else if i == len(parts)-1 {
+			return &r, ind, nil
+		}
+		v = r.values[ind]
+	}
+}
+
+// MarshalJSON encode the record as a JSON object which keep the order of the record fields
+func (r Record) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, f := range r.Type.Fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.Name.Name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if b, err := json.Marshal(jsonValue(r.values[i])); err != nil {
+			return nil, fmt.Errorf("field %s of record %s: %w", f.Name.Name, r.Type.Name, err)
+		} else {
+			buf.Write(b)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// String return the JSON representation of the record
+func (r Record) String() string {
+	b, err := r.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("%s{%s}", r.Type.Name, err)
+	}
+	return string(b)
+}
+
+// jsonValue convert map of cook value, which can have a key of any type, to a map with string key
+// as required by JSON.
+func jsonValue(v any) any {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			m[fmt.Sprint(iter.Key().Interface())] = jsonValue(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice:
+		if _, ok := v.([]byte); ok {
+			return string(v.([]byte))
+		}
+		a := make([]any, rv.Len())
+		for i := range a {
+			a[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return a
+	}
+	return v
+}
@@ -25,7 +25,9 @@ func (bs *BlockStatement) Append(stmt Statement) { bs.Stmts = append(bs.Stmts, s
 
 func (bs *BlockStatement) Evaluate(ctx Context) (err error) {
 	for _, stmt := range bs.Stmts {
-		if err = stmt.Evaluate(ctx); err != nil {
+		if err = ctx.Context().Err(); err != nil {
+			return err
+		} else if err = stmt.Evaluate(ctx); err != nil {
 			return err
 		} else if ctx.ShouldBreak(false) {
 			break
@@ -40,6 +42,8 @@ type AssignStatement struct {
 	Ident SettableNode
 	Op    token.Token
 	Value Node
+	// Type is the type annotation of the variable, e.g. A: integer = 1, ILLEGAL if there is none
+	Type token.Token
 }
 
 func (as *AssignStatement) Evaluate(ctx Context) (err error) {
@@ -79,6 +83,9 @@ avoidEvaluate:
 				return ix.Set(ctx, v, k, nil)
 			}
 		}
+		if i, k, err = assignable(as.Type, i, k); err != nil {
+			return fmt.Errorf("%s: variable %s %w", as.ErrPos(), as.Ident.VariableName(), err)
+		}
 		return as.Ident.Set(ctx, i, k, bubble)
 	default:
 		v, vk, err := as.Ident.Evaluate(ctx)
@@ -154,11 +161,15 @@ type ReturnStatement struct {
 	X Node
 }
 
+// errReturn is return by ReturnStatement to stop the execution of the remaining statements
+// of the function, it is never reported to the user.
+var errReturn = errors.New("return statement outside of function")
+
 // Return Evaluate return/forward value of a literal value or a value of a variable
 func (r *ReturnStatement) Evaluate(ctx Context) error {
 	if v, k, err := r.X.Evaluate(ctx); err == nil {
 		ctx.SetReturnValue(v, k)
-		return nil
+		return errReturn
 	} else {
 		return err
 	}
@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -366,3 +368,102 @@ labela:
 	expectVar(t, ctx, "b", int64(exb), reflect.Int64)
 	expectVar(t, ctx, "c", int64(exc), reflect.Int64)
 }
+
+func TestWorkIn(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web", "src"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "src", "a.txt"), nil, 0600))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	exists := func(name string) Node {
+		return &Exists{Op: token.FD, X: &BasicLit{Lit: name, Kind: token.STRING}}
+	}
+	stmt := &WorkInStatement{
+		Base: dummyBase,
+		Dir:  &BasicLit{Lit: filepath.Join(dir, "web"), Kind: token.STRING},
+		Insts: &BlockStatement{
+			Stmts: []Statement{
+				&AssignStatement{Ident: &Ident{Name: "a"}, Op: token.ASSIGN, Value: exists("src")},
+				&WorkInStatement{
+					Base: dummyBase,
+					Dir:  &BasicLit{Lit: "src", Kind: token.STRING},
+					Insts: &BlockStatement{
+						Stmts: []Statement{
+							&AssignStatement{Ident: &Ident{Name: "b"}, Op: token.ASSIGN, Value: exists("a.txt")},
+						},
+					},
+				},
+				&AssignStatement{Ident: &Ident{Name: "c"}, Op: token.ASSIGN, Value: exists("a.txt")},
+			},
+		},
+	}
+	ctx := NewCook().(*cook).renewContext()
+	require.NoError(t, stmt.Evaluate(ctx))
+	expectVar(t, ctx, "a", true, reflect.Bool)
+	expectVar(t, ctx, "b", true, reflect.Bool)
+	expectVar(t, ctx, "c", false, reflect.Bool)
+	assert.Equal(t, "", ctx.WorkingDir())
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, cwd, wd)
+
+	stmt.Dir = &BasicLit{Lit: filepath.Join(dir, "web", "src", "a.txt"), Kind: token.STRING}
+	assert.Error(t, stmt.Evaluate(ctx))
+	stmt.Dir = &BasicLit{Lit: filepath.Join(dir, "missing"), Kind: token.STRING}
+	assert.Error(t, stmt.Evaluate(ctx))
+}
+
+func TestSwitch(t *testing.T) {
+	lit := func(v string, kind token.Token) Node { return &BasicLit{Lit: v, Kind: kind} }
+	assign := func(v string) *BlockStatement {
+		return &BlockStatement{Stmts: []Statement{
+			&AssignStatement{Ident: &Ident{Name: "r"}, Op: token.ASSIGN, Value: lit(v, token.STRING)},
+		}}
+	}
+	stmt := &SwitchStatement{
+		Base: dummyBase,
+		X:    &Ident{Name: "v"},
+		Cases: []*CaseClause{
+			{Base: dummyBase, Patterns: []Node{lit("1", token.INTEGER), lit("a", token.STRING)}, Insts: assign("value")},
+			{Base: dummyBase, Patterns: []Node{&Interval{A: lit("10", token.INTEGER), B: lit("5", token.INTEGER), BInclude: true}}, Insts: assign("range")},
+			{Base: dummyBase, Patterns: []Node{&IsType{Types: []token.Token{token.TFLOAT, token.TARRAY}}}, Insts: assign("type")},
+			{Base: dummyBase, Patterns: []Node{&Unary{Op: token.FD, X: lit("src/**/*.go", token.STRING)}}, Insts: assign("glob")},
+		},
+		Default: assign("default"),
+	}
+	ctx := NewCook().(*cook).renewContext()
+	for i, tc := range []struct {
+		v      any
+		kind   reflect.Kind
+		result string
+	}{
+		{int64(1), reflect.Int64, "value"},
+		{1.0, reflect.Float64, "value"},
+		{"a", reflect.String, "value"},
+		{int64(5), reflect.Int64, "range"},
+		{7.5, reflect.Float64, "range"},
+		{2.5, reflect.Float64, "type"},
+		{int64(10), reflect.Int64, "default"},
+		{[]any{int64(1)}, reflect.Slice, "type"},
+		{"src/a/b.go", reflect.String, "glob"},
+		{"b.go", reflect.String, "default"},
+		{true, reflect.Bool, "default"},
+	} {
+		t.Logf("TestSwitch case #%d", i+1)
+		ctx.SetVariable("v", tc.v, tc.kind, nil)
+		require.NoError(t, stmt.Evaluate(ctx))
+		expectVar(t, ctx, "r", tc.result, reflect.String)
+	}
+
+	// without value the pattern is a condition
+	stmt = &SwitchStatement{
+		Base: dummyBase,
+		Cases: []*CaseClause{
+			{Base: dummyBase, Patterns: []Node{&OSysCheck{OS: token.WINDOWS}, &OSysCheck{OS: token.LINUX}, &OSysCheck{OS: token.MACOS}}, Insts: assign("os")},
+		},
+	}
+	require.NoError(t, stmt.Evaluate(ctx))
+	expectVar(t, ctx, "r", "os", reflect.String)
+	stmt.Cases[0].Patterns = []Node{lit("1", token.INTEGER)}
+	assert.Error(t, stmt.Evaluate(ctx))
+}
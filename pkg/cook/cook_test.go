@@ -1,10 +1,14 @@
 package cook
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/cozees/cook/pkg/cook/ast"
 	"github.com/cozees/cook/pkg/cook/parser"
@@ -188,6 +192,175 @@ all:
 			}, v)
 		},
 	},
+	{
+		src: `
+STATUS = 0
+NAME = ""
+hello(req) {
+	Q = req["query"]
+	N = Q["name"] + "!"
+	return {"status": 201, "headers": {"X-Name": N}}
+}
+all:
+	URL = @serve "-p" 0 "-r" "/hello:hello"
+	R = @get "--response" "$URL/hello?name=cook"
+	STATUS = R["status"]
+	H = R["headers"]
+	NAME = H["X-Name"]
+`,
+		verifier: func(t *testing.T, scope ast.Scope) {
+			v, _, _ := scope.GetVariable("STATUS")
+			assert.Equal(t, int64(201), v)
+			v, _, _ = scope.GetVariable("NAME")
+			assert.Equal(t, "cook!", v)
+		},
+	},
+	{
+		src: `
+COUNT = 0
+DIR = ""
+SIZE = 0
+BODY = 0
+all:
+	DIR = @tempdir
+	GREET = "hi"
+	@mkdir "$DIR/web"
+	workin "$DIR" {
+		URL = @serve "-p" 0 "-r" {"/greet": (req) {
+			COUNT += 1
+			Q = req["query"]
+			@print "-e" Q["name"] > "web/name.txt"
+			return GREET + " " + Q["name"]
+		}}
+	}
+	for i in [1..3] {
+		@get "$URL/greet?name=cook" > "$DIR/web/body.txt"
+	}
+	S = @stat "$DIR/web/name.txt"
+	SIZE = S["size"]
+	S = @stat "$DIR/web/body.txt"
+	BODY = S["size"]
+`,
+		verifier: func(t *testing.T, scope ast.Scope) {
+			v, _, _ := scope.GetVariable("COUNT")
+			assert.Equal(t, int64(3), v)
+			v, _, _ = scope.GetVariable("SIZE")
+			assert.Equal(t, int64(5), v)
+			// body is "hi cook" from the captured variable GREET
+			v, _, _ = scope.GetVariable("BODY")
+			assert.Equal(t, int64(7), v)
+		},
+	},
+	{
+		src: `
+DIR = ""
+FILE = ""
+ISDIR = false
+all:
+	DIR = @tempdir "--prefix" "cooktest"
+	FILE = @tempfile "--suffix" ".json" "--dir" DIR
+	S = @stat DIR
+	ISDIR = S["isDir"]
+	@print "-e" "{}" > FILE
+`,
+		verifier: func(t *testing.T, scope ast.Scope) {
+			v, _, _ := scope.GetVariable("ISDIR")
+			assert.Equal(t, true, v)
+			dir, _, _ := scope.GetVariable("DIR")
+			file, _, _ := scope.GetVariable("FILE")
+			assert.Contains(t, dir, "cooktest")
+			assert.Equal(t, ".json", filepath.Ext(file.(string)))
+			assert.Equal(t, dir, filepath.Dir(file.(string)))
+			// removed once the target is completed
+			assert.NoDirExists(t, dir.(string))
+		},
+	},
+	{
+		src: `
+A = [3, 1, 4, 1, 5]
+M = {"a": 1, "b": 2, "c": 3}
+all:
+	B = filter A(i, v) => v > 2
+	SUM = reduce A(acc = 0, i, v) => acc + v
+	MAX = reduce A(acc, i, v) => v > acc ? v : acc
+	SORTED = sort M(k, v) => -v
+	G = group A(i, v) => v % 2 == 0
+	T = A(i, v) => [i, v]
+	F = flatten T
+	Z = zip A B
+	Z0 = Z[0]
+	HAS = any A(i, v) => v == 4
+	ALL = all M(k, v) => v > 1
+	MT = M(k, v) => v * 10
+	MF = filter MT(k, v) => v > 10
+`,
+		verifier: func(t *testing.T, scope ast.Scope) {
+			expect := map[string]any{
+				"B":      []any{int64(3), int64(4), int64(5)},
+				"SUM":    int64(14),
+				"MAX":    int64(5),
+				"SORTED": []any{int64(3), int64(2), int64(1)},
+				"G":      map[any]any{true: []any{int64(4)}, false: []any{int64(3), int64(1), int64(1), int64(5)}},
+				"F":      []any{int64(0), int64(3), int64(1), int64(1), int64(2), int64(4), int64(3), int64(1), int64(4), int64(5)},
+				"Z0":     []any{int64(3), int64(3)},
+				"HAS":    true,
+				"ALL":    false,
+				"MF":     map[any]any{"b": int64(20), "c": int64(30)},
+			}
+			for name, ev := range expect {
+				v, _, _ := scope.GetVariable(name)
+				assert.Equal(t, ev, v, name)
+			}
+		},
+	},
+	{
+		src: `
+makeAdder(n) {
+	return (x) => x + n
+}
+double(x) => x * 2
+apply(f, v) {
+	R = @f v
+	return R
+}
+retry(times, f) {
+	for i in [1..times] {
+		R = @f i
+		if R {
+			return i
+		}
+	}
+	return -1
+}
+all:
+	ADD10 = @makeAdder 10
+	V = @ADD10 5
+	N = @retry 5 (a) => a == 3
+	NONE = @retry 2 (a) => false
+	M = @apply double 21
+	FS = {"inc": (a) => a + 1}
+	INC = FS["inc"]
+	I = @INC 1
+	Z = () {
+		return "zero"
+	}
+	W = @Z
+`,
+		verifier: func(t *testing.T, scope ast.Scope) {
+			expect := map[string]any{
+				"V":    int64(15),
+				"N":    int64(3),
+				"NONE": int64(-1),
+				"M":    int64(42),
+				"I":    int64(2),
+				"W":    "zero",
+			}
+			for name, ev := range expect {
+				v, _, _ := scope.GetVariable(name)
+				assert.Equal(t, ev, v, name)
+			}
+		},
+	},
 }
 
 func TestExecuteState(t *testing.T) {
@@ -200,3 +373,482 @@ func TestExecuteState(t *testing.T) {
 		tc.verifier(t, c.Scope())
 	}
 }
+
+func TestCollectionError(t *testing.T) {
+	for i, src := range []string{
+		"A = [1, 2]\nall:\n\tA = A(i, v) => v + X",
+		"A = {1: 2}\nall:\n\tA = A(k, v) => v + X",
+		"A = [1, 2]\nall:\n\tB = filter A(i, v) => v + 1",
+		"A = []\nall:\n\tB = reduce A(acc, i, v) => acc + v",
+		"A = [1, 'a']\nall:\n\tB = sort A(i, v) => v",
+		"A = [[1], [2]]\nall:\n\tB = group A(i, v) => v",
+		"A = [1]\nall:\n\tB = zip A 'a'",
+		"A = 1\nall:\n\tB = all A(i, v) => true",
+	} {
+		t.Logf("TestCollectionError case #%d", i+1)
+		p := parser.NewParser()
+		c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+		if err == nil {
+			err = c.Execute(nil)
+		}
+		assert.Error(t, err)
+	}
+}
+
+func TestTempCleanupOnError(t *testing.T) {
+	src := `
+DIR = ""
+GLOBAL = ""
+all:
+	GLOBAL = @tempdir "-g"
+	DIR = @tempdir
+	@rm "file__not__exist"
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	require.Error(t, c.Execute(nil))
+	for _, name := range []string{"DIR", "GLOBAL"} {
+		v, _, _ := c.Scope().GetVariable(name)
+		require.NotEmpty(t, v)
+		assert.NoDirExists(t, v.(string))
+	}
+}
+
+func TestExecuteCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep command is not available on windows")
+	}
+	src := `
+FINAL = false
+all:
+	#sleep 10
+	@print "unreachable"
+finalize:
+	FINAL = true
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	assert.ErrorIs(t, c.ExecuteContext(ctx, nil, ast.TargetAll), context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	// finalize target is not cancelled
+	v, _, _ := c.Scope().GetVariable("FINAL")
+	assert.Equal(t, true, v)
+}
+
+func TestTargetParams(t *testing.T) {
+	src := `
+RESULT = []
+deploy(env = "staging", replicas: integer = 1, tags: array = []):
+	N = sizeof tags
+	C = $0
+	RESULT += [[env, replicas, N, C]]
+release(version: float):
+	@deploy env: "prod" replicas: "3"
+	@deploy "dev" tags: ["a", "b"]
+	RESULT += version
+all:
+	@deploy
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	require.NoError(t, c.Execute(nil))
+	v, _, _ := c.Scope().GetVariable("RESULT")
+	assert.Equal(t, []any{[]any{"staging", int64(1), int64(0), int64(0)}}, v)
+
+	// parameter is given from command line variable
+	require.NoError(t, c.ExecuteWithTarget(map[string]any{"replicas": "2", "tags": "x"}, "deploy"))
+	v, _, _ = c.Scope().GetVariable("RESULT")
+	assert.Equal(t, []any{[]any{"staging", int64(2), int64(1), int64(0)}}, v)
+
+	require.NoError(t, c.ExecuteWithTarget(map[string]any{"version": int64(2)}, "release"))
+	v, _, _ = c.Scope().GetVariable("RESULT")
+	assert.Equal(t, []any{
+		[]any{"prod", int64(3), int64(0), int64(0)},
+		[]any{"dev", int64(1), int64(2), int64(1)},
+		float64(2),
+	}, v)
+
+	for i, tc := range []struct {
+		pargs map[string]any
+		name  string
+	}{
+		{nil, "release"},
+		{map[string]any{"version": "x"}, "release"},
+		{map[string]any{"replicas": 1.5, "tags": map[any]any{}}, "deploy"},
+	} {
+		t.Logf("TestTargetParams error case #%d", i+1)
+		assert.Error(t, c.ExecuteWithTarget(tc.pargs, tc.name))
+	}
+	for i, call := range []string{
+		"@deploy unknown: 1",
+		"@deploy 'dev' env: 'prod'",
+		"@print env: 1",
+	} {
+		t.Logf("TestTargetParams call error case #%d", i+1)
+		src := "deploy(env = 'staging'):\n\t@print env\nall:\n\t" + call
+		c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+		require.NoError(t, err)
+		assert.Error(t, c.Execute(nil))
+	}
+
+	// a failing target stop the target which call it
+	file := filepath.Join(t.TempDir(), "after.txt")
+	src = "fail:\n\tA = 1 - true\nall:\n\t@fail\n\t@print '-e' 'after' > '" + filepath.ToSlash(file) + "'"
+	c, err = p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	assert.Error(t, c.Execute(nil))
+	assert.NoFileExists(t, file)
+}
+
+func TestRecord(t *testing.T) {
+	src := `
+record Person { name: string, email: string = "" }
+record Package {
+	name: string
+	version: string = "0.1.0"
+	deps: array = []
+	owner: Person
+	size: float = 0
+}
+make(name) => Package{name: name, owner: Person{name: "bob"}, size: 2}
+all:
+	P = @make "cook"
+	P.version = "0.2.0"
+	P.deps += "a"
+	O = P.owner
+	O.email = "bob@example.com"
+	NAME = P.name
+	IS_PACKAGE = P is Package
+	IS_PERSON = P is string | Person
+	MISSING = P.nme ?? "none"
+	JSON = string(P)
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	require.NoError(t, c.Execute(nil))
+	for name, expected := range map[string]any{
+		"NAME":       "cook",
+		"IS_PACKAGE": true,
+		"IS_PERSON":  false,
+		"MISSING":    "none",
+		"JSON":       `{"name":"cook","version":"0.2.0","deps":["a"],"owner":{"name":"bob","email":"bob@example.com"},"size":2}`,
+	} {
+		v, _, _ := c.Scope().GetVariable(name)
+		assert.Equal(t, expected, v, name)
+	}
+
+	for i, stmt := range []string{
+		"P.version = 1",
+		"P = Package{name: 1}",
+		"A = P.nme",
+		"P.owner.name = 'x'",
+	} {
+		t.Logf("TestRecord error case #%d", i+1)
+		src := "record Package { name: string, version: string = '', owner = '' }\nall:\n\tP = @make\n\t" + stmt +
+			"\nmake() => Package{name: 'cook'}"
+		c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+		require.NoError(t, err)
+		assert.Error(t, c.Execute(nil))
+	}
+}
+
+func TestTypeAnnotation(t *testing.T) {
+	src := `
+A: float = 1
+join(a: array, sep: string): string {
+	R: string = ""
+	for i, v in a {
+		R += (i > 0 ? sep : "") + v
+	}
+	return R
+}
+half(x: float): float => x / 2
+all:
+	S = @join [1, 2] "-"
+	H = @half 3
+	N: integer = sizeof S
+	P = @pabs "."
+	C = P + "/x"
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	require.NoError(t, c.Check())
+	require.NoError(t, c.Execute(nil))
+	for name, expected := range map[string]any{"A": float64(1), "S": "1-2", "H": 1.5, "N": int64(3)} {
+		v, _, _ := c.Scope().GetVariable(name)
+		assert.Equal(t, expected, v, name)
+	}
+
+	for i, tc := range []struct {
+		stmt    string
+		runtime bool // the mismatch is also reported when the Cookfile is executed
+	}{
+		{"A: integer = 'x'", true},
+		{"A = @join [1] 2", true},
+		{"A = @bad 1", true},
+		{"A = @join [1]", true},
+		{"A: string = 'x'\n\tA = 2", false},
+		{"A = 1 - true", true},
+		{"A = [1] && true", true},
+		{"if 1 {\n\t}", true},
+		{"A = integer([1])", true},
+		{"A: integer = @pabs '.'", true},
+		{"A: string = @sort 'b' 'a'", true},
+		{"A: integer = @pglob '*.none'", false},
+		{"A = 1\n\tB: boolean = A + 1", true},
+	} {
+		t.Logf("TestTypeAnnotation error case #%d", i+1)
+		src := "join(a: array, sep: string): string => sep\nbad(a): integer => 'x'\nall:\n\t" + tc.stmt
+		c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+		require.NoError(t, err)
+		assert.Error(t, c.Check())
+		if tc.runtime {
+			assert.Error(t, c.Execute(nil))
+		}
+	}
+
+	// kind of a variable assigned in a nested block is unknown after the block
+	src = "all:\n\tA = 1\n\tif true {\n\t\tA = 'x'\n\t}\n\tB: string = A\n\tfor i in [1..2] {\n\t\tC = A + 1\n\t\tA = 'y'\n\t}"
+	c, err = p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	assert.NoError(t, c.Check())
+}
+
+func TestLint(t *testing.T) {
+	src := `A = 1
+UNUSED = 2
+double(x) => x * 2
+build:
+	@tpyo 1
+	@print '-z' A
+	@print '-n' '-s'
+	B = @double 2
+	for i in [1..3] {
+		for i in [1..2] {
+			break:outer
+		}
+	}
+	for:outer j in [1..2] {
+		continue:outer
+		@print j
+	}
+	@print B
+	exit 0
+	@print "never"
+test:
+	@build
+	@print "$A"
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	var issues []string
+	for _, issue := range c.Lint() {
+		issues = append(issues, issue.String())
+	}
+	assert.Equal(t, []string{
+		"sample:2:1: unused-variable: variable UNUSED is assigned but never read",
+		"sample:5:2: undefined-call: target or function tpyo is not exist",
+		"sample:6:2: unknown-flag: @print: unrecognize flag z",
+		"sample:10:7: shadowed-loop-variable: loop variable i overwrite variable i of the enclosing scope",
+		"sample:11:4: undefined-label: label outer is not defined by any enclosing for loop",
+		"sample:16:3: unreachable-code: unreachable code",
+		"sample:20:2: unreachable-code: unreachable code",
+	}, issues)
+
+	src = "A = 1\nall:\n\tfor i, v in [A] {\n\t\t@print i v\n\t}\n\tF = (x) => x\n\t@F 1"
+	c, err = p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	assert.Empty(t, c.Lint())
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	write("cook_modules/golib/Cookfile", `
+import "./util" as u
+VERSION = "1.25"
+_SECRET = 1
+COUNT = 0
+test(pkg) {
+	R = @_fmt pkg
+	return R
+}
+_fmt(p) => "go test " + p + " " + u.NAME
+build:
+	COUNT += 1
+`)
+	write("cook_modules/golib/util", "NAME = \"util\"\n")
+	write("path/shared/Cookfile", "import \"golib\" as go\nV = go.VERSION\n")
+	t.Setenv("COOKPATH", filepath.Join(dir, "path"))
+	write("Cookfile", `
+import "golib" as go
+import "shared" as sh
+VERSION = "main"
+all:
+	R = @go.test "./..."
+	V = go.VERSION
+	S = sh.V
+	P = go._SECRET ?? "hidden"
+	@go.build
+	@go.build
+	C = go.COUNT
+`)
+	c, err := parser.NewParser().Parse(filepath.Join(dir, "Cookfile"))
+	require.NoError(t, err)
+	require.NoError(t, c.Execute(nil))
+	expect := map[string]any{
+		"R":       "go test ./... util",
+		"V":       "1.25",
+		"S":       "1.25",
+		"P":       "hidden",
+		"C":       int64(2),
+		"VERSION": "main",
+	}
+	for name, ev := range expect {
+		v, _, _ := c.Scope().GetVariable(name)
+		assert.Equal(t, ev, v, name)
+	}
+
+	for i, src := range []string{
+		"import \"notfound\" as n\n",
+		"import \"./Cookfile\" as a\n",
+		"import \"golib\" as go\nimport \"golib\" as go\n",
+		"import \"golib\" as go\ngo.VERSION = 1\n",
+		"import \"golib\" as go\nall:\n\t@go._fmt 1\n",
+		"A = x.VERSION\n",
+		"A = 1\nimport \"golib\" as go\n",
+	} {
+		t.Logf("TestImport error case #%d", i+1)
+		write("Cookfile", src)
+		c, err := parser.NewParser().Parse(filepath.Join(dir, "Cookfile"))
+		if err == nil {
+			err = c.Execute(nil)
+		}
+		assert.Error(t, err)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	src := `
+LOG = []
+defer LOG += ["global"]
+stop(name) {
+	defer LOG += ["stopped $name"]
+	if name == "b" {
+		return 0
+	}
+	LOG += ["stopping $name"]
+	return 1
+}
+build:
+	defer LOG += ["build"]
+	workin "/" {
+		defer @rm "file__not__exist"
+	}
+	@stop "a"
+	@stop "b"
+	for i in [1..2] {
+		defer {
+			LOG += [i]
+		}
+	}
+	LOG += ["body"]
+fail:
+	defer LOG += ["fail"]
+	@rm "file__not__exist"
+	LOG += ["unreachable"]
+finalize:
+	LOG += ["finalize"]
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	require.NoError(t, c.Check())
+	// failure of a deferred statement is only a warning
+	require.NoError(t, c.ExecuteWithTarget(nil, "build"))
+	v, _, _ := c.Scope().GetVariable("LOG")
+	assert.Equal(t, []any{
+		"stopping a", "stopped a", "stopped b", "body", int64(2), int64(1), "build", "finalize", "global",
+	}, v)
+
+	require.Error(t, c.ExecuteWithTarget(nil, "fail"))
+	v, _, _ = c.Scope().GetVariable("LOG")
+	assert.Equal(t, []any{"fail", "finalize", "global"}, v)
+}
+
+func TestWhileRetry(t *testing.T) {
+	src := `
+N = 0
+R = []
+C = 0
+all:
+	while N < 5 {
+		N++
+		if N == 2 {
+			continue
+		} else if N == 4 {
+			break
+		}
+		R += [N]
+	}
+	do {
+		N--
+	} while N > 10
+	while:outer true {
+		for i in [1..3] {
+			break:outer
+		}
+	}
+	retry 3 every 10ms {
+		C++
+		if C < 3 {
+			@rm "file__not__exist"
+		}
+	}
+fail:
+	retry 2 {
+		C++
+		@rm "file__not__exist$C"
+	}
+timeout:
+	retry 100 every 50ms timeout 120ms {
+		C++
+		@rm "file__not__exist"
+	}
+`
+	p := parser.NewParser()
+	c, err := p.ParseSrc(token.NewFile("sample", len(src)), []byte(src))
+	require.NoError(t, err)
+	require.NoError(t, c.Check())
+	require.NoError(t, c.Execute(nil))
+	for name, expected := range map[string]any{"N": int64(3), "R": []any{int64(1), int64(3)}, "C": int64(3)} {
+		v, _, _ := c.Scope().GetVariable(name)
+		assert.Equal(t, expected, v, name)
+	}
+
+	// the error of the last attempt is returned
+	err = c.ExecuteWithTarget(map[string]any{"C": int64(0)}, "fail")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file__not__exist2")
+
+	start := time.Now()
+	err = c.ExecuteWithTarget(map[string]any{"C": int64(0)}, "timeout")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start), 5*time.Second)
+	v, _, _ := c.Scope().GetVariable("C")
+	assert.Less(t, v.(int64), int64(5))
+}
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/cozees/cook/pkg/cook/ast"
 	"github.com/cozees/cook/pkg/cook/token"
@@ -16,7 +19,24 @@ type Parser interface {
 }
 
 func NewParser() Parser {
-	return &parser{parsed: make(map[string]*token.File), pending: make(map[string]*token.File)}
+	return newParser(&importer{libs: make(map[string]ast.Cook)})
+}
+
+func newParser(imp *importer) *parser {
+	return &parser{
+		parsed:  make(map[string]*token.File),
+		pending: make(map[string]*token.File),
+		imp:     imp,
+		vars:    make(map[string]string),
+		records: make(map[string]*ast.RecordType),
+	}
+}
+
+// importer keep the library which is already parsed and the chain of Cookfile which is being
+// parsed to detect an import cycle, it is shared between the parser of each library.
+type importer struct {
+	libs  map[string]ast.Cook
+	chain []string
 }
 
 type parser struct {
@@ -40,6 +60,28 @@ type parser struct {
 	nLit  string
 
 	errs *cookErrors.CookError
+
+	// inHeader is true while parsing the expression of if, for, switch or workin statement
+	// where "{" begin the statement block rather than a function literal body.
+	inHeader bool
+	// inCase is true when the next parseStatements parse a clause of switch statement
+	inCase bool
+
+	imp *importer
+	// namespaces imported by the current file
+	namespaces map[string]bool
+
+	// vars hold every variable name declared in the Cookfile with the record type of its value if
+	// the value is always a record literal of the same type, otherwise the type is empty.
+	vars       map[string]string
+	records    map[string]*ast.RecordType
+	recordLits []*ast.RecordLit
+	// recordRefs is a reference to a record type by its name, e.g. A is Package
+	recordRefs []*ast.Ident
+	// selectors is a qualified identifier which is not a member of an imported library, e.g. pkg.name
+	selectors []*ast.Ident
+	// loopVars is the index and value variables of the for loops enclosing the current statement
+	loopVars []string
 }
 
 func (p *parser) curPos() token.Position { return p.tfile.Position(p.cOffs) }
@@ -60,12 +102,28 @@ func (p *parser) errorHandler(pos token.Position, msg string, args ...any) {
 				// index assigned statement.
 				return
 			}
+			switch p.keyword(false) {
+			case token.WHILE, token.DO, token.RETRY, token.WORKIN, token.SWITCH, token.DEFER:
+				return
+			}
 		case token.FOR, token.IF, token.BREAK, token.CONTINUE, token.RETURN, token.EOF, token.COMMENT:
 			return
 		}
 	}
 }
 
+// quoteMark return the quote of a string literal begin at offs or 0 if the string is not quoted
+// such as a duration literal.
+func (p *parser) quoteMark(offs int) byte {
+	if offs > 0 {
+		switch mark := p.s.src[offs-1]; mark {
+		case '\'', '"', '`':
+			return mark
+		}
+	}
+	return 0
+}
+
 func (p *parser) expect(require token.Token) (offs int) {
 	if p.cTok != require {
 		p.errorHandler(p.curPos(), fmt.Sprintf("expect %s but got %s", require, p.cTok))
@@ -92,6 +150,26 @@ func (p *parser) next() {
 	if p.nTok != token.EOF {
 		p.nOffs, p.nTok, p.nLit = p.s.Scan()
 	}
+	if p.cTok == token.IDENT {
+		if ns, _, ok := strings.Cut(p.cLit, "."); ok && !p.namespaces[ns] {
+			// field of a record which is validated once every variable is known
+			p.selectors = append(p.selectors, &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit})
+		}
+	}
+}
+
+// isLibraryMember report whether name is a qualified name of an imported library, e.g. go.VERSION
+func (p *parser) isLibraryMember(name string) bool {
+	ns, _, ok := strings.Cut(name, ".")
+	return ok && p.namespaces[ns]
+}
+
+// declare record a variable name, record is the record type of the variable value if it's known
+func (p *parser) declare(name, record string) {
+	if prev, ok := p.vars[name]; ok && prev != record {
+		record = ""
+	}
+	p.vars[name] = record
 }
 
 func (p *parser) Parse(file string) (ast.Cook, error) {
@@ -108,9 +186,16 @@ func (p *parser) Parse(file string) (ast.Cook, error) {
 }
 
 func (p *parser) ParseSrc(file *token.File, src []byte) (ast.Cook, error) {
+	if abs, err := filepath.Abs(file.Name()); err == nil {
+		p.imp.chain = append(p.imp.chain, abs)
+		defer func() { p.imp.chain = p.imp.chain[:len(p.imp.chain)-1] }()
+	}
 	if err := p.init(file, src); err == nil {
 		p.cook = ast.NewCook()
 		p.block = p.cook.Block()
+		// a parser can be reused to parse another Cookfile
+		p.vars, p.records = make(map[string]string), make(map[string]*ast.RecordType)
+		p.recordLits, p.recordRefs, p.selectors = nil, nil, nil
 		return p.parse()
 	} else {
 		return nil, err
@@ -120,23 +205,48 @@ func (p *parser) ParseSrc(file *token.File, src []byte) (ast.Cook, error) {
 func (p *parser) parse() (cook ast.Cook, err error) {
 	// scan include directive first
 nextFile:
+	p.namespaces = make(map[string]bool)
 	for p.next(); p.cTok != token.EOF; {
-		if p.cTok == token.INCLUDE {
+		switch p.keyword(true) {
+		case token.INCLUDE:
 			p.parseIncludeDirective()
 			continue
+		case token.IMPORT:
+			p.parseImportDirective()
+			continue
 		}
 		break
 	}
 	for p.cTok != token.EOF {
-		switch p.cTok {
+		switch p.keyword(true) {
 		case token.INCLUDE:
 			p.errorHandler(p.curPos(), "include directive must place at the very top of the file.")
+		case token.IMPORT:
+			p.errorHandler(p.curPos(), "import directive must place at the very top of the file.")
 		case token.IDENT:
-			p.parseIdentifier(true)
+			if p.isLibraryMember(p.cLit) {
+				p.errorHandler(p.curPos(), "%s of an imported library cannot be declared or modified", p.cLit)
+			} else {
+				p.parseIdentifier(true)
+			}
+		case token.RECORD:
+			p.parseRecord()
 		case token.FOR:
 			p.parseForLoop(false)
 		case token.IF:
 			p.parseIf(false, nil)
+		case token.WORKIN:
+			p.parseWorkIn(false)
+		case token.SWITCH:
+			p.parseSwitch(false)
+		case token.DEFER:
+			p.parseDefer()
+		case token.WHILE:
+			p.parseWhile()
+		case token.DO:
+			p.parseDo()
+		case token.RETRY:
+			p.parseRetry(false)
 		case token.AT, token.HASH:
 			p.parseCallReference(false, nil)
 		case token.EXIT:
@@ -173,6 +283,7 @@ nextFile:
 		goto nextFile
 	}
 
+	p.checkRecords()
 	if p.errs != nil {
 		// return error stack
 		return nil, p.errs
@@ -211,13 +322,240 @@ func (p *parser) parseIncludeDirective() {
 	}
 }
 
+// parseImportDirective parse the library given by the directive "import 'path' as name" and add it
+// into the namespace name.
+func (p *parser) parseImportDirective() {
+	p.next()
+	offs, path := p.cOffs, p.cLit
+	if p.expect(token.STRING) == -1 {
+		return
+	}
+	if p.cTok != token.IDENT || p.cLit != "as" {
+		p.errorHandler(p.curPos(), "import directive expected as but got %s", p.cLit)
+		return
+	}
+	p.next()
+	name := p.cLit
+	if p.expect(token.IDENT) == -1 {
+		return
+	} else if strings.Contains(name, ".") {
+		p.errorHandler(p.tfile.Position(offs), "invalid namespace %s", name)
+		return
+	}
+	p.expect(token.LF)
+	// the namespace is usable even if the library has error so it does not report further error
+	p.namespaces[name] = true
+	file, err := resolveImport(filepath.Dir(p.tfile.Name()), path)
+	if err != nil {
+		p.errorHandler(p.tfile.Position(offs), err.Error())
+		return
+	}
+	if slices.Contains(p.imp.chain, file) {
+		p.errorHandler(p.tfile.Position(offs), "import cycle %s -> %s", strings.Join(p.imp.chain, " -> "), file)
+		return
+	}
+	lib, ok := p.imp.libs[file]
+	if !ok {
+		if lib, err = newParser(p.imp).Parse(file); err != nil {
+			if p.errs == nil {
+				p.errs = &cookErrors.CookError{}
+			}
+			if ce, ok := err.(*cookErrors.CookError); ok {
+				*p.errs = append(*p.errs, *ce...)
+			} else {
+				p.errs.StackError(err)
+			}
+			return
+		}
+		p.imp.libs[file] = lib
+	}
+	if err = p.cook.AddModule(name, lib); err != nil {
+		p.errorHandler(p.tfile.Position(offs), err.Error())
+	}
+}
+
+// resolveImport return the absolute path of the library Cookfile. A path begin with "." or an absolute
+// path is used as it is, otherwise the path is looked up from directory cook_modules in dir or any of
+// its parent directory then from each directory listed in environment variable COOKPATH. If the path
+// is a directory then the file Cookfile inside the directory is used.
+func resolveImport(dir, path string) (string, error) {
+	// a url is resolved the same way as a path, e.g. https://github.com/org/lib is github.com/org/lib
+	if _, after, ok := strings.Cut(path, "://"); ok {
+		path = after
+	}
+	var candidates []string
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else if path == "." || path == ".." || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		candidates = append(candidates, filepath.Join(dir, path))
+	} else {
+		if abs, err := filepath.Abs(dir); err == nil {
+			for d := abs; ; d = filepath.Dir(d) {
+				candidates = append(candidates, filepath.Join(d, "cook_modules", path))
+				if filepath.Dir(d) == d {
+					break
+				}
+			}
+		}
+		for _, d := range filepath.SplitList(os.Getenv("COOKPATH")) {
+			if d != "" {
+				candidates = append(candidates, filepath.Join(d, path))
+			}
+		}
+	}
+	for _, file := range candidates {
+		stat, err := os.Stat(file)
+		if err == nil && stat.IsDir() {
+			file = filepath.Join(file, "Cookfile")
+			stat, err = os.Stat(file)
+		}
+		if err == nil && !stat.IsDir() {
+			return filepath.Abs(file)
+		}
+	}
+	return "", fmt.Errorf("imported library %s not found", path)
+}
+
+// parseRecord parse record declaration, e.g. record Package { name: string, version: string = "0.1.0", deps: array }
+// the fields are separated by comma or newline.
+func (p *parser) parseRecord() {
+	offs := p.cOffs
+	p.next()
+	rt := &ast.RecordType{Base: &ast.Base{Offset: offs, File: p.tfile}, Name: p.cLit}
+	if p.expect(token.IDENT) == -1 {
+		return
+	} else if strings.Contains(rt.Name, ".") {
+		p.errorHandler(rt.Position(), "invalid record name %s", rt.Name)
+		return
+	} else if p.expect(token.LBRACE) == -1 {
+		return
+	}
+	for {
+		for p.cTok == token.LF {
+			p.next()
+		}
+		if p.cTok == token.RBRACE {
+			break
+		}
+		rf := &ast.RecordField{Name: &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}}
+		if p.expect(token.IDENT) == -1 {
+			return
+		} else if rt.Field(rf.Name.Name) != -1 {
+			p.errorHandler(rf.Name.Position(), "duplicate field %s", rf.Name.Name)
+			return
+		}
+		if p.cTok == token.COLON {
+			switch p.next(); {
+			case token.TINTEGER <= p.cTok && p.cTok <= token.TMAP:
+				rf.Type = p.cTok
+			case p.cTok == token.IDENT:
+				rf.Type, rf.Record = token.IDENT, p.cLit
+				p.recordRefs = append(p.recordRefs, &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit})
+			default:
+				p.errorHandler(p.curPos(), "expect a type but got %s", p.cTok)
+				return
+			}
+			p.next()
+		}
+		if p.cTok == token.ASSIGN {
+			if rf.Default = p.parseBinaryExpr(false, token.LowestPrec+1); rf.Default == nil {
+				return
+			}
+		}
+		rt.Fields = append(rt.Fields, rf)
+		switch p.cTok {
+		case token.COMMA:
+			p.next()
+		case token.LF, token.RBRACE, token.IDENT:
+			// newline after a type keyword is skipped thus the next field may follow directly
+		default:
+			p.errorHandler(p.curPos(), "expect , or } but got %s", p.cTok)
+			return
+		}
+	}
+	p.next()
+	p.expect(token.LF)
+	if err := p.cook.AddRecord(rt); err != nil {
+		p.errorHandler(rt.Position(), err.Error())
+	} else {
+		p.records[rt.Name] = rt
+	}
+}
+
+// checkRecords validate the record constructors, the record type references and the field of
+// the variables which is known to hold a record once every Cookfile is parsed. A record of an
+// imported library is validated at runtime.
+func (p *parser) checkRecords() {
+	for _, ref := range p.recordRefs {
+		if !strings.Contains(ref.Name, ".") && p.records[ref.Name] == nil {
+			p.errorHandler(ref.Position(), "record %s is not declared", ref.Name)
+		}
+	}
+	for _, rl := range p.recordLits {
+		rt := p.records[rl.Name]
+		if rt == nil {
+			continue
+		}
+		for _, f := range rl.Fields {
+			if rt.Field(f.Name) == -1 {
+				p.errorHandler(f.Position(), "record %s has no field %s", rt.Name, f.Name)
+			}
+		}
+		for _, f := range rt.Fields {
+			if f.Default == nil && !slices.ContainsFunc(rl.Fields, func(id *ast.Ident) bool { return id.Name == f.Name.Name }) {
+				p.errorHandler(rl.Position(), "record %s require field %s", rt.Name, f.Name.Name)
+			}
+		}
+	}
+	for _, sel := range p.selectors {
+		parts := strings.Split(sel.Name, ".")
+		record, ok := p.vars[parts[0]]
+		if !ok {
+			p.errorHandler(sel.Position(), "namespace %s is not imported", parts[0])
+			continue
+		}
+		for i, name := range parts[1:] {
+			rt := p.records[record]
+			if rt == nil {
+				break
+			}
+			ind := rt.Field(name)
+			if ind == -1 {
+				p.errorHandler(sel.Position(), "record %s has no field %s", rt.Name, name)
+				break
+			}
+			if record = rt.Fields[ind].Record; record == "" && i+2 < len(parts) && rt.Fields[ind].Type != token.ILLEGAL {
+				p.errorHandler(sel.Position(), "field %s of record %s is not a record", name, rt.Name)
+				break
+			}
+		}
+	}
+}
+
 func (p *parser) parseIdentifier(head bool) {
+	if strings.Contains(p.cLit, ".") && (p.nTok == token.COLON || p.nTok == token.LPAREN) {
+		p.errorHandler(p.curPos(), "invalid target or function name %s", p.cLit)
+		return
+	}
 	switch p.nTok {
 	case token.COLON:
-		p.parseTarget()
+		if p.isAnnotatedVar() {
+			p.parseAnnotatedAssignStatement()
+		} else {
+			p.parseTarget()
+		}
 	case token.LPAREN:
+		if p.isTargetDecl() {
+			p.parseTarget()
+			return
+		}
 		// function declaration or calling a function
-		p.parseDeclareFunction(false)
+		if fn := p.parseDeclareFunction(false); fn != nil {
+			p.cook.AddFunction(fn)
+			if p.cTok == token.LF {
+				p.next()
+			}
+		}
 	case token.LBRACK:
 		// index expression
 		if x := p.parseIndexExpression(); x != nil {
@@ -250,11 +588,172 @@ func (p *parser) parseIdentifier(head bool) {
 	}
 }
 
+// isTargetDecl report whether the identifier followed by "(" declare a target with parameters rather
+// than a function, the parameter list of a target is followed by ":".
+func (p *parser) isTargetDecl() bool {
+	src, depth := p.s.src, 0
+	var quote byte
+	for i := p.nOffs; i < len(src); i++ {
+		switch c := src[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				// a function may annotate its result, e.g. fn(a): string { ... }
+				rest := bytes.TrimLeft(src[i+1:], " \t")
+				if len(rest) == 0 || rest[0] != ':' {
+					return false
+				}
+				rest = bytes.TrimLeft(rest[1:], " \t")
+				return len(rest) == 0 || rest[0] == '\n' || rest[0] == '\r' || bytes.HasPrefix(rest, []byte("//")) ||
+					bytes.HasPrefix(rest, []byte("/*"))
+			}
+		}
+	}
+	return false
+}
+
+// keyword return the contextual keyword which begin a statement at the current identifier, or IDENT if
+// the identifier is the name of a variable, a target or a function. Any other token is returned as is.
+// head is true at the top level of a Cookfile where a function or a target may be declared.
+func (p *parser) keyword(head bool) token.Token {
+	if p.cTok != token.IDENT {
+		return p.cTok
+	}
+	tok := token.LookupContextual(p.cLit)
+	switch tok {
+	case token.IDENT, token.CASE, token.DEFAULT:
+		// a clause of switch statement is recognized by caseClause
+		return token.IDENT
+	case token.IMPORT:
+		if p.nTok == token.STRING {
+			return tok
+		}
+		return token.IDENT
+	case token.RECORD:
+		if p.nTok == token.IDENT {
+			return tok
+		}
+		return token.IDENT
+	}
+	switch p.nTok {
+	case token.COLON:
+		// a loop label, e.g. while:outer, rather than a target or an annotated variable
+		if (tok == token.WHILE || tok == token.DO) && !p.isAnnotatedVar() {
+			rest := bytes.TrimLeft(p.s.src[p.nOffs+1:], " \t")
+			if len(rest) > 0 && isLetter(rune(rest[0])) {
+				return tok
+			}
+		}
+		return token.IDENT
+	case token.LPAREN:
+		// a target or a function may be declared where the statement of a target begin
+		if head && (p.isTargetDecl() || p.isFuncSignature(p.nOffs)) {
+			return token.IDENT
+		}
+	case token.LBRACK, token.INC, token.DEC, token.LF, token.EOF:
+		return token.IDENT
+	}
+	if isAssign(p.nTok) {
+		return token.IDENT
+	}
+	return tok
+}
+
+// caseClause return CASE or DEFAULT if the current identifier begin a clause of switch statement,
+// otherwise the current token is returned.
+func (p *parser) caseClause() token.Token {
+	if p.cTok == token.IDENT {
+		switch tok := token.LookupContextual(p.cLit); tok {
+		case token.DEFAULT:
+			if p.nTok == token.COLON && !p.isAnnotatedVar() {
+				return tok
+			}
+		case token.CASE:
+			switch p.nTok {
+			case token.COLON, token.LBRACK, token.INC, token.DEC, token.LF, token.EOF:
+			default:
+				if !isAssign(p.nTok) {
+					return tok
+				}
+			}
+		}
+	}
+	return p.cTok
+}
+
+// isAssign report whether tok is an assignment operator
+func isAssign(tok token.Token) bool {
+	return (token.ADD_ASSIGN <= tok && tok <= token.REM_ASSIGN) || (token.AND_ASSIGN <= tok && tok <= token.ASSIGN)
+}
+
+// isAnnotatedVar report whether the identifier followed by ":" declare a variable with a type
+// annotation, e.g. A: integer = 1, rather than a target.
+func (p *parser) isAnnotatedVar() bool {
+	rest := bytes.TrimLeft(p.s.src[p.nOffs+1:], " \t")
+	i := 0
+	for i < len(rest) && isLetter(rune(rest[i])) {
+		i++
+	}
+	if typ := token.Lookup(string(rest[:i]), token.IDENT); typ < token.TINTEGER || typ > token.TMAP {
+		return false
+	}
+	rest = bytes.TrimLeft(rest[i:], " \t")
+	return len(rest) > 1 && rest[0] == '=' && rest[1] != '='
+}
+
+// parseAnnotatedAssignStatement parse an assignment to a variable with a type annotation,
+// e.g. A: integer = 1
+func (p *parser) parseAnnotatedAssignStatement() {
+	settable := &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}
+	p.next()
+	typ := p.parseAnnotation()
+	if typ == token.ILLEGAL {
+		return
+	} else if p.nTok != token.ASSIGN {
+		p.errorHandler(p.curPos(), "expect = but got %s", p.nTok)
+		return
+	}
+	if as := p.parseAssignStatement(settable); as != nil {
+		as.Base.Offset = settable.Offset
+		as.Type = typ
+	}
+}
+
+// parseAnnotation parse the type after ":", the current token is the last token of the annotation
+// when it return. ILLEGAL is returned if the type is invalid.
+func (p *parser) parseAnnotation() token.Token {
+	if p.next(); p.cTok < token.TINTEGER || p.cTok > token.TMAP {
+		p.errorHandler(p.curPos(), "expect a type but got %s", p.cTok)
+		return token.ILLEGAL
+	}
+	return p.cTok
+}
+
 func (p *parser) parseTarget() {
 	offs, name := p.cOffs, p.cLit
 	p.next()
+	var params []*ast.TargetParam
+	if p.cTok == token.LPAREN {
+		if params = p.parseTargetParams(); params == nil {
+			return
+		} else if p.cTok != token.COLON {
+			p.errorHandler(p.curPos(), "expect : but got %s", p.cTok)
+			return
+		}
+	}
 	if t, err := p.cook.AddTarget(&ast.Base{File: p.tfile, Offset: offs}, name); err != nil {
 		p.errorHandler(p.curPos(), err.Error())
+	} else if t.Params = params; len(params) > 0 && (name == ast.TargetInitialize || name == ast.TargetFinalize) {
+		p.errorHandler(p.curPos(), "target %s cannot have parameters", name)
 	} else if p.next(); name == "all" && p.cTok == token.MUL {
 		t.SetCallAll()
 		p.next()
@@ -263,7 +762,44 @@ func (p *parser) parseTarget() {
 	}
 }
 
-func (p *parser) parseAssignStatement(settableNode ast.SettableNode) {
+// parseTargetParams parse the parameters of a target, e.g. (env = "staging", replicas: integer = 1)
+func (p *parser) parseTargetParams() []*ast.TargetParam {
+	params := []*ast.TargetParam{}
+	for p.next(); p.cTok != token.RPAREN; {
+		if len(params) > 0 && p.expect(token.COMMA) == -1 {
+			return nil
+		}
+		tp := &ast.TargetParam{Name: &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}}
+		if p.expect(token.IDENT) == -1 {
+			return nil
+		}
+		for _, prev := range params {
+			if prev.Name.Name == tp.Name.Name {
+				p.errorHandler(tp.Name.Position(), "duplicate parameter %s", tp.Name.Name)
+				return nil
+			}
+		}
+		p.declare(tp.Name.Name, "")
+		if p.cTok == token.COLON {
+			if p.next(); p.cTok < token.TINTEGER || p.cTok > token.TMAP {
+				p.errorHandler(p.curPos(), "expect a type but got %s", p.cTok)
+				return nil
+			}
+			tp.Type = p.cTok
+			p.next()
+		}
+		if p.cTok == token.ASSIGN {
+			if tp.Default = p.parseBinaryExpr(false, token.LowestPrec+1); tp.Default == nil {
+				return nil
+			}
+		}
+		params = append(params, tp)
+	}
+	p.next()
+	return params
+}
+
+func (p *parser) parseAssignStatement(settableNode ast.SettableNode) *ast.AssignStatement {
 	offs := p.cOffs
 	p.next()
 	op := p.cTok
@@ -288,9 +824,18 @@ func (p *parser) parseAssignStatement(settableNode ast.SettableNode) {
 			// newline is option on assign statement
 			p.next()
 		}
+		if id, ok := settableNode.(*ast.Ident); ok && !strings.Contains(id.Name, ".") {
+			record := ""
+			if rl, ok := assignStmt.Value.(*ast.RecordLit); ok && op == token.ASSIGN {
+				record = rl.Name
+			}
+			p.declare(id.Name, record)
+		}
 		p.block.Append(assignStmt)
+		return assignStmt
 	default:
 		p.errorHandler(p.curPos(), "unexpected %s", p.cTok)
+		return nil
 	}
 }
 
@@ -299,6 +844,8 @@ func (p *parser) parseBinaryExpr(isChaining bool, priority int) ast.Node {
 	if p.cTok == token.IDENT {
 		if p.nTok == token.LPAREN {
 			return p.parseTransformation()
+		} else if p.nTok == token.IDENT && ast.IsCollectionOp(p.cLit) {
+			return p.parseCollection()
 		} else if p.nTok == token.EXISTS {
 			offs, lit := p.cOffs, p.cLit
 			p.next()
@@ -450,6 +997,8 @@ func (p *parser) parseOperand() (x ast.Node, kind token.Token) {
 	case token.IDENT:
 		if p.nTok == token.LBRACK {
 			x = p.parseIndexExpression()
+		} else if p.nTok == token.LBRACE && !p.inHeader {
+			x = p.parseRecordLit()
 		} else {
 			offs := p.cOffs
 			x = &ast.Ident{Base: &ast.Base{Offset: offs, File: p.tfile}, Name: p.cLit}
@@ -458,7 +1007,7 @@ func (p *parser) parseOperand() (x ast.Node, kind token.Token) {
 		kind = token.IDENT
 	case token.INTEGER, token.FLOAT, token.STRING, token.BOOLEAN:
 		if p.cTok == token.STRING {
-			x = &ast.BasicLit{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Lit: p.cLit, Kind: p.cTok, Mark: p.s.src[p.cOffs-1]}
+			x = &ast.BasicLit{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Lit: p.cLit, Kind: p.cTok, Mark: p.quoteMark(p.cOffs)}
 		} else {
 			x = &ast.BasicLit{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Lit: p.cLit, Kind: p.cTok}
 		}
@@ -468,7 +1017,17 @@ func (p *parser) parseOperand() (x ast.Node, kind token.Token) {
 		x, kind = p.parseStringInterpolation(), token.STRING
 	case token.LPAREN:
 		lparen := p.cOffs
+		if p.isFuncLit() {
+			p.next()
+			if fn := p.parseDeclareFunction(true); fn != nil {
+				x = &ast.FuncLit{Base: &ast.Base{Offset: lparen, File: p.tfile}, Fn: fn}
+			}
+			return x, token.LPAREN
+		}
+		inHeader := p.inHeader
+		p.inHeader = false
 		inx := p.parseBinaryExpr(false, token.LowestPrec+1) // types may be parenthesized: (some type)
+		p.inHeader = inHeader
 		if p.expect(token.RPAREN) == -1 {
 			return nil, 0
 		}
@@ -478,12 +1037,73 @@ func (p *parser) parseOperand() (x ast.Node, kind token.Token) {
 		x, kind = p.parseMapLiteral(), token.MAP
 	case token.LBRACK:
 		x, kind = p.parserArrayLiteral(), token.ARRAY
+	case token.TINTEGER, token.TFLOAT, token.TSTRING, token.TBOOLEAN:
+		// type cast is an operand as well, e.g. call argument @print string(A)
+		if p.nTok != token.LPAREN {
+			p.errorHandler(p.curPos(), fmt.Sprintf("invalid token %s", p.cTok))
+			break
+		}
+		x, kind = p.parseTypeCaseExpr(), token.LPAREN
 	default:
 		p.errorHandler(p.curPos(), fmt.Sprintf("invalid token %s", p.cTok))
 	}
 	return
 }
 
+// isFuncLit report whether the current parenthesis begin a function literal, e.g. "(a, b) => a + b"
+// or "(a, b) { ... }", by looking ahead the source for a list of identifier follow by "=>" or "{".
+func (p *parser) isFuncLit() bool {
+	if p.nTok != token.IDENT && p.nTok != token.RPAREN {
+		return false
+	}
+	return p.isFuncSignature(p.cOffs)
+}
+
+// isFuncSignature report whether the parenthesis at lparen begin a list of parameters followed by
+// "=>" or "{" of a function.
+func (p *parser) isFuncSignature(lparen int) bool {
+	src, i := p.s.src, lparen+1
+	skip := func(lf bool) {
+		for i < len(src) && (src[i] == ' ' || src[i] == '\t' || lf && (src[i] == '\n' || src[i] == '\r')) {
+			i++
+		}
+	}
+	// annotation skip a type annotation, e.g. ": integer"
+	annotation := func() bool {
+		if i < len(src) && src[i] == ':' {
+			i++
+			skip(false)
+			start := i
+			for i < len(src) && isLetter(rune(src[i])) {
+				i++
+			}
+			if i == start {
+				return false
+			}
+			skip(false)
+		}
+		return true
+	}
+	for skip(true); i < len(src) && src[i] != ')'; skip(true) {
+		start := i
+		for i < len(src) && (src[i] == '_' || isLetter(rune(src[i])) || i > start && isDecimal(rune(src[i]))) {
+			i++
+		}
+		if i == start {
+			return false
+		} else if skip(true); annotation() && i < len(src) && src[i] == ',' {
+			i++
+		}
+	}
+	if i >= len(src) {
+		return false
+	}
+	i++
+	skip(false)
+	annotation()
+	return bytes.HasPrefix(src[i:], []byte("=>")) || !p.inHeader && i < len(src) && src[i] == '{'
+}
+
 func (p *parser) parseStringInterpolation() ast.Node {
 	offs := p.cOffs
 	sib := ast.NewStringInterpolationBuilder(p.s.src[offs-1])
@@ -552,17 +1172,53 @@ func (p *parser) parseMapLiteral() ast.Node {
 	}
 }
 
+// parseRecordLit parse record constructor, e.g. Package{name: "cook", version: "1.0"} the current
+// token is the closing brace once it's done.
+func (p *parser) parseRecordLit() ast.Node {
+	rl := &ast.RecordLit{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}
+	p.recordRefs = append(p.recordRefs, &ast.Ident{Base: rl.Base, Name: rl.Name})
+	p.next()
+	for {
+		for p.next(); p.cTok == token.LF; p.next() {
+		}
+		if p.cTok == token.RBRACE {
+			break
+		}
+		f := &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}
+		if p.expect(token.IDENT) == -1 {
+			return nil
+		} else if p.cTok != token.COLON {
+			p.errorHandler(p.curPos(), "expect : but got %s", p.cTok)
+			return nil
+		} else if slices.ContainsFunc(rl.Fields, func(id *ast.Ident) bool { return id.Name == f.Name }) {
+			p.errorHandler(f.Position(), "duplicate field %s", f.Name)
+			return nil
+		}
+		x := p.parseBinaryExpr(false, token.LowestPrec+1)
+		if x == nil {
+			return nil
+		}
+		rl.Fields, rl.Values = append(rl.Fields, f), append(rl.Values, x)
+		for p.cTok == token.LF {
+			p.next()
+		}
+		if p.cTok == token.RBRACE {
+			break
+		} else if p.cTok != token.COMMA {
+			p.errorHandler(p.curPos(), "expect , or } but got %s", p.cTok)
+			return nil
+		}
+	}
+	p.recordLits = append(p.recordLits, rl)
+	return rl
+}
+
 func (p *parser) parserArrayLiteral() ast.Node {
 	offs := p.s.offset
 	p.next()
 	var values []ast.Node
 	if p.cTok != token.RBRACK {
-		x, tok := p.parseOperand()
-		if isGlob, nodes := parseArrayFile(x, tok); isGlob {
-			values = append(values, nodes...)
-		} else {
-			values = append(values, x)
-		}
+		values = append(values, p.parseArrayElement())
 	} else {
 		values = make([]ast.Node, 0)
 	}
@@ -576,12 +1232,10 @@ loop:
 			if p.cTok == token.RBRACK {
 				break loop
 			}
-			y, tok := p.parseOperand()
-			if isGlob, nodes := parseArrayFile(y, tok); isGlob {
-				values = append(values, nodes...)
-			} else {
-				values = append(values, y)
-			}
+			values = append(values, p.parseArrayElement())
+		default:
+			p.errorHandler(p.curPos(), "expect , or ] but got %s", p.cTok)
+			return nil
 		}
 	}
 	if p.expect(token.RBRACK) != -1 {
@@ -623,7 +1277,9 @@ func (p *parser) parseForLoop(inForLoop bool) {
 				return
 			}
 			var tok token.Token
+			p.inHeader = true
 			oprd, tok = p.parseOperand()
+			p.inHeader = false
 			switch tok {
 			case token.INTEGER, token.FLOAT, token.BOOLEAN:
 				p.errorHandler(oprd.Position(), "for loop can iterate through %s", tok)
@@ -635,6 +1291,8 @@ func (p *parser) parseForLoop(inForLoop bool) {
 			}
 			i = &ast.Ident{Base: &ast.Base{Offset: ioffs, File: p.tfile}, Name: ilit}
 			value = &ast.Ident{Base: &ast.Base{Offset: voffs, File: p.tfile}, Name: vlit}
+			p.declare(ilit, "")
+			p.declare(vlit, "")
 		} else {
 			// a range loop
 			if p.expect(token.IN) == -1 {
@@ -646,12 +1304,20 @@ func (p *parser) parseForLoop(inForLoop bool) {
 				return
 			}
 			i = &ast.Ident{Base: &ast.Base{Offset: ioffs, File: p.tfile}, Name: ilit}
+			p.declare(ilit, "")
 		}
 	} else if p.expect(token.LBRACE) == -1 {
 		return
 	}
+	n := len(p.loopVars)
+	for _, v := range []*ast.Ident{i, value} {
+		if v != nil {
+			p.loopVars = append(p.loopVars, v.Name)
+		}
+	}
 	bstmt := &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
-	if p.parseBlock(true, bstmt) {
+	ok := p.parseBlock(true, bstmt)
+	if p.loopVars = p.loopVars[:n]; ok {
 		p.block.Append(&ast.ForStatement{
 			Base:  &ast.Base{Offset: offs, File: p.tfile},
 			Label: label,
@@ -664,6 +1330,145 @@ func (p *parser) parseForLoop(inForLoop bool) {
 	}
 }
 
+// parseLoopLabel parse the optional label of while or do loop, e.g. while:outer, the current token
+// is left at the keyword or the label.
+func (p *parser) parseLoopLabel() (label string, ok bool) {
+	if p.nTok == token.COLON {
+		p.next()
+		if p.next(); p.cTok != token.IDENT {
+			p.errorHandler(p.curPos(), "expect loop label but got %s", p.cTok)
+			return "", false
+		}
+		label = p.cLit
+	}
+	return label, true
+}
+
+// parseWhile parse while loop which execute its block as long as the condition is true.
+//
+//	while:label COND {
+//	}
+func (p *parser) parseWhile() {
+	offs := p.cOffs
+	label, ok := p.parseLoopLabel()
+	if !ok {
+		return
+	}
+	p.inHeader = true
+	cond := p.parseBinaryExpr(false, token.LowestPrec+1)
+	p.inHeader = false
+	if cond == nil {
+		return
+	}
+	blcOffs := p.cOffs
+	if p.expect(token.LBRACE) == -1 {
+		return
+	}
+	bstmt := &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
+	if p.parseBlock(true, bstmt) {
+		p.block.Append(&ast.WhileStatement{
+			Base:  &ast.Base{Offset: offs, File: p.tfile},
+			Label: label,
+			Cond:  cond,
+			Insts: bstmt,
+		})
+	}
+}
+
+// parseDo parse do while loop which execute its block at least once, the condition must be on
+// the same line as the end of the block.
+//
+//	do:label {
+//	} while COND
+func (p *parser) parseDo() {
+	offs := p.cOffs
+	label, ok := p.parseLoopLabel()
+	if !ok {
+		return
+	}
+	p.next()
+	blcOffs := p.cOffs
+	if p.expect(token.LBRACE) == -1 {
+		return
+	}
+	bstmt := &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
+	p.parseStatements(true, bstmt)
+	if p.expect(token.RBRACE) == -1 {
+		return
+	} else if p.cTok != token.IDENT || p.cLit != token.WHILE.String() {
+		p.errorHandler(p.curPos(), "expect %s but got %s", token.WHILE, p.cTok)
+		return
+	}
+	if cond := p.parseBinaryExpr(false, token.LowestPrec+1); cond != nil {
+		p.block.Append(&ast.WhileStatement{
+			Base:  &ast.Base{Offset: offs, File: p.tfile},
+			Label: label,
+			Cond:  cond,
+			Do:    true,
+			Insts: bstmt,
+		})
+		p.expect(token.LF)
+	}
+}
+
+// parseRetry parse retry block, the delay between attempts and the timeout are optional.
+//
+//	retry 5 every 2s timeout 1m {
+//	}
+func (p *parser) parseRetry(inForLoop bool) {
+	rs := &ast.RetryStatement{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}}
+	p.inHeader = true
+	rs.Attempts = p.parseBinaryExpr(false, token.LowestPrec+1)
+	ok := rs.Attempts != nil
+	for ok && p.cTok == token.IDENT && (p.cLit == "every" || p.cLit == "timeout") {
+		opt := &rs.Every
+		if p.cLit == "timeout" {
+			opt = &rs.Timeout
+		}
+		if *opt != nil {
+			p.errorHandler(p.curPos(), "%s is given more than once", p.cLit)
+			ok = false
+		} else {
+			*opt = p.parseBinaryExpr(false, token.LowestPrec+1)
+			ok = *opt != nil
+		}
+	}
+	p.inHeader = false
+	if !ok {
+		return
+	}
+	blcOffs := p.cOffs
+	if p.expect(token.LBRACE) == -1 {
+		return
+	}
+	rs.Insts = &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
+	if p.parseBlock(inForLoop, rs.Insts) {
+		p.block.Append(rs)
+	}
+}
+
+func (p *parser) parseWorkIn(inForLoop bool) {
+	offs := p.cOffs
+	p.inHeader = true
+	dir := p.parseBinaryExpr(false, token.LowestPrec+1)
+	p.inHeader = false
+	if dir == nil {
+		return
+	}
+	blcOffs := p.cOffs
+	if p.expect(token.LBRACE) == -1 {
+		return
+	}
+	bstmt := &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
+	if p.parseBlock(inForLoop, bstmt) {
+		p.block.Append(&ast.WorkInStatement{
+			Base:  &ast.Base{Offset: offs, File: p.tfile},
+			Dir:   dir,
+			Insts: bstmt,
+		})
+	}
+}
+
 func (p *parser) parseIf(inForLoop bool, elstmt *ast.ElseStatement) {
 	offs := p.cOffs
 
@@ -703,7 +1508,9 @@ func (p *parser) parseIf(inForLoop bool, elstmt *ast.ElseStatement) {
 	// 	p.next()
 	// default:
 
+	p.inHeader = true
 	cond := p.parseBinaryExpr(false, token.LowestPrec+1)
+	p.inHeader = false
 	blcOffs := p.cOffs
 	if p.expect(token.LBRACE) == -1 {
 		return
@@ -746,86 +1553,268 @@ func (p *parser) parseIf(inForLoop bool, elstmt *ast.ElseStatement) {
 }
 
 func (p *parser) parseBlock(inForLoop bool, block *ast.BlockStatement) bool {
+	p.parseStatements(inForLoop, block)
+	endBlock := p.cTok == token.RBRACE
+	p.next()
+	if p.cTok == token.LF {
+		p.next()
+	}
+	return endBlock
+}
+
+// parseStatements parse statements into block until the end of the block or a case clause of switch statement
+func (p *parser) parseStatements(inForLoop bool, block *ast.BlockStatement) {
 	prevBlock := p.block
 	p.block = block
+	// only a case clause end at the next case clause, a nested block does not
+	inCase := p.inCase
+	p.inCase = false
 	defer func() { p.block = prevBlock }()
 	for p.cTok != token.RBRACE && p.cTok != token.EOF {
-		switch p.cTok {
-		case token.IDENT:
-			if p.nTok == token.INC || p.nTok == token.DEC {
-				offs, lit := p.cOffs, p.cLit
-				p.next()
-				p.block.Append(&ast.ExprWrapperStatement{
-					X: &ast.IncDec{
-						Op: p.cTok,
-						X:  &ast.Ident{Base: &ast.Base{Offset: offs, File: p.tfile}, Name: lit},
-					},
-				})
-				p.next()
-				p.expect(token.LF)
-			} else {
-				if p.nTok == token.LBRACK {
-					if x := p.parseIndexExpression(); x != nil {
-						p.parseAssignStatement(x)
-					}
-				} else {
-					settable := &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}
-					p.parseAssignStatement(settable)
-				}
-			}
-		case token.AT, token.HASH:
-			// parse invocation command call
-			p.parseCallReference(false, nil)
-		case token.FOR:
-			p.parseForLoop(inForLoop)
-		case token.IF:
-			p.parseIf(inForLoop, nil)
-		case token.EXIT:
-			// parse exit
-			offs := p.cOffs
-			if code := p.parseBinaryExpr(false, token.LowestPrec+1); code != nil {
-				p.block.Append(&ast.ExprWrapperStatement{
-					X: &ast.Exit{Base: &ast.Base{Offset: offs, File: p.tfile}, ExitCode: code},
-				})
-			}
-			p.expect(token.LF)
-		case token.RETURN:
-			// parse return
-			p.block.Append(&ast.ReturnStatement{
-				Base: &ast.Base{Offset: p.cOffs, File: p.tfile},
-				X:    p.parseBinaryExpr(false, token.LowestPrec+1),
+		if tok := p.caseClause(); inCase && (tok == token.CASE || tok == token.DEFAULT) {
+			break
+		}
+		p.parseStatement(inForLoop)
+	}
+}
+
+// parseStatement parse a single statement and append it to the current block
+func (p *parser) parseStatement(inForLoop bool) {
+	switch p.keyword(false) {
+	case token.IDENT:
+		if p.isLibraryMember(p.cLit) {
+			p.errorHandler(p.curPos(), "%s of an imported library cannot be declared or modified", p.cLit)
+		} else if p.nTok == token.COLON {
+			p.parseAnnotatedAssignStatement()
+		} else if p.nTok == token.INC || p.nTok == token.DEC {
+			offs, lit := p.cOffs, p.cLit
+			p.next()
+			p.block.Append(&ast.ExprWrapperStatement{
+				X: &ast.IncDec{
+					Op: p.cTok,
+					X:  &ast.Ident{Base: &ast.Base{Offset: offs, File: p.tfile}, Name: lit},
+				},
 			})
-		case token.BREAK, token.CONTINUE:
-			offs, label, op := p.cOffs, "", p.cTok
 			p.next()
-			if p.cTok == token.COLON {
-				p.next()
-				label = p.cLit
-				if p.expect(token.IDENT) == -1 {
-					return false
+			p.expect(token.LF)
+		} else {
+			if p.nTok == token.LBRACK {
+				if x := p.parseIndexExpression(); x != nil {
+					p.parseAssignStatement(x)
 				}
+			} else {
+				settable := &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}
+				p.parseAssignStatement(settable)
 			}
-			p.block.Append(&ast.BreakContinueStatement{
-				Base:  &ast.Base{Offset: offs, File: p.tfile},
-				Op:    op,
-				Label: label,
+		}
+	case token.AT, token.HASH:
+		// parse invocation command call
+		p.parseCallReference(false, nil)
+	case token.FOR:
+		p.parseForLoop(inForLoop)
+	case token.IF:
+		p.parseIf(inForLoop, nil)
+	case token.WORKIN:
+		p.parseWorkIn(inForLoop)
+	case token.SWITCH:
+		p.parseSwitch(inForLoop)
+	case token.DEFER:
+		p.parseDefer()
+	case token.WHILE:
+		p.parseWhile()
+	case token.DO:
+		p.parseDo()
+	case token.RETRY:
+		p.parseRetry(inForLoop)
+	case token.EXIT:
+		// parse exit
+		offs := p.cOffs
+		if code := p.parseBinaryExpr(false, token.LowestPrec+1); code != nil {
+			p.block.Append(&ast.ExprWrapperStatement{
+				X: &ast.Exit{Base: &ast.Base{Offset: offs, File: p.tfile}, ExitCode: code},
 			})
-			if p.cTok == token.LF {
-				// skip optional line feed
-				p.next()
+		}
+		p.expect(token.LF)
+	case token.RETURN:
+		// parse return
+		p.block.Append(&ast.ReturnStatement{
+			Base: &ast.Base{Offset: p.cOffs, File: p.tfile},
+			X:    p.parseBinaryExpr(false, token.LowestPrec+1),
+		})
+		if p.cTok == token.LF {
+			// skip optional line feed
+			p.next()
+		}
+	case token.BREAK, token.CONTINUE:
+		offs, label, op := p.cOffs, "", p.cTok
+		p.next()
+		if p.cTok == token.COLON {
+			p.next()
+			label = p.cLit
+			if p.expect(token.IDENT) == -1 {
+				return
 			}
+		}
+		p.block.Append(&ast.BreakContinueStatement{
+			Base:  &ast.Base{Offset: offs, File: p.tfile},
+			Op:    op,
+			Label: label,
+		})
+		if p.cTok == token.LF {
+			// skip optional line feed
+			p.next()
+		}
+	case token.COMMENT:
+		p.next()
+		// eat comment for now
+		// TODO: add comment to token file
+	default:
+		p.errorHandler(p.curPos(), "invalid token %s", p.cTok)
+	}
+}
+
+// parseDefer parse defer statement, the deferred statement is either a block or a single call,
+// assignment or increment statement.
+//
+//	defer @rm -r dir
+//	defer {
+//	  ...
+//	}
+func (p *parser) parseDefer() {
+	offs := p.cOffs
+	p.next()
+	block := &ast.BlockStatement{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}}
+	var stmt ast.Statement
+	switch p.cTok {
+	case token.LBRACE:
+		// the deferred block is executed after the loop end thus break and continue is not allowed
+		p.next()
+		if !p.parseBlock(false, block) {
+			return
+		}
+		stmt = block
+	case token.AT, token.HASH, token.IDENT:
+		prevBlock := p.block
+		p.block = block
+		p.parseStatement(false)
+		if p.block = prevBlock; len(block.Stmts) != 1 {
+			return
+		}
+		stmt = block.Stmts[0]
+	default:
+		p.errorHandler(p.curPos(), "defer expect a call, an assignment or a block but got %s", p.cTok)
+		return
+	}
+	p.block.Append(&ast.DeferStatement{
+		Base:     &ast.Base{Offset: offs, File: p.tfile},
+		Stmt:     stmt,
+		LoopVars: slices.Clone(p.loopVars),
+	})
+}
+
+// parseSwitch parse switch statement, the value to be matched is optional.
+//
+//	switch X {
+//	case 1, 2:
+//	case is integer | float:
+//	case ~"*.go":
+//	case in [1..10):
+//	case on linux:
+//	default:
+//	}
+func (p *parser) parseSwitch(inForLoop bool) {
+	sst := &ast.SwitchStatement{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}}
+	if p.nTok == token.LBRACE {
+		p.next()
+	} else {
+		p.inHeader = true
+		sst.X = p.parseBinaryExpr(false, token.LowestPrec+1)
+		if p.inHeader = false; sst.X == nil {
+			return
+		}
+	}
+	if p.expect(token.LBRACE) == -1 {
+		return
+	}
+	for p.cTok != token.RBRACE {
+		switch p.caseClause() {
 		case token.COMMENT:
 			p.next()
-			// eat comment for now
-			// TODO: add comment to token file
+		case token.CASE:
+			cc := &ast.CaseClause{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}}
+			for {
+				pattern := p.parseCasePattern(sst.X != nil)
+				if pattern == nil {
+					return
+				}
+				cc.Patterns = append(cc.Patterns, pattern)
+				if p.cTok != token.COMMA {
+					break
+				}
+			}
+			blcOffs := p.cOffs
+			if p.expect(token.COLON) == -1 {
+				return
+			}
+			cc.Insts = &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
+			p.inCase = true
+			p.parseStatements(inForLoop, cc.Insts)
+			sst.Cases = append(sst.Cases, cc)
+		case token.DEFAULT:
+			if sst.Default != nil {
+				p.errorHandler(p.curPos(), "multiple default case in switch")
+				return
+			}
+			p.next()
+			blcOffs := p.cOffs
+			if p.expect(token.COLON) == -1 {
+				return
+			}
+			sst.Default = &ast.BlockStatement{Base: &ast.Base{Offset: blcOffs, File: p.tfile}}
+			p.inCase = true
+			p.parseStatements(inForLoop, sst.Default)
+		default:
+			p.errorHandler(p.curPos(), "expect case or default but got %s", p.cTok)
+			return
 		}
 	}
-	endBlock := p.cTok == token.RBRACE
 	p.next()
 	if p.cTok == token.LF {
 		p.next()
 	}
-	return endBlock
+	p.block.Append(sst)
+}
+
+// parseCasePattern parse a pattern of case clause, current token is either case or comma.
+func (p *parser) parseCasePattern(hasValue bool) ast.Node {
+	switch p.nTok {
+	case token.IS, token.FD, token.IN:
+		if !hasValue {
+			p.next()
+			p.errorHandler(p.curPos(), "pattern %s require a switch value", p.cTok)
+			return nil
+		}
+	default:
+		return p.parseBinaryExpr(false, token.LowestPrec+1)
+	}
+	p.next()
+	switch offs := p.cOffs; p.cTok {
+	case token.IS:
+		return p.parseIsExpr(nil)
+	case token.FD:
+		p.next()
+		x, _ := p.parseOperand()
+		if x == nil {
+			return nil
+		}
+		return &ast.Unary{Base: &ast.Base{Offset: offs, File: p.tfile}, Op: token.FD, X: x}
+	default:
+		p.next()
+		if r := p.parseInterval(); r != nil {
+			return r
+		}
+		return nil
+	}
 }
 
 func (p *parser) parseIndexExpression() ast.SettableNode {
@@ -905,18 +1894,27 @@ func (p *parser) parseFallbackExpr(x ast.Node) ast.Node {
 func (p *parser) parseIsExpr(x ast.Node) ast.Node {
 	offs := p.cOffs
 	var types []token.Token
+	var records []string
 	p.next()
-	for {
+	// a record type is only accepted right after "is" or "|" otherwise an identifier on the next
+	// line would be taken as a type since the newline after a type keyword is skipped.
+	for afterOp := true; ; {
 		switch {
 		case token.TINTEGER <= p.cTok && p.cTok <= token.TMAP:
 			types = append(types, p.cTok)
+			afterOp = false
+		case p.cTok == token.IDENT && afterOp:
+			records = append(records, p.cLit)
+			p.recordRefs = append(p.recordRefs, &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit})
+			afterOp = false
 		case p.cTok == token.OR:
-			// do nothing
+			afterOp = true
 		default:
 			return &ast.IsType{
-				Base:  &ast.Base{Offset: offs, File: p.tfile},
-				X:     x,
-				Types: types,
+				Base:    &ast.Base{Offset: offs, File: p.tfile},
+				X:       x,
+				Types:   types,
+				Records: records,
 			}
 		}
 		p.next()
@@ -959,6 +1957,52 @@ func (p *parser) parseTransformation() ast.Node {
 	return nil
 }
 
+// parseCollection parse collection operation "op A(i, v) => expr". The accumulator of reduce
+// may given an initial value "reduce A(acc = 0, i, v) => acc + v", flatten may omit the function
+// while zip take two or more array without function "zip A B".
+func (p *parser) parseCollection() ast.Node {
+	c := &ast.Collection{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Op: ast.CollectionOp(p.cLit)}
+	p.next()
+	for p.cTok == token.IDENT {
+		c.X = append(c.X, &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit})
+		if p.next(); c.Op != ast.Zip {
+			break
+		}
+	}
+	switch {
+	case c.Op == ast.Zip:
+		if len(c.X) < 2 {
+			p.errorHandler(p.curPos(), "zip required at least 2 arrays")
+			return nil
+		}
+		return c
+	case c.Op == ast.Flatten && p.cTok != token.LPAREN:
+		return c
+	case p.expect(token.LPAREN) == -1:
+		return nil
+	}
+	var acc *ast.Ident
+	if c.Op == ast.Reduce && p.cTok == token.IDENT && p.nTok == token.ASSIGN {
+		acc = &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit}
+		p.declare(acc.Name, "")
+		p.next()
+		if c.Init = p.parseBinaryExpr(false, token.LowestPrec+1); c.Init == nil {
+			return nil
+		} else if p.cTok == token.COMMA {
+			p.next()
+		}
+	}
+	if c.Fn = p.parseDeclareFunction(true); c.Fn == nil {
+		return nil
+	} else if acc != nil {
+		c.Fn.Args = append([]*ast.Ident{acc}, c.Fn.Args...)
+		if c.Fn.ArgTypes != nil {
+			c.Fn.ArgTypes = append([]token.Token{token.ILLEGAL}, c.Fn.ArgTypes...)
+		}
+	}
+	return c
+}
+
 func (p *parser) parseCallReference(assign bool, prev *ast.Call) ast.Node {
 	callOffs := p.cOffs
 	kind := p.cTok
@@ -996,6 +2040,17 @@ func (p *parser) parseCallReference(assign bool, prev *ast.Call) ast.Node {
 			})
 		case token.PIPE:
 			goto end
+		case token.IDENT:
+			if p.nTok == token.COLON {
+				// named argument of a target
+				offs, name := p.cOffs, p.cLit
+				p.next()
+				p.next()
+				x, _ := p.parseOperand()
+				args = append(args, &ast.NamedArg{Base: &ast.Base{Offset: offs, File: p.tfile}, Name: name, X: x})
+				break
+			}
+			fallthrough
 		default:
 			x, _ := p.parseOperand()
 			if redirect != nil {
@@ -1045,17 +2100,27 @@ func (p *parser) parseDeclareFunction(literal bool) *ast.Function {
 			return nil
 		}
 	}
-	if args := p.parseDeclareArgument(); args == nil {
+	if args, types := p.parseDeclareArgument(); args == nil {
 		return nil
 	} else if p.expect(token.RPAREN) != -1 {
+		result := token.ILLEGAL
+		if p.cTok == token.COLON {
+			if result = p.parseAnnotation(); result == token.ILLEGAL {
+				return nil
+			}
+			p.next()
+		}
 		blcOff := p.cOffs
 		switch p.cTok {
 		case token.LAMBDA:
 			if x := p.parseBinaryExpr(false, token.LowestPrec+1); x != nil {
 				return &ast.Function{
-					Lambda: token.LAMBDA,
-					Args:   args,
-					X:      x,
+					Name:     name,
+					Lambda:   token.LAMBDA,
+					Args:     args,
+					ArgTypes: types,
+					Result:   result,
+					X:        x,
 				}
 			}
 		case token.LBRACE:
@@ -1063,9 +2128,11 @@ func (p *parser) parseDeclareFunction(literal bool) *ast.Function {
 			block := &ast.BlockStatement{Base: &ast.Base{Offset: blcOff, File: p.tfile}}
 			if p.parseBlock(false, block) {
 				return &ast.Function{
-					Name:  name,
-					Args:  args,
-					Insts: block,
+					Name:     name,
+					Args:     args,
+					ArgTypes: types,
+					Result:   result,
+					Insts:    block,
 				}
 			}
 		default:
@@ -1075,34 +2142,48 @@ func (p *parser) parseDeclareFunction(literal bool) *ast.Function {
 	return nil
 }
 
-func (p *parser) parseDeclareArgument() []*ast.Ident {
-	var args []*ast.Ident
+// parseDeclareArgument parse the arguments of a function and their type annotation, e.g. (a: integer, b).
+// The types is nil if none of the argument is annotated.
+func (p *parser) parseDeclareArgument() (args []*ast.Ident, types []token.Token) {
+	args = []*ast.Ident{}
 	for p.cTok != token.RPAREN {
 		if p.cTok == token.IDENT {
 			args = append(args, &ast.Ident{Base: &ast.Base{Offset: p.cOffs, File: p.tfile}, Name: p.cLit})
-			if p.next(); p.cTok == token.COMMA {
+			p.declare(p.cLit, "")
+			if p.next(); p.cTok == token.COLON {
+				typ := p.parseAnnotation()
+				if typ == token.ILLEGAL {
+					return nil, nil
+				}
+				for len(types) < len(args)-1 {
+					types = append(types, token.ILLEGAL)
+				}
+				types = append(types, typ)
+				p.next()
+			}
+			if p.cTok == token.COMMA {
 				p.next()
 			}
 		} else {
 			p.errorHandler(p.curPos(), "expect identifier but got %s", p.cTok)
-			return nil
+			return nil, nil
 		}
 	}
-	return args
+	return args, types
 }
 
-func parseArrayFile(n ast.Node, tok token.Token) (isGlob bool, x []ast.Node) {
-	if tok == token.STRING {
-		bl := n.(*ast.BasicLit)
-		if mes, err := filepath.Glob(bl.Lit); err != nil || len(mes) == 0 {
-			return false, nil
-		} else {
-			for _, sf := range mes {
-				x = append(x, &ast.BasicLit{Lit: sf, Kind: token.STRING, Mark: bl.Mark})
-			}
-		}
-		return true, x
-	} else {
-		return false, nil
+// parseArrayElement parse an element of array literal. A string literal which contain glob special
+// character is a glob pattern to be expanded at runtime, prefixing a string or a string interpolation
+// with "!" make the pattern strict which then require at least one matching file.
+func (p *parser) parseArrayElement() ast.Node {
+	offs, strict := p.cOffs, false
+	if p.cTok == token.NOT && (p.nTok == token.STRING || p.nTok == token.STRING_ITP) {
+		strict = true
+		p.next()
+	}
+	x, _ := p.parseOperand()
+	if x != nil && (strict || ast.IsGlobPattern(x)) {
+		return &ast.Glob{Base: &ast.Base{Offset: offs, File: p.tfile}, Strict: strict, Pattern: x}
 	}
+	return x
 }
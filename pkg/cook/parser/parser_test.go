@@ -66,8 +66,8 @@ var simpleCases = []*parserInputCase{
 		in:  "#pub 'run' file '-o' \"${COVERAGE}/${base}.lcov.info\" \\\n \"--packages=.packages\" \"--report-on=lib\"",
 		out: "#pub 'run' file '-o' \"${COVERAGE}/${base}.lcov.info\" \"--packages=.packages\" \"--report-on=lib\"\n",
 	},
-	/* case 42 */ {in: "A = ['*.go']", out: "A = ['parser.go', 'parser_test.go', 'scanner.go', 'scanner_test.go']\n"},
-	/* case 43 */ {in: "A = [123, '*.go']", out: "A = [123, 'parser.go', 'parser_test.go', 'scanner.go', 'scanner_test.go']\n"},
+	/* case 42 */ {in: "A = ['*.go']", out: "A = ['*.go']\n"},
+	/* case 43 */ {in: "A = [123, '*.go']", out: "A = [123, '*.go']\n"},
 	/* case 44 */ {in: "if sizeof ~'file' == -1 { @print 123 \n }", out: "if sizeof ~'file' == -1 {\n@print 123\n}\n"},
 	/* case 45 */ {in: "@print 45 | @print 'text'", out: "@print 45 | @print 'text'\n"},
 	/* case 46 */ {in: "@print 45 | @print 'text' >> FILE", out: "@print 45 | @print 'text' >> FILE\n"},
@@ -80,6 +80,68 @@ var simpleCases = []*parserInputCase{
 	/* case 53 */ {in: "if @print exists {}", out: "if @print exists {\n}\n"},
 	/* case 54 */ {in: "if #rmdir exists {}", out: "if #rmdir exists {\n}\n"},
 	/* case 55 */ {in: "if #rmdir exists && on windows {}", out: "if #rmdir exists && on windows {\n}\n"},
+	/* case 56 */ {in: "sum(a, b) => a + b\n", out: "sum(a, b) => a + b"},
+	/* case 57 */ {in: "sum(a, b) {\n\treturn a + b\n}\n", out: "sum(a, b) {\nreturn a + b\n}"},
+	/* case 58 */ {in: "workin D + '/web' { @print 1\n }", out: "workin D + '/web' {\n@print 1\n}\n"},
+	/* case 59 */ {in: "@workin 'web'", out: "@workin 'web'\n"},
+	/* case 60 */ {in: "A = filter B(i, v) => v > 2", out: "A = filter B(i, v) => v > 2\n"},
+	/* case 61 */ {in: "A = reduce B(acc = 1 + 2, i, v) => acc * v", out: "A = reduce B(acc = 1 + 2, i, v) => acc * v\n"},
+	/* case 62 */ {in: "A = reduce B(acc, i, v) => acc + v", out: "A = reduce B(acc, i, v) => acc + v\n"},
+	/* case 63 */ {in: "A = flatten B\nC = zip A B D", out: "A = flatten B\nC = zip A B D\n"},
+	/* case 64 */ {in: "if any B(k, v) => v == 1 { @print 1\n }", out: "if any B(k, v) => v == 1 {\n@print 1\n}\n"},
+	/* case 65 */ {in: "A = zip B", out: ""},
+	/* case 66 */ {in: "A = sort B", out: ""},
+	/* case 67 */ {in: "switch A + 1 {\ncase 1, 'a':\n@print 1\nB = 2\ncase is integer | float:\ncase ~'*.go':\n@print 3\ncase in [1..5):\ndefault:\n@print 4\n}", out: "switch A + 1 {\ncase 1, 'a':\n@print 1\nB = 2\ncase is integer | float:\ncase ~'*.go':\n@print 3\ncase in [1..5):\ndefault:\n@print 4\n}\n"},
+	/* case 68 */ {in: "switch {\ncase on linux, A > 2:\n@print 1\n}", out: "switch {\ncase on linux, A > 2:\n@print 1\n}\n"},
+	/* case 69 */ {in: "switch {\ncase is integer:\n}", out: ""},
+	/* case 70 */ {in: "switch A {\ndefault:\ndefault:\n}", out: ""},
+	/* case 71 */ {in: "switch A {\n@print 1\n}", out: ""},
+	/* case 72 */ {in: "if A {\ncase 1:\n}", out: ""},
+	/* case 73 */ {in: "F = (a, b) => a + b", out: "F = (a, b) => a + b\n"},
+	/* case 74 */ {in: "F = () => 1", out: "F = () => 1\n"},
+	/* case 75 */ {in: "F = (a) {\nreturn a\n}", out: "F = (a) {\nreturn a\n}\n"},
+	/* case 76 */ {in: "if (a) {\n@print a\n}", out: "if (a) {\n@print a\n}\n"},
+	/* case 77 */ {in: "A = (a) + 1", out: "A = (a) + 1\n"},
+	/* case 78 */ {in: "deploy(env = 'staging', replicas: integer = 1, tags: array):\n@print env", out: "deploy(env = 'staging', replicas: integer = 1, tags: array):\n@print env\n"},
+	/* case 79 */ {in: "release:\n@deploy env: 'prod' 'x' replicas: A", out: "release:\n@deploy env: 'prod' 'x' replicas: A\n"},
+	/* case 80 */ {in: "deploy(env, env):\n@print env", out: ""},
+	/* case 81 */ {in: "deploy(env: object):\n@print env", out: ""},
+	/* case 82 */ {in: "initialize(env):\n@print env", out: ""},
+	/* case 83 */ {in: "record Package {\nname: string\nversion: string = '0.1.0'\nextra = 1\n}\nP = Package{name: 'cook'}\nA = P.version", out: "record Package { name: string, version: string = '0.1.0', extra = 1 }\nP = Package{name: 'cook'}\nA = P.version\n"},
+	/* case 84 */ {in: "record Package { name: string }\nA = B is string | Package", out: "record Package { name: string }\nA = B is string | Package\n"},
+	/* case 85 */ {in: "record Package { name: string }\nP = Package{nme: 'cook'}", out: ""},
+	/* case 86 */ {in: "record Package { name: string }\nP = Package{}", out: ""},
+	/* case 87 */ {in: "record Package { name: string }\nP = Package{name: 'cook'}\nA = P.nme", out: ""},
+	/* case 88 */ {in: "record Package { name: string }\nA = B is Pakage", out: ""},
+	/* case 89 */ {in: "record Package { name: string, name: string }", out: ""},
+	/* case 90 */ {in: "A = P.name", out: ""},
+	/* case 91 */ {in: "A: integer = 1\nB: map = {}", out: "A: integer = 1\nB: map = {}\n"},
+	/* case 92 */ {in: "all:\nA: float = 1.5", out: "all:\nA: float = 1.5\n"},
+	/* case 93 */ {in: "F = (a: integer, b): string => a + b", out: "F = (a: integer, b): string => a + b\n"},
+	/* case 94 */ {in: "F = (a, b: array): integer {\nreturn a\n}", out: "F = (a, b: array): integer {\nreturn a\n}\n"},
+	/* case 95 */ {in: "add(a: integer, b): integer {\nreturn a + b\n}", out: "add(a: integer, b): integer {\nreturn a + b\n}"},
+	/* case 96 */ {in: "all:\nA: object = 1", out: ""},
+	/* case 97 */ {in: "all:\nA: integer += 1", out: ""},
+	/* case 98 */ {in: "F = (a: object) => a", out: ""},
+	/* case 99 */ {in: "all:\ndefer @print 'done'", out: "all:\ndefer @print 'done'\n"},
+	/* case 100 */ {in: "all:\nfor i in [1..2] {\ndefer {\n@print i\n}\n}", out: "all:\nfor i in [1..2] {\ndefer {\n@print i\n}\n}\n"},
+	/* case 101 */ {in: "defer A += 1", out: "defer A += 1\n"},
+	/* case 102 */ {in: "all:\ndefer return 1", out: ""},
+	/* case 103 */ {in: "all:\nwhile:w A < 3 {\nA++\n}", out: "all:\nwhile:w A < 3 {\nA++\n}\n"},
+	/* case 104 */ {in: "all:\ndo {\nA++\n} while A < 3", out: "all:\ndo {\nA++\n} while A < 3\n"},
+	/* case 105 */ {in: "all:\ndo {\n}\nwhile true {\n}", out: ""},
+	/* case 106 */ {in: "all:\nretry 3 every 500ms timeout T {\n@sleep 1s\n}", out: "all:\nretry 3 every 500ms timeout T {\n@sleep 1s\n}\n"},
+	/* case 107 */ {in: "all:\nretry 3 every 1s every 2s {\n}", out: ""},
+	/* case 108 */ {in: "A = 2min", out: ""},
+	/* case 109 */ {in: "default:\n@print 1", out: "default:\n@print 1\n"},
+	/* case 110 */ {in: "all:\nswitch A {\ncase 1:\ncase = 2\ndefault:\nwhile (A < 2) {\nA++\n}\n}", out: "all:\nswitch A {\ncase 1:\ncase = 2\ndefault:\nwhile (A < 2) {\nA++\n}\n}\n"},
+	/* case 111 */ {in: "workin:\nworkin = 1\n@workin", out: "workin:\nworkin = 1\n@workin\n"},
+	/* case 112 */ {in: "import(a) => a", out: "import(a) => a"},
+	/* case 113 */ {in: "record = 1\nrecord:\n@print record", out: "record = 1\n\nrecord:\n@print record\n"},
+	/* case 114 */ {in: "defer = [1]\ndefer:\ndefer += [2]", out: "defer = [1]\n\ndefer:\ndefer += [2]\n"},
+	/* case 115 */ {in: "do:\ndo = 1\nwhile = do\nretry = 2", out: "do:\ndo = 1\nwhile = do\nretry = 2\n"},
+	/* case 116 */ {in: "A = [123, !'**/*.{go,md}']", out: "A = [123, !'**/*.{go,md}']\n"},
+	/* case 117 */ {in: "@print string(P) integer('3') >> string(F)", out: "@print string(P) integer('3') >> string(F)\n"},
 }
 
 func TestParseSimpleStatement(t *testing.T) {
@@ -3,6 +3,7 @@ package parser
 import (
 	"os"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -71,7 +72,8 @@ func (s *scanner) next() {
 	if s.rdOffset < len(s.src) {
 		if s.ch == '\n' {
 			s.lineOffset = s.offset
-			s.file.AddLine(s.offset)
+			// the next line begin after the line feed, which is two bytes if it was \r\n
+			s.file.AddLine(s.rdOffset)
 		}
 		s.offset = s.rdOffset
 		r, w := rune(s.src[s.rdOffset]), 1
@@ -159,6 +161,13 @@ revisit:
 		} else {
 			offset = s.offset
 			tok, lit = s.scanIdentifier()
+			// qualified identifier of an imported library such as go.VERSION, a dot right after
+			// a variable in a string is not part of the identifier, e.g. "$NAME.txt".
+			for s.ch == '.' && isLetter(rune(s.peek())) && (!s.mode.isMode(scanStringITP) || s.mode.isMode(scanAllowExpr)) {
+				s.next()
+				s.scanIdentifier()
+				lit = string(s.src[offset:s.offset])
+			}
 			if !s.mode.isMode(scanStringITP) {
 				if len(lit) > 1 {
 					tok = token.Lookup(lit, tok)
@@ -368,6 +377,18 @@ func (s *scanner) scanNumber() (token.Token, string) {
 			return tok, lit
 		}
 	}
+	// a duration such as 500ms or 1h30m is an unquoted string literal
+	if isDurationUnit(s.ch) && !s.mode.isMode(scanStringITP) {
+		for isDecimal(s.ch) || s.ch == '.' || isLetter(s.ch) {
+			s.next()
+		}
+		lit := string(s.src[offs:s.offset])
+		if _, err := time.ParseDuration(lit); err != nil {
+			s.errorHandler(s.file.Position(offs), "invalid duration %s", lit)
+			return token.ILLEGAL, lit
+		}
+		return token.STRING, lit
+	}
 	return tok, string(s.src[offs:s.offset])
 }
 
@@ -523,6 +544,11 @@ func (s *scanner) ternary(cond bool, t, f token.Token) token.Token {
 func lower(ch rune) rune     { return ('a' - 'A') | ch } // returns lower-case ch iff ch is ASCII letter
 func isDecimal(ch rune) bool { return '0' <= ch && ch <= '9' }
 
+// isDurationUnit report whether ch is the first letter of a unit of a duration, e.g. ns, us, ms, s, m or h
+func isDurationUnit(ch rune) bool {
+	return ch == 'n' || ch == 'u' || ch == 'm' || ch == 's' || ch == 'h'
+}
+
 func isLetter(ch rune) bool {
 	return 'a' <= lower(ch) && lower(ch) <= 'z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
@@ -299,6 +299,17 @@ var sources = []*testCase{
 			{tok: token.LF, lit: "\n"},
 		},
 	},
+	{
+		src: "retry 3 every 1.5s timeout 1m30s {", output: []*scanOutput{
+			{tok: token.IDENT, lit: "retry"},
+			{tok: token.INTEGER, lit: "3"},
+			{tok: token.IDENT, lit: "every"},
+			{tok: token.STRING, lit: "1.5s"},
+			{tok: token.IDENT, lit: "timeout"},
+			{tok: token.STRING, lit: "1m30s"},
+			{tok: token.LBRACE, lit: "{"},
+		},
+	},
 }
 
 var source string
@@ -342,3 +353,17 @@ func TestGeneralScanner(t *testing.T) {
 		i++
 	}
 }
+
+func TestScannerPosition(t *testing.T) {
+	src := "A\nBC = 1\r\n  D\n\n\tE"
+	file := token.NewFile("sample", len(src))
+	s, err := NewScannerSrc(file, []byte(src), func(p token.Position, msg string, args ...any) {})
+	require.NoError(t, err)
+	// line and column both start from 1, a line feed \r\n count as a single line
+	expected := map[string]string{"A": "sample:1:1", "BC": "sample:2:1", "D": "sample:3:3", "E": "sample:5:2"}
+	for offset, tok, lit := s.Scan(); tok != token.EOF; offset, tok, lit = s.Scan() {
+		if tok == token.IDENT {
+			assert.Equal(t, expected[lit], file.Position(offset).String(), lit)
+		}
+	}
+}
@@ -50,11 +50,12 @@ func (f *File) unpack(offset int) (filename string, line, column int) {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 	filename = f.name
+	// lines hold the offset of the beginning of every line except the first one
 	i := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset }) - 1
 	if i >= 0 {
-		line, column = i+1, offset-f.lines[i]+1
+		line, column = i+2, offset-f.lines[i]+1
 	} else {
-		line, column = 1, offset
+		line, column = 1, offset+1
 	}
 	return
 }
@@ -112,6 +112,21 @@ const (
 	type_rep_end
 	// end of keyword session
 	keyword_end
+
+	// contextual keyword is recognized by the parser only where a statement begin, it is not
+	// reserved thus it remain a valid name of a variable, a target or a function.
+	contextual_beg
+	WORKIN
+	SWITCH
+	CASE
+	DEFAULT
+	IMPORT
+	RECORD
+	DEFER
+	WHILE
+	DO
+	RETRY
+	contextual_end
 )
 
 var tokens = [...]string{
@@ -191,6 +206,16 @@ var tokens = [...]string{
 	DELETE:         "delete",
 	ON:             "on",
 	EXISTS:         "exists",
+	WORKIN:         "workin",
+	SWITCH:         "switch",
+	CASE:           "case",
+	DEFAULT:        "default",
+	IMPORT:         "import",
+	RECORD:         "record",
+	DEFER:          "defer",
+	WHILE:          "while",
+	DO:             "do",
+	RETRY:          "retry",
 	LINUX:          "linux",
 	MACOS:          "darwin",
 	WINDOWS:        "windows",
@@ -205,7 +230,7 @@ var tokens = [...]string{
 
 func (t Token) String() string { return tokens[t] }
 
-var keywords map[string]Token
+var keywords, contextuals map[string]Token
 
 func init() {
 	keywords = make(map[string]Token)
@@ -214,6 +239,10 @@ func init() {
 			keywords[tokens[i]] = i
 		}
 	}
+	contextuals = make(map[string]Token)
+	for i := contextual_beg + 1; i < contextual_end; i++ {
+		contextuals[tokens[i]] = i
+	}
 }
 
 func Lookup(ident string, tok Token) Token {
@@ -223,6 +252,14 @@ func Lookup(ident string, tok Token) Token {
 	return tok
 }
 
+// LookupContextual return the contextual keyword of ident or IDENT if ident is not a contextual keyword.
+func LookupContextual(ident string) Token {
+	if tok, ok := contextuals[ident]; ok {
+		return tok
+	}
+	return IDENT
+}
+
 func IsKeyword(name string) bool {
 	_, ok := keywords[name]
 	return ok
@@ -82,7 +82,7 @@ func (b *mdb) Usage(s string) {
 func (b *mdb) Description(s string) {
 	b.ensureStage(description)
 	b.buf.WriteByte('\n')
-	b.buf.WriteString(whitespace.Replace(strings.TrimSpace(s)))
+	b.buf.WriteString(collapse(s))
 	b.buf.WriteByte('\n')
 	b.stage++
 }
@@ -146,7 +146,7 @@ func (b *mdb) visitFlags(maxFlagSize int, short, long, defaultVal, description s
 	b.buf.WriteString(defaultVal)
 	b.buf.WriteString(" | ")
 	// description last
-	b.buf.WriteString(whitespace.Replace(strings.TrimSpace(description)))
+	b.buf.WriteString(collapse(description))
 	b.buf.WriteString(" |\n")
 }
 
@@ -287,6 +287,12 @@ func maxWidthFlag(flags []*Flag) int {
 
 var whitespace = strings.NewReplacer("\n", " ", "\t", " ")
 
+// collapse join the words of s with a single space, a description written in multiple lines would
+// otherwise keep the indentation of each line in the markdown.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
 func wrapTextByLine(space int, txt string) string {
 	buf := strings.Builder{}
 	indent := strings.Repeat(" ", space)
@@ -334,8 +340,10 @@ func wrapTextWith(initSpace, space, w int, txt string) string {
 			}
 		}
 	}
-	if w > len(txt) {
-		w = len(txt)
+	if w >= len(txt) {
+		// the whole text fit in a line
+		writer(whitespace.Replace(strings.TrimSpace(txt)))
+		return buf.String()
 	}
 	for {
 		if start != 0 {
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -34,6 +35,19 @@ type MainOptions struct {
 	Args     map[string]any
 	FuncMeta *FunctionMeta
 	IsHelp   bool
+	// check the types of the Cookfile without executing it
+	Check bool
+	// lint the Cookfile without executing it, the issues are written as JSON if JSON is true
+	Lint bool
+	JSON bool
+	// watch mode, re-execute the targets whenever a file matching WatchPaths is changed
+	Watch      bool
+	WatchPaths []string
+	Debounce   time.Duration
+	// permission of a new file and whether an overwritten file is backed up, see flag --file-mode
+	// and --keep-backup
+	FileMode string
+	Backup   bool
 }
 
 func ParseMainArgument(args []string) (*MainOptions, error) {
@@ -84,14 +98,61 @@ func ParseMainArgument(args []string) (*MainOptions, error) {
 		mo.IsHelp = true
 		if len(args) == 2 && strings.HasPrefix(args[1], "@") {
 			mo.FuncMeta = &FunctionMeta{Name: args[1][1:]}
+		} else if len(args) == 2 {
+			// help of a target declared in Cookfile
+			mo.Targets = []string{args[1]}
 		}
 		return mo, nil
 	}
 
-	// parse normal argument
+	// check and lint only accept -c to give the Cookfile, lint also accept --json
+	if len(args) >= 1 && (args[0] == "check" || args[0] == "lint") {
+		mo.Check, mo.Lint = args[0] == "check", args[0] == "lint"
+		for i := 1; i < len(args); i++ {
+			switch {
+			case args[i] == "-c" && i+1 < len(args):
+				i++
+				mo.Cookfile = args[i]
+			case args[i] == "--json" && mo.Lint:
+				mo.JSON = true
+			default:
+				return nil, fmt.Errorf("unexpected argument %s, %s only accept -c COOKFILE", args[i], args[0])
+			}
+		}
+		return mo, nil
+	}
+	return ParseTargetArgument(args)
+}
+
+// ParseTargetArgument parse args as the variables and the targets to execute, even if the first
+// argument is a command such as check.
+func ParseTargetArgument(args []string) (*MainOptions, error) {
+	mo := &MainOptions{Cookfile: defaultCookfile}
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		switch {
+		case arg == "--watch":
+			mo.Watch = true
+		case arg == "--keep-backup":
+			mo.Backup = true
+		case isFlag(arg, "--watch-path"), isFlag(arg, "--debounce"), isFlag(arg, "--file-mode"):
+			name, val, ok := strings.Cut(arg, "=")
+			if !ok {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("not enough argument, missing value for flag %s", name)
+				}
+				i++
+				val = args[i]
+			}
+			if name == "--watch-path" {
+				mo.WatchPaths = append(mo.WatchPaths, val)
+			} else if name == "--file-mode" {
+				mo.FileMode = val
+			} else if d, err := time.ParseDuration(val); err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid debounce duration %s", val)
+			} else {
+				mo.Debounce = d
+			}
 		case strings.HasPrefix(arg, "--"):
 			val := ""
 			ieql := strings.IndexByte(arg, '=')
@@ -161,9 +222,17 @@ func ParseMainArgument(args []string) (*MainOptions, error) {
 			mo.Targets = append(mo.Targets, arg)
 		}
 	}
+	if !mo.Watch && (len(mo.WatchPaths) > 0 || mo.Debounce > 0) {
+		return nil, fmt.Errorf("flag --watch-path and --debounce can only be used with --watch")
+	}
 	return mo, nil
 }
 
+// isFlag report whether arg is the flag name either alone or with its value, e.g. --name=value.
+func isFlag(arg, name string) bool {
+	return arg == name || strings.HasPrefix(arg, name+"=")
+}
+
 type Flag struct {
 	Short       string // single character, e.g. -e, -e
 	Long        string // more 2 character, e.g. --name or -name
@@ -299,13 +368,21 @@ func (flag *Flag) Set(fname string, field reflect.Value, val string, nextArg any
 type FunctionArg struct {
 	Val  any
 	Kind reflect.Kind
+	// Name is the parameter name of a named argument given to a target, e.g. @deploy env: "prod"
+	Name string
 }
 
 type Flags struct {
-	FuncName    string
-	Aliases     []string
-	Flags       []*Flag
-	Result      reflect.Type
+	FuncName string
+	Aliases  []string
+	Flags    []*Flag
+	Result   reflect.Type
+	// Return is the kind of the value returned by the function, reflect.Invalid if the function
+	// does not return a value or the kind depend on its flags or arguments.
+	Return reflect.Kind
+	// TextArgs tell that an argument may be a text which begin with "-" such as a unified diff, an
+	// argument which contain whitespace in its name is then never taken as a flag.
+	TextArgs    bool
 	Example     string
 	Usage       string
 	ShortDesc   string
@@ -373,6 +450,10 @@ func (flags *Flags) ensureStruct() error {
 }
 
 func (flags *Flags) checkFlag(arg string) (flag *Flag, fval string, err error) {
+	// a flag name never contain a space, such argument is a text, e.g. a unified diff
+	if name, _, _ := strings.Cut(arg, "="); flags.TextArgs && strings.ContainsAny(name, " \t\r\n") {
+		return
+	}
 	switch {
 	case strings.HasPrefix(arg, "--"):
 		if flag, fval, err = flags.findFlag(arg[2:], false); err != nil {
@@ -390,6 +471,30 @@ func (flags *Flags) checkFlag(arg string) (flag *Flag, fval string, err error) {
 	return
 }
 
+// CheckArgs report the first unknown flag given in args without applying them. An argument which
+// is not a string, e.g. a value only known when the function is called, is never a flag.
+func (flags *Flags) CheckArgs(args []any) error {
+	if flags.ensureStruct() != nil {
+		return nil
+	}
+	val := reflect.New(flags.Result).Elem()
+	for i := 0; i < len(args); i++ {
+		s, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		if flag, fval, err := flags.checkFlag(s); err != nil {
+			return err
+		} else if flag == nil || fval != "" {
+			continue
+		} else if field, _, err := findField(flags.Result, val, flag.Long); err == nil && field.Kind() != reflect.Bool {
+			// the next argument is the value of the flag
+			i++
+		}
+	}
+	return nil
+}
+
 // ParseFlagFunction return a pointer to a struct result if no error occurred.
 // Unlike Parse which accept slice of string, `ParseFlagFunction` accept a slice
 // of interface value (any value), in order to avoid parsing back and forth between
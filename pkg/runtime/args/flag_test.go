@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -154,6 +155,23 @@ func TestFlag(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, tc.opts, opts)
 	}
+	// a text such as a unified diff is an argument only if the function accept it
+	input := []string{"-b", "--- a\n+++ b\n", "- item"}
+	_, err := testFlags.Parse(input)
+	assert.Error(t, err)
+	textFlags := *testFlags
+	textFlags.TextArgs = true
+	opts, err := textFlags.Parse(input)
+	require.NoError(t, err)
+	assert.Equal(t, &OptionsTest{Flagb: true, Args: []any{"--- a\n+++ b\n", "- item"}}, opts)
+}
+
+func TestCheckArgs(t *testing.T) {
+	assert.NoError(t, testFlags.CheckArgs([]any{"-b", "-a", "-x", "--flagc=1", nil, "text"}))
+	assert.NoError(t, testFlags.CheckArgs([]any{"some text", int64(1), "-a"}))
+	assert.Error(t, testFlags.CheckArgs([]any{"-b", "-x"}))
+	assert.Error(t, testFlags.CheckArgs([]any{"--flagz", "1"}))
+	assert.Error(t, testFlags.CheckArgs([]any{"-abc"}))
 }
 
 type testFnFlag struct {
@@ -415,7 +433,76 @@ var testMainFlagCases = []*argsCase{
 			Targets: []string{"sample1", "sample2"},
 		},
 	},
+	{
+		input: []string{"--watch", "--debounce", "1s", "build", "--watch-path", "src/**/*.go", "--watch-path=*.md", "--name", "x"},
+		opts: &MainOptions{
+			Cookfile:   defaultCookfile,
+			Args:       map[string]any{"name": "x"},
+			Targets:    []string{"build"},
+			Watch:      true,
+			WatchPaths: []string{"src/**/*.go", "*.md"},
+			Debounce:   time.Second,
+		},
+	},
+	{
+		input: []string{"--file-mode", "644", "--keep-backup", "build", "--name=x"},
+		opts: &MainOptions{
+			Cookfile: defaultCookfile,
+			Args:     map[string]any{"name": "x"},
+			Targets:  []string{"build"},
+			FileMode: "644",
+			Backup:   true,
+		},
+	},
+	{
+		input: []string{"--file-mode=u=rw,go=r"},
+		opts:  &MainOptions{Cookfile: defaultCookfile, FileMode: "u=rw,go=r"},
+	},
+	{
+		input: []string{"help", "@print"},
+		opts:  &MainOptions{Cookfile: defaultCookfile, IsHelp: true, FuncMeta: &FunctionMeta{Name: "print"}},
+	},
+	{
+		input: []string{"help", "deploy"},
+		opts:  &MainOptions{Cookfile: defaultCookfile, IsHelp: true, Targets: []string{"deploy"}},
+	},
+	{
+		input: []string{"check"},
+		opts:  &MainOptions{Cookfile: defaultCookfile, Check: true},
+	},
+	{
+		input: []string{"check", "-c", "build.cook"},
+		opts:  &MainOptions{Cookfile: "build.cook", Check: true},
+	},
+	{
+		input: []string{"lint", "--json", "-c", "build.cook"},
+		opts:  &MainOptions{Cookfile: "build.cook", Lint: true, JSON: true},
+	},
 	// test error
+	{
+		input:   []string{"check", "build"},
+		failure: true,
+	},
+	{
+		input:   []string{"check", "--json"},
+		failure: true,
+	},
+	{
+		input:   []string{"--watch-path", "*.go", "build"},
+		failure: true,
+	},
+	{
+		input:   []string{"--watch", "--debounce=fast"},
+		failure: true,
+	},
+	{
+		input:   []string{"--watch", "--watch-path"},
+		failure: true,
+	},
+	{
+		input:   []string{"--file-mode"},
+		failure: true,
+	},
 	{
 		input:   []string{"--dict:a", "22", "--dict:i:s", "11:aa"},
 		failure: true,
@@ -440,6 +527,16 @@ var testMainFlagCases = []*argsCase{
 	},
 }
 
+func TestTargetFlag(t *testing.T) {
+	// a command name is a target when the Cookfile declare a target with the same name
+	opts, err := ParseTargetArgument([]string{"check", "-c", "build.cook", "--VAR", "1"})
+	require.NoError(t, err)
+	assert.Equal(t, &MainOptions{Cookfile: "build.cook", Targets: []string{"check"}, Args: map[string]any{"VAR": "1"}}, opts)
+	opts, err = ParseTargetArgument([]string{"lint", "--json", "1"})
+	require.NoError(t, err)
+	assert.Equal(t, &MainOptions{Cookfile: defaultCookfile, Targets: []string{"lint"}, Args: map[string]any{"json": "1"}}, opts)
+}
+
 func TestMainFlag(t *testing.T) {
 	for i, tc := range testMainFlagCases {
 		t.Logf("TestMainFlag case #%d", i+1)
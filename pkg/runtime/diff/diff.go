@@ -0,0 +1,188 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const noNewline = "\\ No newline at end of file\n"
+
+type op int8
+
+const (
+	opEqual op = iota
+	opDelete
+	opInsert
+)
+
+// edit is a single line operation of an edit script, a and b is the index of the line in the
+// old and the new text respectively or the position where the line is deleted or inserted.
+type edit struct {
+	op   op
+	a, b int
+}
+
+// splitLines split s into lines, each line keep its line feed except the last line if s does
+// not end with a line feed.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// myers compute the shortest edit script transforming a into b using Myers' O(ND) algorithm.
+func myers(a, b []string) []edit {
+	// common prefix and suffix does not need to be traced
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(ma), len(mb)
+	// v[k] is the furthest x reached on diagonal k, trace keep v before each step d limited to
+	// diagonal -d-1 to d+1 which is all the step need.
+	v := make([]int, 2*(n+m)+3)
+	offs := n + m + 1
+	var trace [][]int
+	found := n == 0 && m == 0
+	for d := 0; !found; d++ {
+		trace = append(trace, append([]int(nil), v[offs-d-1:offs+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offs+k-1] < v[offs+k+1]) {
+				x = v[offs+k+1]
+			} else {
+				x = v[offs+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && ma[x] == mb[y] {
+				x, y = x+1, y+1
+			}
+			v[offs+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	// backtrack from the end, the script is build in reverse order
+	edits := make([]edit, 0, len(a)+len(b))
+	for i := 0; i < suf; i++ {
+		edits = append(edits, edit{opEqual, len(a) - 1 - i, len(b) - 1 - i})
+	}
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		tv := trace[d]
+		at := func(k int) int { return tv[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			edits = append(edits, edit{opEqual, pre + x, pre + y})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{opInsert, pre + x, pre + prevY})
+			} else {
+				edits = append(edits, edit{opDelete, pre + prevX, pre + y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i := pre - 1; i >= 0; i-- {
+		edits = append(edits, edit{opEqual, i, i})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// hunkRange format the range of a hunk header, a range with one line omit the count and an
+// empty range refer to the line before it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+func writeLine(sb *strings.Builder, prefix byte, line string) {
+	sb.WriteByte(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteByte('\n')
+		sb.WriteString(noNewline)
+	}
+}
+
+// Unified return the unified diff between old and new text with the given number of context
+// line around each change, it return an empty string if both text are identical.
+func Unified(oldName, newName, old, new string, context int) string {
+	a, b := splitLines(old), splitLines(new)
+	edits := myers(a, b)
+	sb := &strings.Builder{}
+	for i := 0; i < len(edits); {
+		// find the next change
+		for i < len(edits) && edits[i].op == opEqual {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+		start := max(i-context, 0)
+		// extend the hunk while the next change is close enough to share the context
+		end, equals := i, 0
+		for j := i; j < len(edits) && equals <= 2*context; j++ {
+			if edits[j].op == opEqual {
+				equals++
+			} else {
+				end, equals = j, 0
+			}
+		}
+		end = min(end+context+1, len(edits))
+		if sb.Len() == 0 {
+			fmt.Fprintf(sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		as, bs, ac, bc := edits[start].a, edits[start].b, 0, 0
+		for _, e := range edits[start:end] {
+			if e.op != opInsert {
+				ac++
+			}
+			if e.op != opDelete {
+				bc++
+			}
+		}
+		fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(as, ac), hunkRange(bs, bc))
+		for _, e := range edits[start:end] {
+			switch e.op {
+			case opEqual:
+				writeLine(sb, ' ', a[e.a])
+			case opDelete:
+				writeLine(sb, '-', a[e.a])
+			case opInsert:
+				writeLine(sb, '+', b[e.b])
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nk\nl"
+	expected := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -7,5 +7,5 @@
+ g
+ h
+ i
+-j
+ k
++l
+\ No newline at end of file
+`
+	assert.Equal(t, expected, Unified("old", "new", old, new, 3))
+	assert.Equal(t, "", Unified("old", "new", old, old, 3))
+	// close changes share one hunk
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,11 +1,11 @@\n a\n-b\n+B\n c\n d\n e\n f\n g\n h\n i\n-j\n+J\n k\n",
+		Unified("a", "b", old, strings.Replace(strings.Replace(old, "b", "B", 1), "j", "J", 1), 4))
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n", Unified("a", "b", "", "x\ny\n", 3))
+	assert.Equal(t, "--- a\n+++ b\n@@ -1 +0,0 @@\n-x\n", Unified("a", "b", "x\n", "", 3))
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	text := func(n int) string {
+		sb := &strings.Builder{}
+		for i := 0; i < n; i++ {
+			sb.WriteString(string(rune('a' + r.Intn(5))))
+			if i+1 < n || r.Intn(2) == 0 {
+				sb.WriteByte('\n')
+			}
+		}
+		return sb.String()
+	}
+	for i := 0; i < 200; i++ {
+		a, b := text(r.Intn(30)), text(r.Intn(30))
+		patch := Unified("a", "b", a, b, r.Intn(4))
+		if a == b {
+			assert.Empty(t, patch)
+			continue
+		}
+		result, err := Apply(a, patch, 0)
+		require.NoError(t, err, "case %d\n%s", i, patch)
+		assert.Equal(t, b, result, "case %d\n%s", i, patch)
+	}
+}
+
+func TestApply(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	patch := Unified("a", "b", old, "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n", 2)
+	// offset, lines added before the hunk
+	result, err := Apply("0\n0\n"+old, patch, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "0\n0\n1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n", result)
+	// context changed require fuzz
+	changed := strings.Replace(old, "3", "three", 1)
+	_, err = Apply(changed, patch, 0)
+	assert.Error(t, err)
+	result, err = Apply(changed, patch, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\nthree\n4\nfive\n6\n7\n8\n9\n10\n", result)
+	// the changed line itself is different
+	_, err = Apply(strings.Replace(old, "5", "V", 1), patch, 2)
+	var herr *HunkError
+	require.ErrorAs(t, err, &herr)
+	assert.Equal(t, []int{1}, herr.Index)
+	assert.Equal(t, "1 out of 1 hunks failed: hunk #1 at line 3", err.Error())
+
+	// patch without file header and with an empty context line stripped of its space
+	result, err = Apply("a\n\nb\n", "@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "a\n\nc\n", result)
+
+	_, err = Apply(old, "not a patch", 0)
+	assert.Error(t, err)
+	_, err = Apply(old, "--- a\n+++ b\n@@ -1 +1 @@\n-1\n+one\n--- c\n+++ d\n@@ -1 +1 @@\n-1\n+one\n", 0)
+	assert.Error(t, err)
+	_, err = Apply(old, "@@ -1,2 +1,2 @@\n-1\n+one\n", 0)
+	assert.Error(t, err)
+}
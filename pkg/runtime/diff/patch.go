@@ -0,0 +1,212 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Hunk is a single hunk of a unified diff.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	// Lines hold the hunk body, each line start with ' ', '-' or '+'
+	Lines []string
+}
+
+// HunkError describe the hunks which cannot be applied.
+type HunkError struct {
+	Total  int
+	Failed []*Hunk
+	Index  []int
+}
+
+func (he *HunkError) Error() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%d out of %d hunks failed:", len(he.Failed), he.Total)
+	for i, h := range he.Failed {
+		fmt.Fprintf(sb, " hunk #%d at line %d", he.Index[i], h.OldStart)
+		if i+1 < len(he.Failed) {
+			sb.WriteByte(',')
+		}
+	}
+	return sb.String()
+}
+
+func atoi(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// Parse parse the hunks of a unified diff. The file header, --- and +++ lines, is optional
+// however the patch must modify only one file.
+func Parse(patch string) ([]*Hunk, error) {
+	var hunks []*Hunk
+	lines := splitLines(patch)
+	headers := 0
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "--- ") {
+			if headers++; headers > 1 {
+				return nil, fmt.Errorf("patch modify more than one file")
+			}
+			continue
+		}
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil {
+			// text before or between the hunk such as git header is ignored
+			continue
+		}
+		h := &Hunk{
+			OldStart: atoi(m[1], 0),
+			OldLines: atoi(m[2], 1),
+			NewStart: atoi(m[3], 0),
+			NewLines: atoi(m[4], 1),
+		}
+		oc, nc := 0, 0
+		for oc < h.OldLines || nc < h.NewLines {
+			if i++; i >= len(lines) {
+				return nil, fmt.Errorf("hunk %s is truncated", strings.TrimSpace(m[0]))
+			}
+			line = lines[i]
+			if line == "\n" {
+				// some editor strip the trailing space of an empty context line
+				line = " \n"
+			}
+			switch line[0] {
+			case ' ':
+				oc, nc = oc+1, nc+1
+			case '-':
+				oc++
+			case '+':
+				nc++
+			case '\\':
+				h.noNewline()
+				continue
+			default:
+				return nil, fmt.Errorf("invalid line %q in hunk %s", strings.TrimRight(line, "\n"), strings.TrimSpace(m[0]))
+			}
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			h.Lines = append(h.Lines, line)
+		}
+		if oc != h.OldLines || nc != h.NewLines {
+			return nil, fmt.Errorf("hunk %s does not match its line count", strings.TrimSpace(m[0]))
+		}
+		// no newline marker after the last line of the hunk
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\\") {
+			i++
+			h.noNewline()
+		}
+		hunks = append(hunks, h)
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("patch does not contain any hunk")
+	}
+	return hunks, nil
+}
+
+// noNewline remove the line feed of the last line since it's the end of the file.
+func (h *Hunk) noNewline() {
+	if n := len(h.Lines); n > 0 {
+		h.Lines[n-1] = strings.TrimSuffix(h.Lines[n-1], "\n")
+	}
+}
+
+// split return the old and new lines of the hunk as well as the number of leading and
+// trailing context line.
+func (h *Hunk) split() (old, new []string, lead, trail int) {
+	for _, l := range h.Lines {
+		if l[0] != '+' {
+			old = append(old, l[1:])
+		}
+		if l[0] != '-' {
+			new = append(new, l[1:])
+		}
+	}
+	for lead < len(h.Lines) && h.Lines[lead][0] == ' ' {
+		lead++
+	}
+	for trail < len(h.Lines)-lead && h.Lines[len(h.Lines)-1-trail][0] == ' ' {
+		trail++
+	}
+	return
+}
+
+func matchAt(src, old []string, at int) bool {
+	for i, l := range old {
+		if src[at+i] != l {
+			return false
+		}
+	}
+	return true
+}
+
+// find search for old in src starting at expected position then moving further away in both
+// direction, the position must not be less than from.
+func find(src, old []string, expected, from int) int {
+	last := len(src) - len(old)
+	expected = min(max(expected, from), max(last, from))
+	for delta := 0; expected-delta >= from || expected+delta <= last; delta++ {
+		if at := expected + delta; at <= last && matchAt(src, old, at) {
+			return at
+		}
+		if at := expected - delta; delta > 0 && at >= from && at <= last && matchAt(src, old, at) {
+			return at
+		}
+	}
+	return -1
+}
+
+// Apply apply the unified diff patch to src. A hunk is searched near its original position
+// thus it still apply if lines were added or removed before it. When the context does not
+// match, up to fuzz leading and trailing context lines are ignored. If any hunk cannot be
+// applied a *HunkError is returned and src is left as is.
+func Apply(src, patch string, fuzz int) (string, error) {
+	hunks, err := Parse(patch)
+	if err != nil {
+		return "", err
+	}
+	lines := splitLines(src)
+	var out []string
+	pos, offset := 0, 0
+	herr := &HunkError{Total: len(hunks)}
+	for i, h := range hunks {
+		old, new, lead, trail := h.split()
+		// position of the first old line in src as stated by the hunk header
+		base := h.OldStart - 1
+		if h.OldLines == 0 {
+			base = h.OldStart
+		}
+		at := -1
+		for f := 0; f <= fuzz && at == -1; f++ {
+			dl, dt := min(f, lead), min(f, trail)
+			if f > 0 && dl+dt == 0 {
+				break
+			}
+			if at = find(lines, old[dl:len(old)-dt], base+offset+dl, pos); at != -1 {
+				old, new = old[dl:len(old)-dt], new[dl:len(new)-dt]
+				offset = at - base - dl
+			}
+		}
+		if at == -1 {
+			herr.Failed = append(herr.Failed, h)
+			herr.Index = append(herr.Index, i+1)
+			continue
+		}
+		out = append(append(out, lines[pos:at]...), new...)
+		pos = at + len(old)
+	}
+	if len(herr.Failed) > 0 {
+		return "", herr
+	}
+	out = append(out, lines[pos:]...)
+	return strings.Join(out, ""), nil
+}
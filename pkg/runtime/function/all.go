@@ -1,7 +1,10 @@
 package function
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 
 	"github.com/cozees/cook/pkg/runtime/args"
@@ -9,6 +12,9 @@ import (
 
 type FuncHandler func(f Function, i any) (any, error)
 
+// RuntimeFuncHandler is a handler of function which require access to the running Cookfile.
+type RuntimeFuncHandler func(rt Runtime, f Function, i any) (any, error)
+
 type Function interface {
 	Apply([]*args.FunctionArg) (any, error)
 	Name() string
@@ -16,6 +22,81 @@ type Function interface {
 	Alias() []string
 }
 
+// Runtime give a function access to the Cookfile which is currently executing.
+type Runtime interface {
+	// OnExit register fn to be called when the current target is completed. If global is true or
+	// the function is not called within a target, fn is called after finalize target instead.
+	OnExit(global bool, fn func() error)
+	// Call execute fn with the given arguments, fn is either a name of a function declared in Cookfile
+	// or a function value such as one given as an argument of a built-in function. Call is safe to use
+	// from another goroutine, fn never run concurrently with the statements of the Cookfile.
+	Call(fn any, args ...any) (any, error)
+	// Detach return a runtime which keep the current working directory and context, it's used by a
+	// function which call fn later after it is returned, e.g. a route handler of @serve.
+	Detach() Runtime
+	// WorkingDir return the directory which a relative path is resolved against, an empty string
+	// mean the process working directory.
+	WorkingDir() string
+	// Context return a context which is cancelled when the execution should stop
+	Context() context.Context
+}
+
+// ResolvePath return path joined to the working directory of the runtime if path is relative.
+func ResolvePath(rt Runtime, path string) string {
+	if dir := rt.WorkingDir(); dir != "" && !filepath.IsAbs(path) {
+		return filepath.Join(dir, path)
+	}
+	return path
+}
+
+func resolvePaths(rt Runtime, paths []string) []string {
+	resolved := make([]string, len(paths))
+	for i, p := range paths {
+		resolved[i] = ResolvePath(rt, p)
+	}
+	return resolved
+}
+
+// RuntimeFunction is a function which can be executed with a Runtime. Calling Apply
+// on such function release any resource registered with OnExit when Apply return.
+type RuntimeFunction interface {
+	Function
+	ApplyWithRuntime(rt Runtime, args []*args.FunctionArg) (any, error)
+}
+
+// StatusFunction is a function which decide the exit status of the process base on its result
+// when the function is executed directly from the command line, e.g. cook @diff a b.
+type StatusFunction interface {
+	Function
+	ExitStatus(result any) int
+}
+
+// standaloneRuntime is used when a runtime function is executed without a Cookfile.
+type standaloneRuntime struct {
+	exits []func() error
+}
+
+func (sr *standaloneRuntime) OnExit(_ bool, fn func() error) { sr.exits = append(sr.exits, fn) }
+
+func (sr *standaloneRuntime) Call(fn any, _ ...any) (any, error) {
+	return nil, fmt.Errorf("function %v is not exist", fn)
+}
+
+func (sr *standaloneRuntime) Detach() Runtime { return sr }
+
+func (sr *standaloneRuntime) WorkingDir() string { return "" }
+
+func (sr *standaloneRuntime) Context() context.Context { return context.Background() }
+
+func (sr *standaloneRuntime) close() {
+	for i := len(sr.exits) - 1; i >= 0; i-- {
+		if err := sr.exits[i](); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+	}
+	sr.exits = nil
+}
+
 // store function reference by name
 var funcStore map[string]Function = make(map[string]Function)
 
@@ -35,6 +116,8 @@ type BaseFunction struct {
 	fnFlags   *args.Flags
 	nameAlias []string
 	handler   FuncHandler
+	rhandler  RuntimeFuncHandler
+	status    func(result any) int
 }
 
 func NewBaseFunction(flags *args.Flags, fh FuncHandler, alias ...string) *BaseFunction {
@@ -46,11 +129,33 @@ func NewBaseFunction(flags *args.Flags, fh FuncHandler, alias ...string) *BaseFu
 	}
 }
 
+func NewRuntimeFunction(flags *args.Flags, fh RuntimeFuncHandler, alias ...string) *BaseFunction {
+	flags.Aliases = alias
+	return &BaseFunction{
+		fnFlags:   flags,
+		nameAlias: alias,
+		rhandler:  fh,
+	}
+}
+
 func (bf *BaseFunction) Name() string       { return bf.fnFlags.FuncName }
 func (bf *BaseFunction) Alias() []string    { return bf.nameAlias }
 func (bf *BaseFunction) Flags() *args.Flags { return bf.fnFlags }
 
+// ExitStatus return 0 unless the function define its own exit status.
+func (bf *BaseFunction) ExitStatus(result any) int {
+	if bf.status == nil {
+		return 0
+	}
+	return bf.status(result)
+}
+
 func (bf *BaseFunction) Apply(args []*args.FunctionArg) (any, error) {
+	if bf.rhandler != nil {
+		rt := &standaloneRuntime{}
+		defer rt.close()
+		return bf.ApplyWithRuntime(rt, args)
+	}
 	i, err := bf.fnFlags.ParseFunctionArgs(args)
 	if bf.handler != nil && i != nil {
 		i, err = bf.handler(bf, i)
@@ -58,6 +163,17 @@ func (bf *BaseFunction) Apply(args []*args.FunctionArg) (any, error) {
 	return i, err
 }
 
+func (bf *BaseFunction) ApplyWithRuntime(rt Runtime, args []*args.FunctionArg) (any, error) {
+	if bf.rhandler == nil {
+		return bf.Apply(args)
+	}
+	i, err := bf.fnFlags.ParseFunctionArgs(args)
+	if i != nil {
+		i, err = bf.rhandler(rt, bf, i)
+	}
+	return i, err
+}
+
 func toString(i any) (string, error) {
 	switch v := i.(type) {
 	case string:
@@ -68,6 +184,8 @@ func toString(i any) (string, error) {
 		return strconv.FormatFloat(v, 'g', -1, 64), nil
 	case bool:
 		return strconv.FormatBool(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
 	default:
 		return "", fmt.Errorf("value %v cannot convert to string", i)
 	}
@@ -0,0 +1,202 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// environment variable to configure DefaultFileMode and Backup
+	envFileMode = "COOK_FILE_MODE"
+	envBackup   = "COOK_BACKUP"
+	// suffix of the file holding the previous content of an overwritten file
+	backupSuffix = ".bak"
+)
+
+var (
+	// DefaultFileMode is the permission of a new file created by a redirect or a built-in function,
+	// the process umask still apply. It can be set with environment variable COOK_FILE_MODE or flag
+	// --file-mode of cook, see SetFileMode.
+	DefaultFileMode os.FileMode = 0666
+	// Backup tell every atomic write to keep the previous content of an overwritten file in a file
+	// with suffix .bak. It can be enabled with environment variable COOK_BACKUP=true or flag
+	// --keep-backup of cook.
+	Backup bool
+)
+
+func init() {
+	if v := os.Getenv(envFileMode); v != "" {
+		if err := SetFileMode(v); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: invalid %s %s: %s\n", envFileMode, v, err)
+		}
+	}
+	if v := os.Getenv(envBackup); v != "" {
+		var err error
+		if Backup, err = strconv.ParseBool(v); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: invalid %s %s: %s\n", envBackup, v, err)
+		}
+	}
+}
+
+// SetFileMode set DefaultFileMode using linux permission syntax such as 644 or u=rw,go=r.
+func SetFileMode(raw string) error {
+	m, err := fm.Parse(0, raw)
+	if err != nil {
+		return err
+	}
+	DefaultFileMode = m
+	return nil
+}
+
+// AtomicFile is a file written into a temporary file in the same directory as the target file
+// which then replace the target only when Commit is called. A reader of the target never observe
+// a partially written file even if the write fail midway.
+type AtomicFile struct {
+	*os.File
+	// Backup keep the previous content of the target in a file with suffix .bak
+	Backup bool
+
+	name    string
+	done    bool
+	inPlace bool
+}
+
+// CreateAtomicFile create an atomic file for the target name. The target keep its permission and
+// owner if it already exist otherwise perm is used, or DefaultFileMode if perm is 0. If appendTo is
+// true, the target is not a regular file such as a device or a named pipe or its owner cannot be
+// kept, the target is opened and written in place instead, appending use O_APPEND and never create
+// a backup. A symbolic link is followed thus the file it point to is written rather than the link.
+func CreateAtomicFile(name string, appendTo bool, perm os.FileMode) (*AtomicFile, error) {
+	// write through a symbolic link rather than replacing the link itself
+	if real, err := filepath.EvalSymlinks(name); err == nil {
+		name = real
+	}
+	stat, err := os.Stat(name)
+	switch {
+	case err == nil && stat.IsDir():
+		return nil, fmt.Errorf("%s is a directory", name)
+	case err == nil:
+		perm = stat.Mode().Perm()
+	case !os.IsNotExist(err):
+		return nil, err
+	case perm == 0:
+		perm = DefaultFileMode
+	}
+	af := &AtomicFile{Backup: Backup, name: name}
+	inPlace := func() (*AtomicFile, error) {
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if appendTo {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		if af.File, err = os.OpenFile(name, flag, perm); err != nil {
+			return nil, err
+		}
+		af.inPlace = true
+		return af, nil
+	}
+	if appendTo || (stat != nil && !stat.Mode().IsRegular()) {
+		return inPlace()
+	}
+	dir, base := filepath.Split(name)
+	// create the file ourselves rather than os.CreateTemp so the umask apply to perm
+	for i := 0; ; i++ {
+		tmp := filepath.Join(dir, "."+base+".tmp"+strconv.FormatInt(time.Now().UnixNano()+int64(i), 36))
+		if af.File, err = os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm); err == nil {
+			break
+		} else if !os.IsExist(err) || i >= 100 {
+			return nil, err
+		}
+	}
+	// an existing target keep it permission regardless of umask and its owner
+	if stat != nil {
+		if err = af.Chmod(perm); err == nil {
+			err = keepOwner(af.File, stat)
+		}
+		if err != nil {
+			af.File.Close()
+			os.Remove(af.File.Name())
+			if errors.Is(err, fs.ErrPermission) {
+				// only a privileged user can give the file away, write the target in place instead
+				return inPlace()
+			}
+			return nil, err
+		}
+	}
+	return af, nil
+}
+
+// Commit close the temporary file and move it to replace the target file. A file written in
+// place is only closed.
+func (af *AtomicFile) Commit() error {
+	if af.done {
+		return nil
+	}
+	af.done = true
+	if af.inPlace {
+		return af.File.Close()
+	}
+	tmp := af.File.Name()
+	err := af.File.Close()
+	if err == nil && af.Backup {
+		err = backupFile(af.name)
+	}
+	if err == nil {
+		err = os.Rename(tmp, af.name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// Discard close and remove the temporary file leaving the target untouched. It does nothing
+// if the file has been committed thus it's safe to defer it right after CreateAtomicFile.
+// A file written in place is only closed, whatever has been written remain in the target.
+func (af *AtomicFile) Discard() {
+	if !af.done {
+		af.done = true
+		af.File.Close()
+		if !af.inPlace {
+			os.Remove(af.File.Name())
+		}
+	}
+}
+
+// backupFile keep the current content of name in name.bak, it does nothing if name does not exist.
+func backupFile(name string) error {
+	if _, err := os.Stat(name); os.IsNotExist(err) {
+		return nil
+	}
+	bak := name + backupSuffix
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// hard link is cheap, fallback to copy if the file system does not support it
+	if err := os.Link(name, bak); err == nil {
+		return nil
+	}
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	stat, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(bak, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,151 @@
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	read := func(f string) string {
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+		return string(b)
+	}
+	noTemp := func() {
+		matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp*"))
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+	}
+	// new file
+	af, err := CreateAtomicFile(file, false, 0)
+	require.NoError(t, err)
+	_, err = af.WriteString("first")
+	require.NoError(t, err)
+	assert.NoFileExists(t, file)
+	require.NoError(t, af.Commit())
+	assert.Equal(t, "first", read(file))
+	noTemp()
+	// discard leave the target untouched
+	af, err = CreateAtomicFile(file, false, 0)
+	require.NoError(t, err)
+	_, err = af.WriteString("discarded")
+	require.NoError(t, err)
+	af.Discard()
+	assert.Equal(t, "first", read(file))
+	noTemp()
+	// append is written in place
+	af, err = CreateAtomicFile(file, true, 0)
+	require.NoError(t, err)
+	_, err = af.WriteString(" second")
+	require.NoError(t, err)
+	assert.Equal(t, "first second", read(file))
+	noTemp()
+	require.NoError(t, af.Commit())
+	af.Discard()
+	assert.Equal(t, "first second", read(file))
+	// overwrite with backup
+	af, err = CreateAtomicFile(file, false, 0)
+	require.NoError(t, err)
+	af.Backup = true
+	_, err = af.WriteString("first second third")
+	require.NoError(t, err)
+	require.NoError(t, af.Commit())
+	assert.Equal(t, "first second third", read(file))
+	assert.Equal(t, "first second", read(file+backupSuffix))
+	noTemp()
+
+	_, err = CreateAtomicFile(dir, false, 0)
+	assert.Error(t, err)
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// the existing file keep its permission while new file use the given one
+	require.NoError(t, os.Chmod(file, 0604))
+	af, err = CreateAtomicFile(file, false, 0600)
+	require.NoError(t, err)
+	require.NoError(t, af.Commit())
+	stat, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0604), stat.Mode().Perm())
+	af, err = CreateAtomicFile(filepath.Join(dir, "b.txt"), false, 0600)
+	require.NoError(t, err)
+	require.NoError(t, af.Commit())
+	stat, err = os.Stat(filepath.Join(dir, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	// write through symbolic link
+	link := filepath.Join(dir, "link.txt")
+	require.NoError(t, os.Symlink("a.txt", link))
+	af, err = CreateAtomicFile(link, false, 0)
+	require.NoError(t, err)
+	_, err = af.WriteString("via link")
+	require.NoError(t, err)
+	require.NoError(t, af.Commit())
+	assert.Equal(t, "via link", read(file))
+	stat, err = os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, stat.Mode()&os.ModeSymlink)
+	// a non-regular file is written in place rather than replaced
+	af, err = CreateAtomicFile(os.DevNull, false, 0)
+	require.NoError(t, err)
+	_, err = af.WriteString("discarded")
+	require.NoError(t, err)
+	require.NoError(t, af.Commit())
+	stat, err = os.Stat(os.DevNull)
+	require.NoError(t, err)
+	assert.False(t, stat.Mode().IsRegular())
+	// copy with backup
+	_, err = GetFunction("cp").Apply(convertToFunctionArgs([]string{"-b", filepath.Join(dir, "b.txt"), file}))
+	require.NoError(t, err)
+	assert.Equal(t, "", read(file))
+	assert.Equal(t, "via link", read(file+backupSuffix))
+}
+
+func TestAtomicFileOwner(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Getuid() != 0 {
+		t.Skip("changing the owner of a file require root")
+	}
+	dir := t.TempDir()
+	file, link := filepath.Join(dir, "a.txt"), filepath.Join(dir, "link.txt")
+	require.NoError(t, os.WriteFile(file, []byte("first"), 0640))
+	require.NoError(t, os.Chown(file, 1234, 2345))
+	require.NoError(t, os.Symlink("a.txt", link))
+	owner := func(f string) (uint32, uint32) {
+		stat, err := os.Stat(f)
+		require.NoError(t, err)
+		st := stat.Sys().(*syscall.Stat_t)
+		return st.Uid, st.Gid
+	}
+	for _, target := range []string{file, link} {
+		af, err := CreateAtomicFile(target, false, 0)
+		require.NoError(t, err)
+		_, err = af.WriteString("via " + filepath.Base(target))
+		require.NoError(t, err)
+		require.NoError(t, af.Commit())
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "via "+filepath.Base(target), string(b))
+		uid, gid := owner(file)
+		assert.Equal(t, uint32(1234), uid)
+		assert.Equal(t, uint32(2345), gid)
+	}
+	stat, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, stat.Mode()&os.ModeSymlink)
+}
+
+func TestSetFileMode(t *testing.T) {
+	defer func(m os.FileMode) { DefaultFileMode = m }(DefaultFileMode)
+	require.NoError(t, SetFileMode("u=rw,go=r"))
+	assert.Equal(t, os.FileMode(0644), DefaultFileMode)
+	assert.Error(t, SetFileMode("abc"))
+	assert.Equal(t, os.FileMode(0644), DefaultFileMode)
+}
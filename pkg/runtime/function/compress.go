@@ -54,13 +54,14 @@ type compressOptions struct {
 
 	// internal state
 	verboseIO io.Writer
+	dir       string
 	ext       string
 	needExt   bool
 	mode      os.FileMode
 	handler   func(w io.WriteCloser, opts *compressOptions) (any, error)
 }
 
-func (co *compressOptions) validate() error {
+func (co *compressOptions) validate(rt Runtime) error {
 	if len(co.Args) == 0 {
 		return errors.New("compress no input")
 	} else if len(co.Args) > 1 {
@@ -68,24 +69,31 @@ func (co *compressOptions) validate() error {
 	}
 
 	co.needExt = false
+	input := co.Args[0]
+	if !filepath.IsAbs(input) {
+		co.dir = rt.WorkingDir()
+	}
+	co.Args[0] = ResolvePath(rt, input)
 	m, err := filepath.Glob(co.Args[0])
 	if co.Out == "" {
 		if err == nil && (len(m) >= 1 && m[0] != co.Args[0]) {
 			return errors.New("file name is require when input is a glob pattern")
 		}
 		co.Out = co.Args[0]
-		if co.Out == "." || co.Out == ".." || co.Out == "./" || co.Out == "../" {
+		if input == "." || input == ".." || input == "./" || input == "../" {
 			if absOut, err := filepath.Abs(co.Out); err != nil {
 				return err
 			} else {
-				co.Out = filepath.Base(absOut)
+				co.Out = ResolvePath(rt, filepath.Base(absOut))
 			}
 		}
 		co.needExt = true
+	} else {
+		co.Out = ResolvePath(rt, co.Out)
 	}
 	istat, serr := os.Stat(co.Args[0])
-	if err != nil && serr != nil {
-		return fmt.Errorf("input %s is not exist", co.Args[0])
+	if (err != nil || len(m) == 0) && serr != nil {
+		return fmt.Errorf("input %s is not exist", input)
 	}
 
 	co.mode = 0777
@@ -109,7 +117,7 @@ func (co *compressOptions) validate() error {
 
 	switch co.Kind {
 	case "gzip":
-		if !co.Tar && ((err == nil && (len(m) >= 1 && m[0] != co.Args[0])) || istat.IsDir()) {
+		if !co.Tar && ((err == nil && (len(m) >= 1 && m[0] != co.Args[0])) || (istat != nil && istat.IsDir())) {
 			return errors.New("gzip cannnot be use to compress a folder or multiple file/folder, it must use with tarball")
 		}
 		co.ext += ".gz"
@@ -155,6 +163,17 @@ var compressFlags = &args.Flags{
 	Description: compressorDesc,
 }
 
+// entryName return the name of path inside the archive, path is relative to the working directory
+// of the runtime if the input was given as a relative path.
+func (co *compressOptions) entryName(path string) string {
+	if co.dir != "" {
+		if rel, err := filepath.Rel(co.dir, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
 type listFileDirFunc func(source, path string, d fs.DirEntry, err error) error
 
 func rootDir(files []string) string {
@@ -221,7 +240,7 @@ func tarFileDir(w io.WriteCloser, opts *compressOptions) (v any, err error) {
 			return err
 		}
 		header := &tar.Header{
-			Name:    path,
+			Name:    opts.entryName(path),
 			Mode:    int64(stat.Mode()),
 			Size:    stat.Size(),
 			ModTime: stat.ModTime(),
@@ -261,7 +280,7 @@ func gzipFileDir(w io.WriteCloser, opts *compressOptions) (v any, err error) {
 		// a folder nor a glob pattern.
 		return nil, listFileDir(opts, func(source, path string, d fs.DirEntry, err error) (rerr error) {
 			if err == nil {
-				gw.Name = path
+				gw.Name = opts.entryName(path)
 				if fi, err := d.Info(); err != nil {
 					return err
 				} else {
@@ -298,7 +317,7 @@ func zipFileDir(w io.WriteCloser, opts *compressOptions) (v any, err error) {
 		} else {
 			header.Method = zip.Deflate
 			if source == path {
-				header.Name = path
+				header.Name = opts.entryName(path)
 			} else if header.Name, err = filepath.Rel(source, path); err != nil {
 				return err
 			}
@@ -326,9 +345,9 @@ func zipFileDir(w io.WriteCloser, opts *compressOptions) (v any, err error) {
 	})
 }
 
-var compressFn = NewBaseFunction(compressFlags, func(f Function, i any) (v any, err error) {
+var compressFn = NewRuntimeFunction(compressFlags, func(rt Runtime, f Function, i any) (v any, err error) {
 	opts := i.(*compressOptions)
-	if err = opts.validate(); err != nil {
+	if err = opts.validate(rt); err != nil {
 		return nil, err
 	}
 	// open file
@@ -454,7 +473,7 @@ func extractGzipFile(r io.ReadSeeker, opts *extractOptions) (err error) {
 	}
 	defer gr.Close()
 	buf := make([]byte, 512)
-	if n, err := gr.Read(buf); err != nil {
+	if n, err := gr.Read(buf); err != nil && err != io.EOF {
 		return err
 	} else {
 		// the content is tarbal file extract tar
@@ -557,13 +576,15 @@ func extractHandler(buf []byte, file string, opts *extractOptions) error {
 	}
 }
 
-var extractFn = NewBaseFunction(extractFlags, func(f Function, i any) (any, error) {
+var extractFn = NewRuntimeFunction(extractFlags, func(rt Runtime, f Function, i any) (any, error) {
 	opts := i.(*extractOptions)
 	if opts.Verbose {
 		opts.verboseIO = os.Stdout
 	}
 	var err error
-	if opts.Out == "" {
+	if opts.Out != "" {
+		opts.Out = ResolvePath(rt, opts.Out)
+	} else if opts.Out = rt.WorkingDir(); opts.Out == "" {
 		if opts.Out, err = os.Getwd(); err != nil {
 			return nil, err
 		}
@@ -586,7 +607,7 @@ var extractFn = NewBaseFunction(extractFlags, func(f Function, i any) (any, erro
 		return nil, errors.New("no file to extract")
 	}
 	buf := make([]byte, 512)
-	for _, file := range opts.Args {
+	for _, file := range resolvePaths(rt, opts.Args) {
 		if err = extractHandler(buf, file, opts); err != nil {
 			return nil, err
 		}
@@ -153,3 +153,33 @@ func TestCompressExtractDir(t *testing.T) {
 		os.RemoveAll(outcompress)
 	}
 }
+
+func TestCompressExtractWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web", "css"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "a.txt"), []byte("content a"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "css", "b.css"), []byte("content b"), 0600))
+	rt := &testRuntime{dir: filepath.Join(dir, "web")}
+	apply := func(name string, params ...string) error {
+		_, err := GetFunction(name).(RuntimeFunction).ApplyWithRuntime(rt, convertToFunctionArgs(params))
+		return err
+	}
+	require.NoError(t, apply("compress", "-k", "gzip", "-o", "a.gz", "a.txt"))
+	assert.FileExists(t, filepath.Join(dir, "web", "a.gz"))
+	require.NoError(t, apply("compress", "-t", "css"))
+	assert.FileExists(t, filepath.Join(dir, "web", "css.tar"))
+	require.NoError(t, apply("compress", "-k", "zip", "."))
+	assert.FileExists(t, filepath.Join(dir, "web", "web.zip"))
+	assert.Error(t, apply("compress", "-k", "gzip", "missing.txt"))
+
+	require.NoError(t, apply("extract", "-o", "out", "a.gz", "css.tar"))
+	verifyFileContent(t, filepath.Join(dir, "web", "a.txt"), filepath.Join(dir, "web", "out", "a.txt"))
+	verifyFileContent(t, filepath.Join(dir, "web", "css", "b.css"), filepath.Join(dir, "web", "out", "css", "b.css"))
+	require.NoError(t, os.Remove(filepath.Join(dir, "web", "a.txt")))
+	require.NoError(t, apply("extract", "a.gz"))
+	assert.FileExists(t, filepath.Join(dir, "web", "a.txt"))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(cwd, "a.gz"))
+	assert.NoDirExists(t, filepath.Join(cwd, "out"))
+}
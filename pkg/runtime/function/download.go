@@ -0,0 +1,283 @@
+package function
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"golang.org/x/term"
+)
+
+const (
+	outputDesc = `a path to a file to store the downloaded data. If it is not given, the last segment
+				  of the URL path is used as file name in the current working directory.`
+	resumeDesc = `continue a previous interrupted download instead of starting over. The partial data is kept
+				  in a file with the same name as output suffix by .part until the download is completed, it is
+				  kept even if the download fail. The download start over if the server cannot continue the
+				  partial file, e.g. it is larger than the remote file.`
+	sha256Desc = `a hex encoded sha256 checksum which the downloaded file must match. If the output file is
+				  already exist and it's match the checksum then the download is skipped.`
+	downloadDesc = `Download the data from [URL] and store it into a file. The data is written to a temporary file
+					first and only move to the output file when the download is completed, thus the output file is
+					never left half written. A progress bar is shown if the standard output is a terminal.
+					The function return the path to the downloaded file.`
+)
+
+var downloadFlags = &args.Flags{
+	Flags: append([]*args.Flag{
+		{Short: "h", Long: "header", Description: headerDesc},
+		{Short: "o", Long: "output", Description: outputDesc},
+		{Long: "resume", Description: resumeDesc},
+		{Long: "sha256", Description: sha256Desc},
+	}, httpClientFlags...),
+	Result:      httpOptsType,
+	FuncName:    "download",
+	Return:      reflect.String,
+	ShortDesc:   "download a file over http",
+	Usage:       "@download [-o PATH] [--resume] [--sha256 CHECKSUM] URL",
+	Example:     "@download -o cook.tar.gz --sha256 9f86d08...b0f00a08 https://www.example.com/cook.tar.gz",
+	Description: downloadDesc,
+}
+
+// for override testing purpose only
+var isTerminal = func() bool { return term.IsTerminal(int(os.Stdout.Fd())) }
+
+// fileSHA256 return hex encoded sha256 checksum of the given file.
+func fileSHA256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// progress write a progress bar to the writer w as the data is being written to it.
+type progress struct {
+	w       io.Writer
+	name    string
+	current int64
+	total   int64
+	last    time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.current += int64(len(b))
+	if now := time.Now(); now.Sub(p.last) >= 100*time.Millisecond {
+		p.last = now
+		p.print()
+	}
+	return len(b), nil
+}
+
+func (p *progress) print() {
+	if p.total <= 0 {
+		fmt.Fprintf(p.w, "\r%s %s", p.name, byteSize(p.current))
+		return
+	}
+	const width = 30
+	filled := int(p.current * width / p.total)
+	fmt.Fprintf(p.w, "\r%s [%s%s] %3d%% %s/%s", p.name, strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		p.current*100/p.total, byteSize(p.current), byteSize(p.total))
+}
+
+func (p *progress) done() {
+	p.print()
+	fmt.Fprintln(p.w)
+}
+
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func download(rt Runtime, bf Function, i any) (any, error) {
+	opts := i.(*httpOption)
+	rawURL, err := opts.validate(rt, bf.Name())
+	if err != nil {
+		return nil, err
+	}
+	output := opts.Output
+	if output == "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if output = path.Base(u.Path); output == "/" || output == "." {
+			return nil, fmt.Errorf("function %s cannot determine file name from %s, use flag output instead", bf.Name(), rawURL)
+		}
+	}
+	// returned path stay relative to the working directory just like the given output
+	dst := ResolvePath(rt, output)
+	checksum := strings.ToLower(opts.SHA256)
+	if checksum != "" {
+		if sum, err := fileSHA256(dst); err == nil && sum == checksum {
+			return output, nil
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	part := dst + ".part"
+	var offset int64
+	if stat, err := os.Stat(part); opts.Resume && err == nil {
+		offset = stat.Size()
+	}
+
+	resp, offset, err := opts.resume(rt.Context(), rawURL, offset)
+	if err != nil {
+		return nil, err
+	}
+	// a nil response mean the partial file is already contain the whole content
+	if resp != nil {
+		defer resp.Body.Close()
+		if err = writePart(part, resp, offset, filepath.Base(output)); err != nil {
+			if !opts.Resume {
+				os.Remove(part)
+			}
+			return nil, err
+		}
+	}
+
+	if checksum != "" {
+		sum, err := fileSHA256(part)
+		if err != nil {
+			return nil, err
+		}
+		if sum != checksum {
+			os.Remove(part)
+			return nil, fmt.Errorf("checksum mismatch for %s, expected %s but got %s", rawURL, checksum, sum)
+		}
+	}
+	if err = os.Rename(part, dst); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// resume send the download request continuing the partial file of the given size. The returned offset
+// is where the response body continue the partial file, 0 if the partial file must be written from the
+// beginning, and the response is nil if the partial file is already complete. The partial file is started
+// over if the range returned by the server does not continue it.
+func (ho *httpOption) resume(ctx context.Context, rawURL string, offset int64) (*http.Response, int64, error) {
+	header := ho.Header
+	if offset > 0 {
+		if ho.Header = header.Clone(); ho.Header == nil {
+			ho.Header = make(http.Header)
+		}
+		ho.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := ho.send(ctx, http.MethodGet, rawURL, nil)
+	ho.Header = header
+	if err != nil {
+		return nil, 0, err
+	}
+	start, total := contentRange(resp)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start == offset {
+			return resp, offset, nil
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if total == offset {
+			resp.Body.Close()
+			return nil, offset, nil
+		}
+	case resp.StatusCode >= 200 && resp.StatusCode < 300 && resp.StatusCode != http.StatusPartialContent:
+		// server doesn't support range request or it's a fresh download
+		return resp, 0, nil
+	default:
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("server report %d on download %s", resp.StatusCode, rawURL)
+	}
+	resp.Body.Close()
+	return ho.resume(ctx, rawURL, 0)
+}
+
+// contentRange return the first byte position and the complete length given by the header Content-Range
+// of the response such as "bytes 100-199/200" or "bytes */200", -1 is returned for any unknown value.
+func contentRange(resp *http.Response) (start, total int64) {
+	start, total = -1, -1
+	spec, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return
+	}
+	rng, size, _ := strings.Cut(spec, "/")
+	if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+		total = n
+	}
+	if first, _, ok := strings.Cut(rng, "-"); ok {
+		if n, err := strconv.ParseInt(first, 10, 64); err == nil {
+			start = n
+		}
+	}
+	return
+}
+
+// writePart write the response body into the partial file at offset, the file is truncated at offset
+// first. An error is returned if the body is shorter than its Content-Length.
+func writePart(part string, resp *http.Response, offset int64, name string) error {
+	if err := os.MkdirAll(filepath.Dir(part), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if err = f.Truncate(offset); err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	var w io.Writer = f
+	var p *progress
+	if isTerminal() {
+		p = &progress{w: os.Stdout, name: name, current: offset}
+		if resp.ContentLength > 0 {
+			p.total = offset + resp.ContentLength
+		}
+		w = io.MultiWriter(f, p)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err == nil && resp.ContentLength >= 0 && n != resp.ContentLength {
+		err = fmt.Errorf("download incomplete, expected %d bytes but got %d", resp.ContentLength, n)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if p != nil {
+		p.done()
+	}
+	return err
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(downloadFlags, download))
+}
@@ -0,0 +1,139 @@
+package function
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	content := []byte(strings.Repeat("cook download content ", 100))
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	requests, lastRange := 0, ""
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		lastRange = r.Header.Get("Range")
+		http.ServeContent(rw, r, "file.txt", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "file.txt")
+	download := GetFunction("download")
+
+	// fresh download
+	result, err := download.Apply(convertToFunctionArgs([]string{"-o", output, "--sha256", checksum, server.URL + "/file.txt"}))
+	require.NoError(t, err)
+	assert.Equal(t, output, result)
+	b, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, content, b)
+	assert.NoFileExists(t, output+".part")
+	assert.Equal(t, 1, requests)
+
+	// skip download when checksum match
+	_, err = download.Apply(convertToFunctionArgs([]string{"-o", output, "--sha256", checksum, server.URL + "/file.txt"}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// resume from partial file
+	require.NoError(t, os.Remove(output))
+	require.NoError(t, os.WriteFile(output+".part", content[:100], 0600))
+	_, err = download.Apply(convertToFunctionArgs([]string{"-o", output, "--resume", "--sha256", checksum, server.URL + "/file.txt"}))
+	require.NoError(t, err)
+	assert.Equal(t, "bytes=100-", lastRange)
+	b, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, content, b)
+
+	// checksum mismatch must not leave any file behind
+	require.NoError(t, os.Remove(output))
+	_, err = download.Apply(convertToFunctionArgs([]string{"-o", output, "--sha256", strings.Repeat("0", 64), server.URL + "/file.txt"}))
+	assert.Error(t, err)
+	assert.NoFileExists(t, output)
+	assert.NoFileExists(t, output+".part")
+
+	// relative output is resolved against the working directory
+	rt := &testRuntime{dir: dir}
+	result, err = download.(RuntimeFunction).ApplyWithRuntime(rt, convertToFunctionArgs([]string{"-o", "sub/copy.txt", server.URL + "/file.txt"}))
+	require.NoError(t, err)
+	assert.Equal(t, "sub/copy.txt", result)
+	assert.FileExists(t, filepath.Join(dir, "sub", "copy.txt"))
+
+	// output derived from url
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+	result, err = download.Apply(convertToFunctionArgs([]string{server.URL + "/file.txt"}))
+	require.NoError(t, err)
+	assert.Equal(t, "file.txt", result)
+	assert.FileExists(t, output)
+
+	_, err = download.Apply(convertToFunctionArgs([]string{server.URL}))
+	assert.Error(t, err)
+}
+
+func TestDownloadResumeMismatch(t *testing.T) {
+	content := []byte(strings.Repeat("cook resume content ", 100))
+	badRange, truncate, requests := false, false, 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case truncate:
+			// body shorter than its Content-Length
+			rw.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			rw.Write(content[:100])
+		case badRange && r.Header.Get("Range") != "":
+			rw.Header().Set("Content-Range", fmt.Sprintf("bytes 0-99/%d", len(content)))
+			rw.WriteHeader(http.StatusPartialContent)
+			rw.Write(content[:100])
+		default:
+			http.ServeContent(rw, r, "file.txt", time.Time{}, bytes.NewReader(content))
+		}
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "file.txt")
+	download := GetFunction("download")
+	apply := func(sargs ...string) error {
+		_, err := download.Apply(convertToFunctionArgs(append(sargs, "-o", output, server.URL+"/file.txt")))
+		return err
+	}
+
+	// partial file larger than the remote file is started over
+	require.NoError(t, os.WriteFile(output+".part", bytes.Repeat([]byte("x"), len(content)+10), 0600))
+	require.NoError(t, apply("--resume"))
+	b, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, content, b)
+	assert.Equal(t, 2, requests)
+
+	// range which does not continue the partial file is started over
+	badRange, requests = true, 0
+	require.NoError(t, os.WriteFile(output+".part", content[:300], 0600))
+	require.NoError(t, apply("--resume"))
+	b, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, content, b)
+	assert.Equal(t, 2, requests)
+
+	// incomplete body is an error, the partial file is kept only when resuming
+	badRange, truncate = false, true
+	assert.Error(t, apply())
+	assert.NoFileExists(t, output+".part")
+	assert.Error(t, apply("--resume"))
+	assert.FileExists(t, output+".part")
+}
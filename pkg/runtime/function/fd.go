@@ -10,6 +10,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cozees/cook/pkg/runtime/args"
 	"github.com/cozees/cook/pkg/runtime/parser"
@@ -306,7 +307,8 @@ func readUserGroup(o *fdOptions) (u, g string, err error) {
 }
 
 func AllFileDirectoryFlags() []*args.Flags {
-	return []*args.Flags{rmFlags, mkdirFlags, rmdirFlags, chmodFlags, chownFlags, cpFlags, mvFlags, chdirFlags}
+	return []*args.Flags{rmFlags, mkdirFlags, rmdirFlags, chmodFlags, chownFlags, cpFlags, mvFlags, chdirFlags, findFlags,
+		lnFlags, touchFlags, statFlags, readlinkFlags, syncFlags, tempdirFlags, tempfileFlags}
 }
 
 type fdOptions struct {
@@ -314,10 +316,14 @@ type fdOptions struct {
 	Mode      string `flag:"mode,0740"`
 	Numguid   bool   `flag:"guinum"`
 	Silence   bool   `flag:"silence"`
+	Symbolic  bool   `flag:"symbolic"`
+	Force     bool   `flag:"force"`
+	Date      string `flag:"date"`
+	Backup    bool   `flag:"backup"`
 	Args      []string
 }
 
-func removeAll(folderOnly bool, f Function, i any) (any, error) {
+func removeAll(folderOnly bool, rt Runtime, f Function, i any) (any, error) {
 	opts := i.(*fdOptions)
 	paths, err := readPath(f, opts, -1, 0)
 	if err != nil {
@@ -325,7 +331,7 @@ func removeAll(folderOnly bool, f Function, i any) (any, error) {
 	}
 	for _, path := range paths {
 		if folderOnly || !opts.Recursive {
-			stat, err := os.Stat(path)
+			stat, err := os.Stat(ResolvePath(rt, path))
 			if err != nil {
 				return nil, err
 			}
@@ -347,17 +353,19 @@ func removeAll(folderOnly bool, f Function, i any) (any, error) {
 					path = a
 				}
 			}
-			if err = os.RemoveAll(path); err != nil {
+			if err = os.RemoveAll(ResolvePath(rt, path)); err != nil {
 				return nil, err
 			}
-		} else if err = os.Remove(path); err != nil {
+		} else if err = os.Remove(ResolvePath(rt, path)); err != nil {
 			return nil, err
 		}
 	}
 	return nil, nil
 }
 
-func copyFile(a, b string) error {
+// copyFile copy file a to b atomically, b keep its permission if it already exist otherwise it
+// has the same permission as a.
+func copyFile(a, b string, backup bool) error {
 	f1, err := os.Open(a)
 	if err != nil {
 		return err
@@ -369,20 +377,21 @@ func copyFile(a, b string) error {
 	} else if f1Stat.IsDir() {
 		return fmt.Errorf("%s is not a file, to copy directory use -r", a)
 	}
-	f2, err := os.OpenFile(b, os.O_CREATE|os.O_WRONLY, f1Stat.Mode())
+	f2, err := CreateAtomicFile(b, false, f1Stat.Mode().Perm())
 	if err != nil {
 		return err
 	}
-	defer f2.Close()
+	defer f2.Discard()
+	f2.Backup = f2.Backup || backup
 	if cp, err := io.Copy(f2, f1); err != nil {
 		return err
 	} else if cp != f1Stat.Size() {
 		return fmt.Errorf("copy failed, only %d out of %d bytes was copied", cp, f1Stat.Size())
 	}
-	return nil
+	return f2.Commit()
 }
 
-func copyOrMoveDir(move bool, a, b string) (bool, error) {
+func copyOrMoveDir(move, backup bool, a, b string) (bool, error) {
 	stata, err := os.Stat(a)
 	if os.IsNotExist(err) || err != nil {
 		return false, err
@@ -436,7 +445,7 @@ func copyOrMoveDir(move bool, a, b string) (bool, error) {
 					}
 					err = os.MkdirAll(filepath.Join(b, rel), di.Mode())
 				} else {
-					err = copyFile(path, filepath.Join(b, rel))
+					err = copyFile(path, filepath.Join(b, rel), backup)
 				}
 			}
 			return err
@@ -464,11 +473,12 @@ func moveOrCopyGlob(sources []string, dir string, action func(moveTo bool, a, b
 	return nil
 }
 
-func moveOrCopy(f Function, i any, action func(moveTo bool, a, b string) error) (any, error) {
+func moveOrCopy(rt Runtime, f Function, i any, action func(moveTo bool, a, b string) error) (any, error) {
 	paths, err := readPath(f, i.(*fdOptions), -1, 0)
 	if err != nil {
 		return nil, err
 	}
+	paths = resolvePaths(rt, paths)
 	ic := len(paths)
 	if ic < 2 {
 		return nil, errMissingTarget
@@ -568,12 +578,13 @@ var rmFlags = &args.Flags{
 }
 
 var chdirFlags = &args.Flags{
-	Result:      fdOptionsType,
-	FuncName:    "workin",
-	ShortDesc:   "change working directory",
-	Usage:       "@workin PATH",
-	Example:     "@workin dir1/dir2/dir3",
-	Description: `Change current working directory to the given directory.` + pathDesc,
+	Result:    fdOptionsType,
+	FuncName:  "workin",
+	ShortDesc: "change working directory",
+	Usage:     "@workin PATH",
+	Example:   "@workin dir1/dir2/dir3",
+	Description: `Change current working directory of the process to the given directory. The change last until the end of
+				  the run, use a workin block instead to change the working directory of a block only. ` + pathDesc,
 }
 
 var chownFlags = &args.Flags{
@@ -615,13 +626,106 @@ var mvFlags = &args.Flags{
 var cpFlags = &args.Flags{
 	Flags: []*args.Flag{
 		{Short: "r", Long: "recursive", Description: `Copies the directory and the entire sub-tree to the target. To copy the content only add trailing /.`},
+		{Short: "b", Long: "backup", Description: `Keep the previous content of an overwritten file in a file with suffix .bak.`},
+	},
+	Result:    fdOptionsType,
+	FuncName:  "cp",
+	ShortDesc: "Copy files or directories",
+	Usage:     "@cp [-r] [-b] PATH [PATH ...] NEW_PATH",
+	Example:   "@cp dir1 file.txt dir2/dir3",
+	Description: `Copy one or more of files or directories. If the target is not exist the @cp will create like call @mkdir -p.
+				  Each file is written to a temporary file next to the target then renamed so the target is never left
+				  partially written. ` + pathDesc,
+}
+
+var lnFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "s", Long: "symbolic", Description: `Create a symbolic link instead of a hard link.`},
+		{Short: "f", Long: "force", Description: `Remove the existing file at the link path before creating the link.`},
 	},
+	Result:    fdOptionsType,
+	FuncName:  "ln",
+	ShortDesc: "Create a link to a file or directory",
+	Usage:     "@ln [-s] [-f] TARGET LINK",
+	Example:   "@ln -s -f ../lib/libsample.so.1 build/libsample.so",
+	Description: `Create a hard link or a symbolic link named LINK which point to TARGET. If LINK is an existing directory then
+				  the link is created inside the directory with the same name as TARGET. Symbolic link TARGET is stored as is
+				  thus a relative TARGET is resolved against the directory of LINK. ` + pathDesc,
+}
+
+var touchFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "d", Long: "date", Description: `Use the given time instead of the current time. The time can be in RFC3339 format
+												 such as 2006-01-02T15:04:05Z07:00, 2006-01-02 15:04:05 or 2006-01-02 in local time.`},
+	},
+	Result:      fdOptionsType,
+	FuncName:    "touch",
+	ShortDesc:   "Change file access and modification time",
+	Usage:       "@touch [-d TIME] PATH [PATH ...]",
+	Example:     "@touch -d '2022-01-02 10:00:00' file1.txt file2.txt",
+	Description: `Update the access and modification time of each file, a file which does not exist is created empty. ` + pathDesc,
+}
+
+var statFlags = &args.Flags{
+	Result:    fdOptionsType,
+	FuncName:  "stat",
+	Return:    reflect.Map,
+	ShortDesc: "Return file or directory metadata",
+	Usage:     "@stat PATH",
+	Example:   "@stat file.txt",
+	Description: `Return a map with key size, mode, mtime, isDir, isLink, owner and group of the given file or directory.
+				  The mode is a linux permission string such as -rwxr-x---, mtime is in RFC3339 format and isLink tell whether
+				  PATH itself is a symbolic link while the other information is of the file it point to. ` + pathDesc,
+}
+
+var readlinkFlags = &args.Flags{
 	Result:      fdOptionsType,
-	FuncName:    "cp",
-	ShortDesc:   "Copy files or directories",
-	Usage:       "@cp [-r] mode PATH [PATH ...] NEW_PATH",
-	Example:     "@cp dir1 file.txt dir2/dir3",
-	Description: `Copy one or more of files or directories. If the target is not exist the @cp will create like call @mkdir -p. ` + pathDesc,
+	FuncName:    "readlink",
+	Return:      reflect.String,
+	ShortDesc:   "Return the target of a symbolic link",
+	Usage:       "@readlink PATH",
+	Example:     "@readlink build/libsample.so",
+	Description: `Return the target of the symbolic link as it was created without resolving it. ` + pathDesc,
+}
+
+// touchTimeLayouts is the layout accepted by @touch -d
+var touchTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+func parseTouchTime(s string) (time.Time, error) {
+	for _, layout := range touchTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s, expect format %s", s, strings.Join(touchTimeLayouts, ", "))
+}
+
+func fdStat(path string) (map[any]any, error) {
+	lstat, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	stat := lstat
+	isLink := lstat.Mode()&fs.ModeSymlink != 0
+	// a dangling link report the information of the link itself
+	if s, err := GetFDStat(path); err == nil {
+		stat = s
+	} else if !isLink {
+		return nil, err
+	}
+	owner, group, err := fdOwnerGroup(path, stat)
+	if err != nil {
+		return nil, err
+	}
+	return map[any]any{
+		"size":   stat.Size(),
+		"mode":   UnixStringPermission(stat.Mode(), stat.IsDir()),
+		"mtime":  stat.ModTime().Format(time.RFC3339),
+		"isDir":  stat.IsDir(),
+		"isLink": isLink,
+		"owner":  owner,
+		"group":  group,
+	}, nil
 }
 
 var originalWorkingDir string
@@ -633,13 +737,13 @@ func init() {
 		panic("unsupported operation get working directory \"Getwd\"")
 	}
 
-	registerFunction(NewBaseFunction(mkdirFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(mkdirFlags, func(rt Runtime, f Function, i any) (any, error) {
 		opts := i.(*fdOptions)
 		paths, err := readPath(f, opts, -1, 0)
 		if err != nil {
 			return nil, err
 		}
-		for _, path := range paths {
+		for _, path := range resolvePaths(rt, paths) {
 			m, err := fm.Parse(0, opts.Mode)
 			if err != nil {
 				return nil, err
@@ -655,18 +759,18 @@ func init() {
 		return nil, nil
 	}))
 
-	registerFunction(NewBaseFunction(rmdirFlags, func(f Function, i any) (any, error) {
-		return removeAll(true, f, i)
+	registerFunction(NewRuntimeFunction(rmdirFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return removeAll(true, rt, f, i)
 	}))
 
-	registerFunction(NewBaseFunction(rmFlags, func(f Function, i any) (v any, err error) {
-		if v, err = removeAll(false, f, i); err != nil && i.(*fdOptions).Silence {
+	registerFunction(NewRuntimeFunction(rmFlags, func(rt Runtime, f Function, i any) (v any, err error) {
+		if v, err = removeAll(false, rt, f, i); err != nil && i.(*fdOptions).Silence {
 			err = nil
 		}
 		return
 	}))
 
-	registerFunction(NewBaseFunction(chdirFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(chdirFlags, func(rt Runtime, f Function, i any) (any, error) {
 		opts := i.(*fdOptions)
 		if len(opts.Args) == 0 {
 			return nil, os.Chdir(originalWorkingDir)
@@ -675,11 +779,11 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return nil, os.Chdir(paths[0])
+			return nil, os.Chdir(ResolvePath(rt, paths[0]))
 		}
 	}, "chdir"))
 
-	registerFunction(NewBaseFunction(chownFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(chownFlags, func(rt Runtime, f Function, i any) (any, error) {
 		opts := i.(*fdOptions)
 		// must call ownergroup before path
 		u, g, err := readUserGroup(opts)
@@ -690,6 +794,7 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
+		paths = resolvePaths(rt, paths)
 		if opts.Recursive {
 			for _, path := range paths {
 				err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
@@ -709,12 +814,13 @@ func init() {
 		return nil, nil
 	}))
 
-	registerFunction(NewBaseFunction(chmodFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(chmodFlags, func(rt Runtime, f Function, i any) (any, error) {
 		opts := i.(*fdOptions)
 		paths, err := readPath(f, opts, -1, 1)
 		if err != nil {
 			return nil, err
 		}
+		paths = resolvePaths(rt, paths)
 		handleChmod := func(path string) error {
 			if err = Chmod(path, opts.Args[0]); err != nil {
 				return err
@@ -740,10 +846,10 @@ func init() {
 		return nil, nil
 	}))
 
-	registerFunction(NewBaseFunction(mvFlags, func(f Function, i any) (any, error) {
-		return moveOrCopy(f, i, func(moveTo bool, a, b string) error {
+	registerFunction(NewRuntimeFunction(mvFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return moveOrCopy(rt, f, i, func(moveTo bool, a, b string) error {
 			if moveTo {
-				if isFile, err := copyOrMoveDir(true, a, b); err != nil {
+				if isFile, err := copyOrMoveDir(true, false, a, b); err != nil {
 					return err
 				} else if !isFile {
 					return nil
@@ -753,11 +859,11 @@ func init() {
 		})
 	}, "move"))
 
-	registerFunction(NewBaseFunction(cpFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(cpFlags, func(rt Runtime, f Function, i any) (any, error) {
 		opts := i.(*fdOptions)
-		return moveOrCopy(f, i, func(_ bool, a, b string) error {
+		return moveOrCopy(rt, f, i, func(_ bool, a, b string) error {
 			if opts.Recursive {
-				if isFile, err := copyOrMoveDir(false, a, b); err != nil {
+				if isFile, err := copyOrMoveDir(false, opts.Backup, a, b); err != nil {
 					return err
 				} else if !isFile {
 					return nil
@@ -768,7 +874,75 @@ func init() {
 			if (err == nil || os.IsExist(err)) && statb.IsDir() {
 				b = filepath.Join(b, filepath.Base(a))
 			}
-			return copyFile(a, b)
+			return copyFile(a, b, opts.Backup)
 		})
 	}, "copy"))
+
+	registerFunction(NewRuntimeFunction(lnFlags, func(rt Runtime, f Function, i any) (any, error) {
+		opts := i.(*fdOptions)
+		paths, err := readPath(f, opts, 2, 0)
+		if err != nil {
+			return nil, err
+		}
+		// symbolic link target is relative to the link itself thus it is stored as is
+		target, link := paths[0], ResolvePath(rt, paths[1])
+		if !opts.Symbolic {
+			target = ResolvePath(rt, target)
+		}
+		if stat, err := os.Stat(link); err == nil && stat.IsDir() {
+			link = filepath.Join(link, filepath.Base(target))
+		}
+		if opts.Force {
+			if err = os.Remove(link); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		if opts.Symbolic {
+			return nil, os.Symlink(target, link)
+		}
+		return nil, os.Link(target, link)
+	}))
+
+	registerFunction(NewRuntimeFunction(touchFlags, func(rt Runtime, f Function, i any) (any, error) {
+		opts := i.(*fdOptions)
+		paths, err := readPath(f, opts, -1, 0)
+		if err != nil {
+			return nil, err
+		}
+		t := time.Now()
+		if opts.Date != "" {
+			if t, err = parseTouchTime(opts.Date); err != nil {
+				return nil, err
+			}
+		}
+		for _, path := range resolvePaths(rt, paths) {
+			if _, err = os.Stat(path); os.IsNotExist(err) {
+				fd, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, DefaultFileMode)
+				if err != nil {
+					return nil, err
+				}
+				fd.Close()
+			}
+			if err = os.Chtimes(path, t, t); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}))
+
+	registerFunction(NewRuntimeFunction(statFlags, func(rt Runtime, f Function, i any) (any, error) {
+		paths, err := readPath(f, i.(*fdOptions), 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fdStat(ResolvePath(rt, paths[0]))
+	}))
+
+	registerFunction(NewRuntimeFunction(readlinkFlags, func(rt Runtime, f Function, i any) (any, error) {
+		paths, err := readPath(f, i.(*fdOptions), 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		return os.Readlink(ResolvePath(rt, paths[0]))
+	}))
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/cozees/cook/pkg/runtime/args"
 	"github.com/stretchr/testify/assert"
@@ -185,6 +186,29 @@ func TestChangeDir(t *testing.T) {
 	assert.Equal(t, cdir, ndir)
 }
 
+func TestRuntimeWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	rt := &testRuntime{dir: dir}
+	apply := func(name string, params ...string) any {
+		v, err := GetFunction(name).(RuntimeFunction).ApplyWithRuntime(rt, convertToFunctionArgs(params))
+		require.NoError(t, err)
+		return v
+	}
+	apply("mkdir", "-p", "a/b")
+	assert.DirExists(t, filepath.Join(dir, "a", "b"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "x.txt"), []byte("x"), 0600))
+	apply("cp", "a/x.txt", "a/b/y.txt")
+	assert.FileExists(t, filepath.Join(dir, "a", "b", "y.txt"))
+	// result stay relative to the working directory
+	assert.Equal(t, []string{filepath.Join("a", "b", "y.txt"), filepath.Join("a", "x.txt")}, apply("find", "-t", "f", "a"))
+	assert.Equal(t, []string{filepath.Join("a", "x.txt")}, apply("pglob", "a/*.txt"))
+	apply("rm", "a/x.txt")
+	assert.NoFileExists(t, filepath.Join(dir, "a", "x.txt"))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(cwd, "a"))
+}
+
 func TestChown(t *testing.T) {
 	u, g, f := "_**_nouser", "_**_nogroup", "file__not__exist"
 	defer os.Remove(f)
@@ -352,3 +376,71 @@ func TestMoveRename(t *testing.T) {
 	assert.FileExists(t, f2)
 	assert.FileExists(t, f3)
 }
+
+func TestLinkTouchStat(t *testing.T) {
+	dir := t.TempDir()
+	a, d := filepath.Join(dir, "a.txt"), filepath.Join(dir, "d")
+	require.NoError(t, os.WriteFile(a, []byte("sample"), 0640))
+	require.NoError(t, os.Mkdir(d, 0750))
+	// hard link
+	_, err := GetFunction("ln").Apply(convertToFunctionArgs([]string{a, d}))
+	require.NoError(t, err)
+	verifyLink := func(file, content string) {
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	}
+	verifyLink(filepath.Join(d, "a.txt"), "sample")
+	_, err = GetFunction("ln").Apply(convertToFunctionArgs([]string{a, filepath.Join(d, "a.txt")}))
+	assert.Error(t, err)
+	_, err = GetFunction("ln").Apply(convertToFunctionArgs([]string{a}))
+	assert.Error(t, err)
+	// touch create new file and update time
+	b := filepath.Join(dir, "b.txt")
+	_, err = GetFunction("touch").Apply(convertToFunctionArgs([]string{"-d", "2021-03-04 05:06:07", a, b}))
+	require.NoError(t, err)
+	expect := time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	for _, f := range []string{a, b} {
+		stat, err := os.Stat(f)
+		require.NoError(t, err)
+		assert.True(t, expect.Equal(stat.ModTime()))
+	}
+	_, err = GetFunction("touch").Apply(convertToFunctionArgs([]string{"-d", "yesterday", a}))
+	assert.Error(t, err)
+	// stat
+	result, err := GetFunction("stat").Apply(convertToFunctionArgs([]string{a}))
+	require.NoError(t, err)
+	m := result.(map[any]any)
+	assert.Equal(t, int64(6), m["size"])
+	assert.Equal(t, expect.Format(time.RFC3339), m["mtime"])
+	assert.Equal(t, false, m["isDir"])
+	assert.Equal(t, false, m["isLink"])
+	assert.NotEmpty(t, m["owner"])
+	assert.NotEmpty(t, m["group"])
+	result, err = GetFunction("stat").Apply(convertToFunctionArgs([]string{d}))
+	require.NoError(t, err)
+	assert.Equal(t, true, result.(map[any]any)["isDir"])
+	_, err = GetFunction("stat").Apply(convertToFunctionArgs([]string{filepath.Join(dir, "none")}))
+	assert.Error(t, err)
+	if runtime.GOOS == "windows" {
+		return
+	}
+	assert.Equal(t, "-rw-r-----", m["mode"])
+	assert.Equal(t, "drwxr-x---", result.(map[any]any)["mode"])
+	// symbolic link
+	link := filepath.Join(dir, "link.txt")
+	_, err = GetFunction("ln").Apply(convertToFunctionArgs([]string{"-s", "a.txt", link}))
+	require.NoError(t, err)
+	verifyLink(link, "sample")
+	_, err = GetFunction("ln").Apply(convertToFunctionArgs([]string{"-s", "-f", "b.txt", link}))
+	require.NoError(t, err)
+	target, err := GetFunction("readlink").Apply(convertToFunctionArgs([]string{link}))
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", target)
+	result, err = GetFunction("stat").Apply(convertToFunctionArgs([]string{link}))
+	require.NoError(t, err)
+	assert.Equal(t, true, result.(map[any]any)["isLink"])
+	assert.Equal(t, int64(0), result.(map[any]any)["size"])
+	_, err = GetFunction("readlink").Apply(convertToFunctionArgs([]string{a}))
+	assert.Error(t, err)
+}
@@ -234,9 +234,9 @@ type wrapStatInfo struct {
 }
 
 func (wsi *wrapStatInfo) Name() string       { return wsi.src.Name() }
-func (wsi *wrapStatInfo) Size() int64        { return wsi.Size() }
-func (wsi *wrapStatInfo) Mode() os.FileMode  { return (wsi.Mode() &^ os.ModePerm) | wsi.winMode }
-func (wsi *wrapStatInfo) ModTime() time.Time { return wsi.ModTime() }
+func (wsi *wrapStatInfo) Size() int64        { return wsi.src.Size() }
+func (wsi *wrapStatInfo) Mode() os.FileMode  { return (wsi.src.Mode() &^ os.ModePerm) | wsi.winMode }
+func (wsi *wrapStatInfo) ModTime() time.Time { return wsi.src.ModTime() }
 func (wsi *wrapStatInfo) IsDir() bool        { return wsi.src.IsDir() }
 func (wsi *wrapStatInfo) Sys() any           { return wsi.src.Sys() }
 
@@ -244,9 +244,8 @@ func GetFDStat(file string) (stat os.FileInfo, err error) {
 	if stat, err = os.Stat(file); err == nil {
 		var mode os.FileMode
 		if mode, err = GetFDModePerm(file); err == nil {
-			stat.Mode()
+			stat = &wrapStatInfo{src: stat, winMode: mode}
 		}
-		stat = &wrapStatInfo{src: stat, winMode: mode}
 	}
 	return stat, err
 }
@@ -277,3 +276,32 @@ func getWinModePerm(u, g, o *windows.EXPLICIT_ACCESS) (os.FileMode, error) {
 	}
 	return mode, nil
 }
+
+// fdOwnerGroup return the owner and group account name of the file in form DOMAIN\name.
+func fdOwnerGroup(file string, _ os.FileInfo) (owner, group string, err error) {
+	sd, err := windows.GetNamedSecurityInfo(file, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION|windows.GROUP_SECURITY_INFORMATION)
+	if err != nil {
+		return "", "", err
+	}
+	name := func(sid *windows.SID) string {
+		if account, domain, _, err := sid.LookupAccount(""); err == nil {
+			if domain != "" {
+				return domain + "\\" + account
+			}
+			return account
+		}
+		return sid.String()
+	}
+	osid, _, err := sd.Owner()
+	if err != nil {
+		return "", "", err
+	}
+	gsid, _, err := sd.Group()
+	if err != nil {
+		return "", "", err
+	}
+	return name(osid), name(gsid), nil
+}
+
+// keepOwner does nothing, a file created on windows inherit the access control of its directory.
+func keepOwner(_ *os.File, _ os.FileInfo) error { return nil }
@@ -5,7 +5,9 @@ package function
 import (
 	"fmt"
 	"os"
+	osu "os/user"
 	"strconv"
+	"syscall"
 )
 
 func Chmod(file string, raw string) error {
@@ -51,3 +53,35 @@ func GetFDModePerm(file string) (os.FileMode, error) {
 }
 
 func GetFDStat(file string) (stat os.FileInfo, err error) { return os.Stat(file) }
+
+// fdOwnerGroup return the user and group name owning the file, a numeric id is return instead
+// if the name cannot be found.
+func fdOwnerGroup(file string, stat os.FileInfo) (owner, group string, err error) {
+	st, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported file information of %s", file)
+	}
+	owner, group = strconv.FormatUint(uint64(st.Uid), 10), strconv.FormatUint(uint64(st.Gid), 10)
+	if u, err := osu.LookupId(owner); err == nil {
+		owner = u.Username
+	}
+	if g, err := osu.LookupGroupId(group); err == nil {
+		group = g.Name
+	}
+	return owner, group, nil
+}
+
+// keepOwner change the owner of f to the owner of the file described by stat if they are different.
+func keepOwner(f *os.File, stat os.FileInfo) error {
+	want, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	fstat, err := f.Stat()
+	if err != nil {
+		return err
+	} else if cur, ok := fstat.Sys().(*syscall.Stat_t); ok && cur.Uid == want.Uid && cur.Gid == want.Gid {
+		return nil
+	}
+	return f.Chown(int(want.Uid), int(want.Gid))
+}
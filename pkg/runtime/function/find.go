@@ -0,0 +1,231 @@
+package function
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/cozees/cook/pkg/runtime/glob"
+)
+
+type findOption struct {
+	Name     []string `flag:"name"`
+	Type     string   `flag:"type"`
+	Newer    string   `flag:"newer"`
+	MTime    string   `flag:"mtime"`
+	Size     string   `flag:"size"`
+	MaxDepth int64    `flag:"maxdepth,-1"`
+	Exclude  []string `flag:"exclude"`
+	Args     []string
+
+	newer   time.Time
+	mtime   time.Duration
+	size    int64
+	sizeCmp int
+}
+
+const (
+	findNameDesc = `a glob pattern to match against the file name. If the pattern contain a slash "/", it is matched against
+					the path relative to ROOT instead. The pattern support "**" to match any number of directory as well as
+					alternative such as *.{go,md}. The flag can be given multiple time to match any of the pattern.`
+	findTypeDesc  = `type of the entry to return, f for regular file, d for directory and l for symbolic link.`
+	findNewerDesc = `return only the entry which has been modified more recent than the given file.`
+	findMTimeDesc = `a duration such as 24h. Prefix with "-" to return entry modified within the duration or "+" to return entry modified before the duration.
+					  Use the form --mtime=-24h since a value start with "-" is otherwise treated as a flag.`
+	findSizeDesc    = `size of the file in byte with optional suffix k, M or G. Prefix with "+" for bigger than or "-" for smaller than the size.`
+	findDepthDesc   = `descend at most the given level of directory below ROOT. A negative value mean no limit.`
+	findExcludeDesc = `a glob pattern, using the same syntax as flag name, of the entry to be skipped. Excluded directory is not descended.`
+	findDesc        = `Walk the file tree rooted at each ROOT and return a sorted array of path which satisfy every given predicate.
+					   The root itself is never part of the result.`
+)
+
+var findFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "n", Long: "name", Description: findNameDesc},
+		{Short: "t", Long: "type", Description: findTypeDesc},
+		{Long: "newer", Description: findNewerDesc},
+		{Long: "mtime", Description: findMTimeDesc},
+		{Long: "size", Description: findSizeDesc},
+		{Short: "d", Long: "maxdepth", Description: findDepthDesc},
+		{Short: "e", Long: "exclude", Description: findExcludeDesc},
+	},
+	Result:      reflect.TypeOf((*findOption)(nil)).Elem(),
+	FuncName:    "find",
+	Return:      reflect.Slice,
+	ShortDesc:   "search for file or directory in a directory hierarchy",
+	Usage:       "@find [-n pattern] [-t f|d|l] [--newer FILE] [--mtime DURATION] [--size SIZE] [-d depth] [-e pattern] ROOT [ROOT ...]",
+	Example:     "@find -n '*.{go,md}' -t f -e vendor -e '**/testdata' .",
+	Description: findDesc,
+}
+
+func (fo *findOption) validate(f Function) (err error) {
+	if len(fo.Args) == 0 {
+		return fmt.Errorf("function %s required at least one ROOT directory", f.Name())
+	}
+	switch fo.Type {
+	case "", "f", "d", "l":
+	default:
+		return fmt.Errorf("invalid type %s, expect f, d or l", fo.Type)
+	}
+	for _, p := range append(fo.Name, fo.Exclude...) {
+		if _, err = glob.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", p, err)
+		}
+	}
+	if fo.Newer != "" {
+		stat, err := os.Stat(fo.Newer)
+		if err != nil {
+			return err
+		}
+		fo.newer = stat.ModTime()
+	}
+	if fo.MTime != "" {
+		if fo.MTime[0] != '-' && fo.MTime[0] != '+' {
+			return fmt.Errorf("mtime %s must start with - or +", fo.MTime)
+		}
+		if fo.mtime, err = time.ParseDuration(fo.MTime[1:]); err != nil {
+			return fmt.Errorf("invalid mtime %s: %w", fo.MTime, err)
+		}
+	}
+	if fo.Size != "" {
+		fo.sizeCmp, fo.size, err = parseSize(fo.Size)
+	}
+	return err
+}
+
+// parseSize parse size such as +10k or -2M and return the comparison -1, 0 or 1 along with
+// the size in byte.
+func parseSize(s string) (cmp int, size int64, err error) {
+	switch s[0] {
+	case '+':
+		cmp, s = 1, s[1:]
+	case '-':
+		cmp, s = -1, s[1:]
+	}
+	unit := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'k', 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		}
+		if unit > 1 {
+			s = s[:n-1]
+		}
+	}
+	if size, err = strconv.ParseInt(s, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid size %s", s)
+	}
+	return cmp, size * unit, nil
+}
+
+func matchAny(patterns []string, name, rel string) bool {
+	for _, p := range patterns {
+		target := name
+		if strings.ContainsRune(p, '/') {
+			target = rel
+		}
+		if ok, _ := glob.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (fo *findOption) accept(d fs.DirEntry, rel string) (bool, error) {
+	switch {
+	case fo.Type == "f" && !d.Type().IsRegular(),
+		fo.Type == "d" && !d.IsDir(),
+		fo.Type == "l" && d.Type()&fs.ModeSymlink == 0:
+		return false, nil
+	}
+	if len(fo.Name) > 0 && !matchAny(fo.Name, d.Name(), rel) {
+		return false, nil
+	}
+	if fo.Newer == "" && fo.MTime == "" && fo.Size == "" {
+		return true, nil
+	}
+	info, err := d.Info()
+	if err != nil {
+		return false, err
+	}
+	if fo.Newer != "" && !info.ModTime().After(fo.newer) {
+		return false, nil
+	}
+	if fo.MTime != "" {
+		within := time.Since(info.ModTime()) <= fo.mtime
+		if within != (fo.MTime[0] == '-') {
+			return false, nil
+		}
+	}
+	if fo.Size != "" {
+		if d.IsDir() {
+			return false, nil
+		}
+		switch size := info.Size(); {
+		case fo.sizeCmp > 0 && size <= fo.size,
+			fo.sizeCmp < 0 && size >= fo.size,
+			fo.sizeCmp == 0 && size != fo.size:
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func find(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*findOption)
+	if opts.Newer != "" {
+		opts.Newer = ResolvePath(rt, opts.Newer)
+	}
+	if err := opts.validate(f); err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, root := range opts.Args {
+		// result path is relative to the working directory just like ROOT
+		rroot := ResolvePath(rt, root)
+		err := filepath.WalkDir(rroot, func(fpath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			} else if fpath == rroot {
+				return nil
+			}
+			rel, _ := filepath.Rel(rroot, fpath)
+			rel = filepath.ToSlash(rel)
+			if matchAny(opts.Exclude, d.Name(), rel) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if ok, err := opts.accept(d, rel); err != nil {
+				return err
+			} else if ok {
+				result = append(result, filepath.Join(root, rel))
+			}
+			if d.IsDir() && opts.MaxDepth >= 0 && int64(strings.Count(rel, "/")+1) >= opts.MaxDepth {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(findFlags, find))
+}
@@ -0,0 +1,84 @@
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type findTestCase struct {
+	args   []string
+	output []string
+	err    bool
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	files := map[string]int{
+		"a.go":              10,
+		"b.md":              2048,
+		"src/c.go":          100,
+		"src/test/d.go":     0,
+		"src/test/e.txt":    5000,
+		"vendor/lib/f.go":   1,
+		"vendor/lib/g.md":   1,
+		"docs/test/h.md":    30,
+		"docs/test/old.txt": 1,
+	}
+	for f, size := range files {
+		f = filepath.Join(dir, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0700))
+		require.NoError(t, os.WriteFile(f, make([]byte, size), 0600))
+	}
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "docs", "test", "old.txt"), old, old))
+	ref := filepath.Join(dir, "docs", "test", "old.txt")
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink("a.go", filepath.Join(dir, "link.go")))
+	}
+
+	join := func(fs ...string) []string {
+		for i, f := range fs {
+			fs[i] = filepath.Join(dir, filepath.FromSlash(f))
+		}
+		return fs
+	}
+	cases := []*findTestCase{
+		{args: []string{"-n", "*.go", "-t", "f"}, output: join("a.go", "src/c.go", "src/test/d.go", "vendor/lib/f.go")},
+		{args: []string{"-n", "*.go", "-t", "f", "-e", "vendor"}, output: join("a.go", "src/c.go", "src/test/d.go")},
+		{args: []string{"-n", "**/test/*.{go,md}"}, output: join("docs/test/h.md", "src/test/d.go")},
+		{args: []string{"-t", "d", "-d", "1"}, output: join("docs", "src", "vendor")},
+		{args: []string{"-t", "d", "-e", "**/lib"}, output: join("docs", "docs/test", "src", "src/test", "vendor")},
+		{args: []string{"--size", "+1k", "-t", "f"}, output: join("b.md", "src/test/e.txt")},
+		{args: []string{"--size=-1", "-t", "f"}, output: join("src/test/d.go")},
+		{args: []string{"--size", "100", "-t", "f"}, output: join("src/c.go")},
+		{args: []string{"--mtime", "+24h", "-t", "f"}, output: join("docs/test/old.txt")},
+		{args: []string{"--mtime=-24h", "-n", "*.txt"}, output: join("src/test/e.txt")},
+		{args: []string{"--newer", ref, "-n", "*.txt"}, output: join("src/test/e.txt")},
+		{args: []string{"-n", "*.none"}, output: []string{}},
+		{args: []string{"-t", "x"}, err: true},
+		{args: []string{"--mtime", "24h"}, err: true},
+		{args: []string{"--size", "abc"}, err: true},
+		{args: []string{"-n", "[a"}, err: true},
+	}
+	if runtime.GOOS != "windows" {
+		cases = append(cases, &findTestCase{args: []string{"-t", "l"}, output: join("link.go")})
+	}
+	for i, tc := range cases {
+		t.Logf("TestFind case #%d", i+1)
+		result, err := GetFunction("find").Apply(convertToFunctionArgs(append(tc.args, dir)))
+		if tc.err {
+			assert.Error(t, err)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, result)
+		}
+	}
+	_, err := GetFunction("find").Apply(convertToFunctionArgs([]string{"-t", "f"}))
+	assert.Error(t, err)
+}
@@ -2,17 +2,28 @@ package function
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/cozees/cook/pkg/runtime/args"
 )
 
 func AllHttpFlags() []*args.Flags {
-	return []*args.Flags{getFlags, headFlags, optionsFlags, postFlags, putFlags, deleteFlags, patchFlags}
+	return []*args.Flags{getFlags, headFlags, optionsFlags, postFlags, putFlags, deleteFlags, patchFlags, downloadFlags, serveFlags}
 }
 
 type httpOption struct {
@@ -21,14 +32,120 @@ type httpOption struct {
 	Data         string      `flag:"data"`
 	Restriction  bool        `flag:"strict"`
 	IsMetionData bool        `mention:"data"` // true if argument flag data is given even the value is zero/empty string ""
+	Form         []string    `flag:"form"`
+	URLEncode    bool        `flag:"urlencode"`
+	Timeout      string      `flag:"timeout"`
+	Retry        int64       `flag:"retry"`
+	User         string      `flag:"user"`
+	Bearer       string      `flag:"bearer"`
+	CACert       string      `flag:"cacert"`
+	Insecure     bool        `flag:"insecure"`
+	MaxRedirects int64       `flag:"max-redirects,10"`
+	Proxy        string      `flag:"proxy"`
+	Response     bool        `flag:"response"`
+	NoFail       bool        `flag:"no-fail"`
+	Output       string      `flag:"output"`
+	Resume       bool        `flag:"resume"`
+	SHA256       string      `flag:"sha256"`
 	Args         []string
 }
 
-func (ho *httpOption) validate(name string) (string, error) {
+// validate check the flags given to the function and resolve the path of flag file, cacert and form
+// file field against the working directory of the runtime.
+func (ho *httpOption) validate(rt Runtime, name string) (string, error) {
 	if len(ho.Args) != 1 {
 		return "", fmt.Errorf("function %s required one last argument as URL", name)
-	} else {
-		return ho.Args[0], nil
+	} else if ho.Retry < 0 {
+		return "", fmt.Errorf("function %s retry must not be a negative number", name)
+	} else if ho.MaxRedirects < 0 {
+		return "", fmt.Errorf("function %s max-redirects must not be a negative number", name)
+	} else if len(ho.Form) > 0 && (ho.File != "" || ho.IsMetionData) {
+		return "", fmt.Errorf("function %s flag form cannot be use with flag data or file", name)
+	} else if ho.URLEncode && len(ho.Form) == 0 {
+		return "", fmt.Errorf("function %s flag urlencode require at least one flag form", name)
+	} else if ho.User != "" && ho.Bearer != "" {
+		return "", fmt.Errorf("function %s accept either flag user or bearer but not both", name)
+	}
+	if ho.File != "" {
+		ho.File = ResolvePath(rt, ho.File)
+	}
+	if ho.CACert != "" {
+		ho.CACert = ResolvePath(rt, ho.CACert)
+	}
+	for i, field := range ho.Form {
+		if key, val, ok := strings.Cut(field, "="); ok && strings.HasPrefix(val, "@") {
+			ho.Form[i] = key + "=@" + ResolvePath(rt, val[1:])
+		}
+	}
+	return ho.Args[0], nil
+}
+
+// client create an http client configured according to the timeout, proxy, tls and
+// redirect flags given to the function.
+func (ho *httpOption) client() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ho.Proxy != "" {
+		purl, err := url.Parse(ho.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %s: %w", ho.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(purl)
+	}
+	if ho.CACert != "" || ho.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: ho.Insecure}
+		if ho.CACert != "" {
+			pem, err := os.ReadFile(ho.CACert)
+			if err != nil {
+				return nil, err
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in %s", ho.CACert)
+			}
+			transport.TLSClientConfig.RootCAs = pool
+		}
+	}
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if ho.MaxRedirects == 0 {
+				// the redirect response is returned as is
+				return http.ErrUseLastResponse
+			} else if int64(len(via)) > ho.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", ho.MaxRedirects)
+			}
+			return nil
+		},
+	}
+	if ho.Timeout != "" {
+		d, err := time.ParseDuration(ho.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", ho.Timeout, err)
+		}
+		client.Timeout = d
+	}
+	return client, nil
+}
+
+// succeed report whether the response status is a success, a redirect is also a success if
+// the function is told not to follow any redirect.
+func (ho *httpOption) succeed(resp *http.Response) bool {
+	if ho.MaxRedirects == 0 && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		return true
+	}
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// authorize set basic or bearer authorization header to the request if any was given.
+func (ho *httpOption) authorize(req *http.Request) {
+	if ho.User != "" {
+		u, p, _ := strings.Cut(ho.User, ":")
+		req.SetBasicAuth(u, p)
+	} else if ho.Bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+ho.Bearer)
 	}
 }
 
@@ -38,12 +155,32 @@ const (
 				  empty string to the server unless it was explicit in argument with --data "".`
 	fileDesc = `a path to a file which it's content is being used as the data to send to the server.
 				  Note: if both flag "file" and "data" is given at the same time then flag "file" is used instead of "data".`
-	strictDesc = `enforce the http request and response to follow the standard of http definition for each method.`
+	formDesc = `a form field in form of key=value to be sent as multipart/form-data. If the value start with @
+				the rest of the value is a path to a file to be uploaded, e.g. --form artifact=@build/cook.tar.gz.`
+	urlencodeDesc = `send the form fields as application/x-www-form-urlencoded instead of multipart/form-data.
+					 A value start with @ is replaced with the content of the file.`
+	strictDesc  = `enforce the http request and response to follow the standard of http definition for each method.`
+	timeoutDesc = `maximum time to wait for the request to complete including reading the response body, e.g. 30s or 1m.
+				   By default, there is no timeout.`
+	retryDesc = `number of time to retry the request when the server response with status 5xx or the connection failed.
+				 Each retry wait twice as long as the previous one, up to 30 seconds.`
+	userDesc     = `user and password in form of user:password to authenticate with the server using basic authentication.`
+	bearerDesc   = `a token to authenticate with the server using bearer authorization header.`
+	cacertDesc   = `a path to a PEM file contain one or more certificate authority used to verify the server certificate.`
+	insecureDesc = `skip verifying the server certificate. It should only be used for testing purpose.`
+	redirectDesc = `maximum number of redirect to follow before the function report an error. Use 0 to not follow any redirect,
+					the redirect response is then returned as is.`
+	proxyDesc    = `an url of proxy server to send the request through, e.g. http://proxy.local:3128.`
+	responseDesc = `return a map of "status", "headers", "body" and "url" instead of only the response body.
+					The "url" is the final url after following redirect and "headers" is a map of header
+					name to its value where multiple value is joined by a comma.`
+	noFailDesc = `do not report an error when the server response with status code other than 2xx. The function
+				  return a map as if flag response is given thus it can decide what to do base on the status code.`
 
 	// Common introduction for any http function
-	baseFnDesc = `Send an http request to the server at [URL] and return a response map which contain
-			  	  two key "header" and "body". The "header" is a map of response header where the "body"
-				  is a reader object if there is data in the body.`
+	baseFnDesc = `Send an http request to the server at [URL] and return the response body as a reader object.
+			  	  If flag response is given, a map which contain "status", "headers", "body" and "url" is
+				  returned instead. By default, a response with status code other than 2xx cause an error.`
 	largeBodyDesc = `function can be use with redirect statement as well as assign statement.
 			 		 However if the data from the function is too large it's better to use redirect
 					 statement to store the data in a file instead.`
@@ -52,17 +189,37 @@ const (
 					  written to standard output instead.`
 )
 
-var httpNoBodyFlags = []*args.Flag{
+// flags shared by every http function to configure the http client
+var httpClientFlags = []*args.Flag{
+	{Short: "t", Long: "timeout", Description: timeoutDesc},
+	{Short: "r", Long: "retry", Description: retryDesc},
+	{Short: "u", Long: "user", Description: userDesc},
+	{Long: "bearer", Description: bearerDesc},
+	{Long: "cacert", Description: cacertDesc},
+	{Short: "k", Long: "insecure", Description: insecureDesc},
+	{Long: "max-redirects", Description: redirectDesc},
+	{Long: "proxy", Description: proxyDesc},
+}
+
+// flags shared by every http function which return the response to caller
+var httpResponseFlags = []*args.Flag{
+	{Long: "response", Description: responseDesc},
+	{Long: "no-fail", Description: noFailDesc},
+}
+
+var httpNoBodyFlags = append([]*args.Flag{
 	{Short: "h", Long: "header", Description: headerDesc},
 	{Long: "strict", Description: strictDesc},
-}
+}, append(httpClientFlags, httpResponseFlags...)...)
 
-var httpFlags = []*args.Flag{
+var httpFlags = append([]*args.Flag{
 	{Short: "h", Long: "header", Description: headerDesc},
 	{Short: "d", Long: "data", Description: dataDesc},
 	{Short: "f", Long: "file", Description: fileDesc},
+	{Short: "F", Long: "form", Description: formDesc},
+	{Long: "urlencode", Description: urlencodeDesc},
 	{Long: "strict", Description: strictDesc},
-}
+}, append(httpClientFlags, httpResponseFlags...)...)
 
 type readerCloser struct {
 	*bytes.Reader
@@ -70,10 +227,145 @@ type readerCloser struct {
 
 func (rc *readerCloser) Close() error { return nil }
 
-func detectContentType(r io.ReadSeekCloser) string {
-	defer r.Seek(0, 0)
+// detectContentType sniff the content type from the first 512 bytes of r, the reader is
+// rewind to the beginning afterward.
+func detectContentType(r io.ReadSeeker) string {
+	defer r.Seek(0, io.SeekStart)
 	buf := [512]byte{}
-	return http.DetectContentType(buf[:])
+	n, _ := io.ReadFull(r, buf[:])
+	return http.DetectContentType(buf[:n])
+}
+
+// formBody encode the form flags into a request body and return it with its content type.
+// A multipart body is spool into a temporary file to avoid holding large upload in memory,
+// the caller is responsible to close the body which also remove the temporary file.
+func (ho *httpOption) formBody() (io.ReadSeekCloser, string, error) {
+	if ho.URLEncode {
+		values := url.Values{}
+		for _, field := range ho.Form {
+			key, val, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, "", fmt.Errorf("invalid form field %s, it must be in form of key=value", field)
+			}
+			if strings.HasPrefix(val, "@") {
+				b, err := os.ReadFile(val[1:])
+				if err != nil {
+					return nil, "", err
+				}
+				val = string(b)
+			}
+			values.Add(key, val)
+		}
+		body := &readerCloser{Reader: bytes.NewReader([]byte(values.Encode()))}
+		return body, "application/x-www-form-urlencoded", nil
+	}
+
+	tmp, err := os.CreateTemp("", "cook-form-*")
+	if err != nil {
+		return nil, "", err
+	}
+	body := &tempFileBody{File: tmp}
+	mw := multipart.NewWriter(tmp)
+	for _, field := range ho.Form {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			err = fmt.Errorf("invalid form field %s, it must be in form of key=value", field)
+		} else if strings.HasPrefix(val, "@") {
+			err = writeFormFile(mw, key, val[1:])
+		} else {
+			err = mw.WriteField(key, val)
+		}
+		if err != nil {
+			body.Close()
+			return nil, "", err
+		}
+	}
+	if err = mw.Close(); err != nil {
+		body.Close()
+		return nil, "", err
+	}
+	return body, mw.FormDataContentType(), nil
+}
+
+func writeFormFile(mw *multipart.Writer, key, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     key,
+		"filename": filepath.Base(file),
+	}))
+	h.Set("Content-Type", detectContentType(f))
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// tempFileBody is a temporary file which is removed when it closed.
+type tempFileBody struct {
+	*os.File
+}
+
+func (tf *tempFileBody) Close() error {
+	err := tf.File.Close()
+	if rerr := os.Remove(tf.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// base delay before the first retry, each subsequence retry double the delay.
+// It is a variable for testing purpose only.
+var retryBackoff = 500 * time.Millisecond
+
+// maxRetryBackoff is the longest delay between two attempts however large the retry flag is.
+const maxRetryBackoff = 30 * time.Second
+
+// backoff return the delay to wait after the given attempt before sending the request again.
+func backoff(attempt int64) time.Duration {
+	if attempt < 32 {
+		if d := retryBackoff << attempt; d > 0 && d < maxRetryBackoff {
+			return d
+		}
+	}
+	return maxRetryBackoff
+}
+
+// shouldRetry report whether the request should be sent again, a connection error or
+// a server error 5xx is consider temporary.
+func shouldRetry(resp *http.Response, err error) bool {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		// redirect limit is reported from CheckRedirect, no point of retrying
+		return uerr.Err == nil || !strings.HasPrefix(uerr.Err.Error(), "stopped after")
+	}
+	return err != nil || resp.StatusCode >= 500
+}
+
+// responseMap convert the response into a map of status, headers, body and url.
+func responseMap(resp *http.Response, canHasResponseBody bool) map[any]any {
+	headers := make(map[any]any, len(resp.Header))
+	for k, vs := range resp.Header {
+		headers[k] = strings.Join(vs, ", ")
+	}
+	var body any = ""
+	if canHasResponseBody {
+		body = resp.Body
+	} else {
+		resp.Body.Close()
+	}
+	return map[any]any{
+		"status":  int64(resp.StatusCode),
+		"headers": headers,
+		"body":    body,
+		"url":     resp.Request.URL.String(),
+	}
 }
 
 // for override testing purpose only
@@ -84,15 +376,58 @@ var returnFunc = func(resp *http.Response, canHasResponseBody bool) any {
 	return nil
 }
 
-func httpRequest(bf Function, i any, method string) (result any, err error) {
+// send create the request and send it to the server, the request is resent according to
+// the retry flag when the server is temporary unavailable until ctx is cancelled.
+func (ho *httpOption) send(ctx context.Context, method, url string, body io.ReadSeeker) (resp *http.Response, err error) {
+	client, err := ho.client()
+	if err != nil {
+		return nil, err
+	}
+	var req *http.Request
+	for attempt := int64(0); ; attempt++ {
+		var reqBody io.Reader
+		if body != nil {
+			// rewind body on every attempt, http client close the request body
+			// after it was sent thus it is wrap to keep the body reusable on retry
+			if _, err = body.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			reqBody = io.NopCloser(body)
+		}
+		if req, err = http.NewRequestWithContext(ctx, method, url, reqBody); err != nil {
+			return nil, err
+		}
+		// set header if available
+		if ho.Header != nil {
+			req.Header = ho.Header.Clone()
+		}
+		if body != nil && req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", detectContentType(body))
+		}
+		ho.authorize(req)
+		resp, err = client.Do(req)
+		if attempt >= ho.Retry || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+func httpRequest(rt Runtime, bf Function, i any, method string) (result any, err error) {
 	opts := i.(*httpOption)
-	url, err := opts.validate(bf.Name())
+	url, err := opts.validate(rt, bf.Name())
 	if err != nil {
 		return nil, err
 	}
 
-	var resp *http.Response
-	var req *http.Request
 	var body io.ReadSeekCloser
 	var canResponseHasBody = false
 	switch method {
@@ -102,37 +437,49 @@ func httpRequest(bf Function, i any, method string) (result any, err error) {
 		canResponseHasBody = true
 		fallthrough
 	case http.MethodPut:
-		if opts.File != "" {
+		if len(opts.Form) > 0 {
+			var contentType string
+			if body, contentType, err = opts.formBody(); err != nil {
+				return nil, err
+			}
+			defer body.Close()
+			if opts.Header.Get("Content-Type") == "" {
+				if opts.Header == nil {
+					opts.Header = make(http.Header)
+				}
+				opts.Header.Set("Content-Type", contentType)
+			}
+		} else if opts.File != "" {
 			body, err = os.Open(opts.File)
 			if err != nil {
 				return nil, err
 			}
+			defer body.Close()
 		} else if opts.IsMetionData {
 			body = &readerCloser{Reader: bytes.NewReader([]byte(opts.Data))}
 		}
 	}
 
-	if req, err = http.NewRequest(method, url, body); err != nil {
-		return nil, err
-	}
-	// set header if available
-	if opts.Header != nil {
-		req.Header = http.Header(opts.Header)
-	}
-	if body != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", detectContentType(body))
+	var resp *http.Response
+	if body != nil {
+		resp, err = opts.send(rt.Context(), method, url, body)
+	} else {
+		resp, err = opts.send(rt.Context(), method, url, nil)
 	}
-	if resp, err = http.DefaultClient.Do(req); err != nil {
+	if err != nil {
 		return nil, err
 	}
-	switch {
-	case resp.StatusCode == http.StatusOK:
-		return returnFunc(resp, canResponseHasBody), nil
-	case resp.StatusCode < 300:
-		return nil, nil
-	default:
-		return nil, fmt.Errorf("server report %d on get %s", resp.StatusCode, url)
+	if !opts.NoFail && !opts.succeed(resp) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("server report %d on %s %s", resp.StatusCode, strings.ToLower(method), url)
+	}
+	if opts.Response || opts.NoFail {
+		// status other than 2xx is only distinguishable through the response map
+		return responseMap(resp, canResponseHasBody), nil
+	} else if !canResponseHasBody {
+		resp.Body.Close()
 	}
+	return returnFunc(resp, canResponseHasBody), nil
 }
 
 var httpOptsType = reflect.TypeOf((*httpOption)(nil)).Elem()
@@ -172,7 +519,7 @@ var postFlags = &args.Flags{
 	Result:      httpOptsType,
 	FuncName:    "post",
 	ShortDesc:   "send http post request",
-	Usage:       "@post [-h key:val [-h key:value] ...] [-d data] [-f file] URL",
+	Usage:       "@post [-h key:val [-h key:value] ...] [-d data] [-f file] [-F key=value [-F key=@file] ...] [--urlencode] URL",
 	Example:     "@post -h Content-Type:application/json -d '{\"key\":123}' https://www.example.com",
 	Description: baseFnDesc + " @post " + largeBodyDesc,
 }
@@ -182,7 +529,7 @@ var patchFlags = &args.Flags{
 	Result:      httpOptsType,
 	FuncName:    "patch",
 	ShortDesc:   "send http patch request",
-	Usage:       "@patch [-h key:val [-h key:value] ...] [-d data] [-f file] URL",
+	Usage:       "@patch [-h key:val [-h key:value] ...] [-d data] [-f file] [-F key=value [-F key=@file] ...] [--urlencode] URL",
 	Example:     "@patch -h Content-Type:application/json -d '{\"key\":123}' https://www.example.com",
 	Description: baseFnDesc + " @patch " + largeBodyDesc,
 }
@@ -192,7 +539,7 @@ var putFlags = &args.Flags{
 	Result:      httpOptsType,
 	FuncName:    "put",
 	ShortDesc:   "send http put request",
-	Usage:       "@put [-h key:val [-h key:value] ...] [-d data] [-f file] URL",
+	Usage:       "@put [-h key:val [-h key:value] ...] [-d data] [-f file] [-F key=value [-F key=@file] ...] [--urlencode] URL",
 	Example:     "@put -h Content-Type:application/json -d '{\"key\":123}' https://www.example.com",
 	Description: baseFnDesc + " Note: By standard, put " + noBodyRespDesc,
 }
@@ -202,37 +549,37 @@ var deleteFlags = &args.Flags{
 	Result:      httpOptsType,
 	FuncName:    "delete",
 	ShortDesc:   "send http delete request",
-	Usage:       "@delete [-h key:val [-h key:value] ...] [-d data] [-f file] URL",
+	Usage:       "@delete [-h key:val [-h key:value] ...] [-d data] [-f file] [-F key=value [-F key=@file] ...] [--urlencode] URL",
 	Example:     "@delete -h Content-Type:application/json -d '{\"key\":123}' https://www.example.com",
 	Description: baseFnDesc + " @delete " + largeBodyDesc,
 }
 
 func init() {
-	registerFunction(NewBaseFunction(getFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodGet)
+	registerFunction(NewRuntimeFunction(getFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodGet)
 	}, "fetch"))
 
-	registerFunction(NewBaseFunction(headFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodHead)
+	registerFunction(NewRuntimeFunction(headFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodHead)
 	}))
 
-	registerFunction(NewBaseFunction(optionsFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodOptions)
+	registerFunction(NewRuntimeFunction(optionsFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodOptions)
 	}))
 
-	registerFunction(NewBaseFunction(postFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodPost)
+	registerFunction(NewRuntimeFunction(postFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodPost)
 	}))
 
-	registerFunction(NewBaseFunction(patchFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodPatch)
+	registerFunction(NewRuntimeFunction(patchFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodPatch)
 	}))
 
-	registerFunction(NewBaseFunction(putFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodPut)
+	registerFunction(NewRuntimeFunction(putFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodPut)
 	}))
 
-	registerFunction(NewBaseFunction(deleteFlags, func(f Function, i any) (any, error) {
-		return httpRequest(f, i, http.MethodDelete)
+	registerFunction(NewRuntimeFunction(deleteFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return httpRequest(rt, f, i, http.MethodDelete)
 	}))
 }
@@ -2,15 +2,19 @@ package function
 
 import (
 	"bytes"
+	"context"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cozees/cook/pkg/runtime/args"
 	"github.com/stretchr/testify/assert"
@@ -149,7 +153,7 @@ var getTestCase = []httpTestInOut{
 	{ // case 7
 		args:    convertToFunctionArgs([]string{"-d", "simple"}),
 		methods: []string{http.MethodPost, http.MethodPatch, http.MethodDelete},
-		output:  "R-Content-Type: application/octet-stream\nR-Method: %s\nsimple",
+		output:  "R-Content-Type: text/plain; charset=utf-8\nR-Method: %s\nsimple",
 	},
 	{ // case 8
 		args:    convertToFunctionArgs([]string{"-h", "Content-Type: text/plain", "-d", "simple"}),
@@ -159,7 +163,7 @@ var getTestCase = []httpTestInOut{
 	{ // case 9
 		args:    convertToFunctionArgs([]string{"-f", jsonFile}),
 		methods: []string{http.MethodPost, http.MethodPatch, http.MethodDelete},
-		output:  "R-Content-Type: application/octet-stream\nR-Method: %s\n" + jsonContent,
+		output:  "R-Content-Type: text/plain; charset=utf-8\nR-Method: %s\n" + jsonContent,
 	},
 	{ // case 10
 		args:    convertToFunctionArgs([]string{"-h", "Content-Type: application/json; charset=utf-8", "-f", jsonFile}),
@@ -195,3 +199,266 @@ func TestHttpFunction(t *testing.T) {
 		}
 	}
 }
+
+func applyHttp(t *testing.T, name string, sargs ...string) (*http.Response, error) {
+	result, err := GetFunction(name).Apply(convertToFunctionArgs(sargs))
+	if err != nil {
+		return nil, err
+	}
+	require.NotNil(t, result)
+	resp := result.(*http.Response)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, nil
+}
+
+func TestHttpClientOption(t *testing.T) {
+	retryBackoff = time.Millisecond
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+		_, err := applyHttp(t, "get", "--timeout", "20ms", server.URL)
+		assert.Error(t, err)
+		_, err = applyHttp(t, "get", "--timeout", "abc", server.URL)
+		assert.Error(t, err)
+		_, err = applyHttp(t, "get", "--timeout", "2s", server.URL)
+		assert.NoError(t, err)
+	})
+	t.Run("retry", func(t *testing.T) {
+		count := 0
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			count++
+			b, _ := io.ReadAll(r.Body)
+			if count < 3 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			rw.Write(b)
+		}))
+		defer server.Close()
+		_, err := applyHttp(t, "post", "-d", "payload", "--retry", "1", server.URL)
+		assert.Error(t, err)
+		assert.Equal(t, 2, count)
+		count = 0
+		resp, err := applyHttp(t, "post", "-d", "payload", "--retry", "3", server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		b, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "payload", string(b))
+		// delay is capped however large the retry is
+		assert.Equal(t, 2*time.Millisecond, backoff(1))
+		assert.Equal(t, maxRetryBackoff, backoff(40))
+		assert.Equal(t, maxRetryBackoff, backoff(1<<40))
+	})
+	t.Run("retry-cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		count := 0
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			count++
+			cancel()
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		rt := &testRuntime{ctx: ctx}
+		_, err := GetFunction("get").(RuntimeFunction).ApplyWithRuntime(rt, convertToFunctionArgs([]string{"--retry", "100", server.URL}))
+		assert.Error(t, err)
+		assert.Equal(t, 1, count)
+	})
+	t.Run("auth", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("R-Authorization", r.Header.Get("Authorization"))
+		}))
+		defer server.Close()
+		resp, err := applyHttp(t, "get", "-u", "cook:secret", server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "Basic Y29vazpzZWNyZXQ=", resp.Header.Get("R-Authorization"))
+		resp, err = applyHttp(t, "delete", "--bearer", "token", server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token", resp.Header.Get("R-Authorization"))
+		_, err = applyHttp(t, "get", "-u", "cook:secret", "--bearer", "token", server.URL)
+		assert.Error(t, err)
+	})
+	t.Run("max-redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			var n int
+			fmt.Sscanf(r.URL.Path, "/%d", &n)
+			if n < 3 {
+				http.Redirect(rw, r, fmt.Sprintf("/%d", n+1), http.StatusFound)
+				return
+			}
+			rw.Write([]byte("done"))
+		}))
+		defer server.Close()
+		_, err := applyHttp(t, "get", "--max-redirects", "2", server.URL+"/0")
+		assert.Error(t, err)
+		_, err = applyHttp(t, "get", "--max-redirects", "3", server.URL+"/0")
+		assert.NoError(t, err)
+		_, err = applyHttp(t, "get", server.URL+"/0")
+		assert.NoError(t, err)
+		// redirect is not followed, the response is returned as is
+		resp, err := applyHttp(t, "get", "--max-redirects", "0", server.URL+"/0")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/1", resp.Header.Get("Location"))
+	})
+	t.Run("tls", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		_, err := applyHttp(t, "get", server.URL)
+		assert.Error(t, err)
+		_, err = applyHttp(t, "get", "-k", server.URL)
+		assert.NoError(t, err)
+		cert := filepath.Join(t.TempDir(), "ca.pem")
+		block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+		require.NoError(t, os.WriteFile(cert, pem.EncodeToMemory(block), 0600))
+		_, err = applyHttp(t, "get", "--cacert", cert, server.URL)
+		assert.NoError(t, err)
+	})
+	t.Run("proxy", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("R-Proxy", r.URL.String())
+		}))
+		defer proxy.Close()
+		resp, err := applyHttp(t, "get", "--proxy", proxy.URL, "http://cook.invalid/path")
+		require.NoError(t, err)
+		assert.Equal(t, "http://cook.invalid/path", resp.Header.Get("R-Proxy"))
+	})
+}
+
+func TestHttpResponseOption(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(rw, r, "/created", http.StatusFound)
+		case "/created":
+			rw.Header().Add("X-Id", "1")
+			rw.Header().Add("X-Id", "2")
+			rw.WriteHeader(http.StatusCreated)
+			rw.Write([]byte("created"))
+		default:
+			http.NotFound(rw, r)
+		}
+	}))
+	defer server.Close()
+
+	result, err := GetFunction("get").Apply(convertToFunctionArgs([]string{"--response", server.URL + "/moved"}))
+	require.NoError(t, err)
+	m := result.(map[any]any)
+	assert.Equal(t, int64(http.StatusCreated), m["status"])
+	assert.Equal(t, server.URL+"/created", m["url"])
+	assert.Equal(t, "1, 2", m["headers"].(map[any]any)["X-Id"])
+	body := m["body"].(io.ReadCloser)
+	b, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "created", string(b))
+
+	result, err = GetFunction("head").Apply(convertToFunctionArgs([]string{"--response", server.URL + "/created"}))
+	require.NoError(t, err)
+	assert.Equal(t, "", result.(map[any]any)["body"])
+
+	_, err = GetFunction("get").Apply(convertToFunctionArgs([]string{"--response", server.URL + "/missing"}))
+	assert.Error(t, err)
+
+	result, err = GetFunction("get").Apply(convertToFunctionArgs([]string{"--response", "--no-fail", server.URL + "/missing"}))
+	require.NoError(t, err)
+	m = result.(map[any]any)
+	assert.Equal(t, int64(http.StatusNotFound), m["status"])
+	m["body"].(io.ReadCloser).Close()
+
+	// no-fail alone return the response map as well
+	result, err = GetFunction("get").Apply(convertToFunctionArgs([]string{"--no-fail", server.URL + "/missing"}))
+	require.NoError(t, err)
+	m = result.(map[any]any)
+	assert.Equal(t, int64(http.StatusNotFound), m["status"])
+	m["body"].(io.ReadCloser).Close()
+}
+
+func TestHttpFormOption(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("R-Content-Type", r.Header.Get("Content-Type"))
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+		} else {
+			require.NoError(t, r.ParseForm())
+		}
+		keys := []string{}
+		for k := range r.Form {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(rw, "%s=%s\n", k, strings.Join(r.Form[k], ","))
+		}
+		if r.MultipartForm != nil {
+			for k, fhs := range r.MultipartForm.File {
+				f, err := fhs[0].Open()
+				require.NoError(t, err)
+				b, err := io.ReadAll(f)
+				f.Close()
+				require.NoError(t, err)
+				fmt.Fprintf(rw, "%s@%s(%s)=%s\n", k, fhs[0].Filename, fhs[0].Header.Get("Content-Type"), b)
+			}
+		}
+	}))
+	defer server.Close()
+
+	read := func(resp *http.Response) string {
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	resp, err := applyHttp(t, "post", "-F", "name=cook", "-F", "tag=a", "-F", "tag=b", "-F", "artifact=@"+jsonFile, server.URL)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("R-Content-Type"), "multipart/form-data; boundary="))
+	assert.Equal(t, "name=cook\ntag=a,b\nartifact@sample(text/plain; charset=utf-8)="+jsonContent+"\n", read(resp))
+
+	resp, err = applyHttp(t, "patch", "--form", "name=cook book", "--form", "tag=@"+jsonFile, "--urlencode", server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-www-form-urlencoded", resp.Header.Get("R-Content-Type"))
+	assert.Equal(t, "name=cook book\ntag="+jsonContent+"\n", read(resp))
+
+	_, err = applyHttp(t, "post", "-F", "name", server.URL)
+	assert.Error(t, err)
+	_, err = applyHttp(t, "post", "-F", "name=cook", "-d", "data", server.URL)
+	assert.Error(t, err)
+	_, err = applyHttp(t, "post", "--urlencode", server.URL)
+	assert.Error(t, err)
+}
+
+func TestHttpWorkingDir(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			f, _, err := r.FormFile("artifact")
+			require.NoError(t, err)
+			defer f.Close()
+			io.Copy(rw, f)
+		} else if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			require.NoError(t, r.ParseForm())
+			io.WriteString(rw, r.Form.Get("tag"))
+		} else {
+			io.Copy(rw, r.Body)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), pem.EncodeToMemory(block), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "body.txt"), []byte("file body"), 0600))
+	rt := &testRuntime{dir: dir}
+	apply := func(name string, params ...string) string {
+		v, err := GetFunction(name).(RuntimeFunction).ApplyWithRuntime(rt, convertToFunctionArgs(params))
+		require.NoError(t, err)
+		resp := v.(*http.Response)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+	assert.Equal(t, "file body", apply("post", "--cacert", "ca.pem", "-f", "body.txt", server.URL))
+	assert.Equal(t, "file body", apply("post", "--cacert", "ca.pem", "-F", "artifact=@body.txt", server.URL))
+	assert.Equal(t, "file body", apply("patch", "--cacert", "ca.pem", "-F", "tag=@body.txt", "--urlencode", server.URL))
+}
@@ -0,0 +1,480 @@
+package function
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+)
+
+const inputDesc = ` Each INPUT can be a string, an array of line or a reader such as the result of an http function,
+				   a file is read with flag --file. A value piped into the function is the last INPUT.`
+
+type grepOption struct {
+	Invert     bool     `flag:"invert"`
+	Count      bool     `flag:"count"`
+	Number     bool     `flag:"number"`
+	IgnoreCase bool     `flag:"ignore-case"`
+	Files      []string `flag:"file"`
+	Args       []any
+}
+
+type firstOption struct {
+	Lines int64    `flag:"lines,10"`
+	Files []string `flag:"file"`
+	Args  []any
+}
+
+type tailOption struct {
+	Lines string   `flag:"lines,10"`
+	Files []string `flag:"file"`
+	Args  []any
+}
+
+type sortOption struct {
+	Numeric    bool     `flag:"numeric"`
+	Reverse    bool     `flag:"reverse"`
+	Unique     bool     `flag:"unique"`
+	IgnoreCase bool     `flag:"ignore-case"`
+	Key        int64    `flag:"key"`
+	Separator  string   `flag:"separator"`
+	Files      []string `flag:"file"`
+	Args       []any
+}
+
+type uniqOption struct {
+	Count      bool     `flag:"count"`
+	Repeated   bool     `flag:"repeated"`
+	Unique     bool     `flag:"unique"`
+	IgnoreCase bool     `flag:"ignore-case"`
+	Files      []string `flag:"file"`
+	Args       []any
+}
+
+type wcOption struct {
+	Lines bool     `flag:"lines"`
+	Words bool     `flag:"words"`
+	Bytes bool     `flag:"bytes"`
+	Files []string `flag:"file"`
+	Args  []any
+}
+
+const (
+	grepInvertDesc = `return the lines which do not match the PATTERN instead.`
+	grepCountDesc  = `return the number of matching lines instead of the lines.`
+	grepNumberDesc = `prefix each line with its line number within its INPUT follow by a colon, e.g. 12:text.`
+	ignoreCaseDesc = `ignore the case of the letter when comparing.`
+	inputFileDesc  = `read the content of the file as an INPUT before the other INPUT, the flag can be given more than once.`
+	grepDesc       = `Return an array of the lines matching the regular expression PATTERN.` + inputDesc
+	firstLinesDesc = `number of lines to return, default is 10.`
+	firstDesc      = `Return an array of the first lines of the INPUT. Note that @head is the http function.` + inputDesc
+	tailLinesDesc  = `number of lines to return, default is 10. If the number is prefixed with + such as +5, the lines
+						 starting from that line number is returned instead.`
+	tailDesc        = `Return an array of the last lines of the INPUT.` + inputDesc
+	sortNumericDesc = `compare the key as a floating point number, a key which is not a number is treated as 0.`
+	sortReverseDesc = `sort in descending order.`
+	sortUniqueDesc  = `keep only the first line of the lines which have the same key.`
+	sortKeyDesc     = `sort by the Nth field of the line starting from 1 instead of the whole line. Fields are separated
+						 by whitespace unless flag --separator is given.`
+	sortSeparatorDesc = `the string separating the fields of the line used by flag --key.`
+	sortDesc          = `Return an array of the lines of the INPUT sorted in ascending order. Lines with the same key keep
+						 their original order.` + inputDesc
+	uniqCountDesc    = `return an array of [count, line] pairs instead of lines.`
+	uniqRepeatedDesc = `return only the line which is repeated.`
+	uniqUniqueDesc   = `return only the line which is not repeated.`
+	uniqDesc         = `Return an array of the lines of the INPUT with adjacent identical lines merged into one. Sort the
+						 lines first to remove every duplicated line.` + inputDesc
+	wcLinesDesc = `return the number of lines.`
+	wcWordsDesc = `return the number of words separated by whitespace.`
+	wcBytesDesc = `return the number of bytes.`
+	wcDesc      = `Count the lines, words and bytes of the INPUT and return a map with key lines, words and bytes.
+						 If one flag is given, the count is returned as an integer instead.` + inputDesc
+)
+
+var grepFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "v", Long: "invert", Description: grepInvertDesc},
+		{Short: "c", Long: "count", Description: grepCountDesc},
+		{Short: "n", Long: "number", Description: grepNumberDesc},
+		{Short: "i", Long: "ignore-case", Description: ignoreCaseDesc},
+		{Long: "file", Description: inputFileDesc},
+	},
+	Result:      reflect.TypeOf((*grepOption)(nil)).Elem(),
+	FuncName:    "grep",
+	ShortDesc:   "find the lines matching a regular expression",
+	Usage:       "@grep [-v] [-c] [-n] [-i] [--file FILE] PATTERN [INPUT ...]",
+	Example:     "@grep -c -i --file build.log 'error|fatal'",
+	Description: grepDesc,
+}
+
+var firstFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "n", Long: "lines", Description: firstLinesDesc},
+		{Long: "file", Description: inputFileDesc},
+	},
+	Result:      reflect.TypeOf((*firstOption)(nil)).Elem(),
+	FuncName:    "first",
+	Return:      reflect.Slice,
+	ShortDesc:   "return the first lines",
+	Usage:       "@first [-n N] [--file FILE] [INPUT ...]",
+	Example:     "@first -n 5 --file CHANGELOG.md",
+	Description: firstDesc,
+}
+
+var tailFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "n", Long: "lines", Description: tailLinesDesc},
+		{Long: "file", Description: inputFileDesc},
+	},
+	Result:      reflect.TypeOf((*tailOption)(nil)).Elem(),
+	FuncName:    "tail",
+	Return:      reflect.Slice,
+	ShortDesc:   "return the last lines",
+	Usage:       "@tail [-n [+]N] [--file FILE] [INPUT ...]",
+	Example:     "@tail -n 20 --file server.log",
+	Description: tailDesc,
+}
+
+var sortFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "n", Long: "numeric", Description: sortNumericDesc},
+		{Short: "r", Long: "reverse", Description: sortReverseDesc},
+		{Short: "u", Long: "unique", Description: sortUniqueDesc},
+		{Short: "f", Long: "ignore-case", Description: ignoreCaseDesc},
+		{Short: "k", Long: "key", Description: sortKeyDesc},
+		{Short: "t", Long: "separator", Description: sortSeparatorDesc},
+		{Long: "file", Description: inputFileDesc},
+	},
+	Result:      reflect.TypeOf((*sortOption)(nil)).Elem(),
+	FuncName:    "sort",
+	Return:      reflect.Slice,
+	ShortDesc:   "sort lines",
+	Usage:       "@sort [-n] [-r] [-u] [-f] [-k N] [-t SEP] [--file FILE] [INPUT ...]",
+	Example:     "@sort -n -r -k 2 -t , --file scores.csv",
+	Description: sortDesc,
+}
+
+var uniqFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "c", Long: "count", Description: uniqCountDesc},
+		{Short: "d", Long: "repeated", Description: uniqRepeatedDesc},
+		{Short: "u", Long: "unique", Description: uniqUniqueDesc},
+		{Short: "i", Long: "ignore-case", Description: ignoreCaseDesc},
+		{Long: "file", Description: inputFileDesc},
+	},
+	Result:      reflect.TypeOf((*uniqOption)(nil)).Elem(),
+	FuncName:    "uniq",
+	Return:      reflect.Slice,
+	ShortDesc:   "merge adjacent identical lines",
+	Usage:       "@uniq [-c] [-d] [-u] [-i] [--file FILE] [INPUT ...]",
+	Example:     "@uniq -c --file words.txt",
+	Description: uniqDesc,
+}
+
+var wcFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "l", Long: "lines", Description: wcLinesDesc},
+		{Short: "w", Long: "words", Description: wcWordsDesc},
+		{Short: "c", Long: "bytes", Description: wcBytesDesc},
+		{Long: "file", Description: inputFileDesc},
+	},
+	Result:      reflect.TypeOf((*wcOption)(nil)).Elem(),
+	FuncName:    "wc",
+	ShortDesc:   "count lines, words and bytes",
+	Usage:       "@wc [-l] [-w] [-c] [--file FILE] [INPUT ...]",
+	Example:     "@wc -l --file main.go",
+	Description: wcDesc,
+}
+
+// readInputs return the content of each file follow by the content of each input. A string input is
+// the text itself, an array is a list of lines and a reader is read until the end.
+func readInputs(rt Runtime, f Function, files []string, inputs []any) ([]string, error) {
+	if len(files) == 0 && len(inputs) == 0 {
+		return nil, fmt.Errorf("function %s required at least one INPUT or --file", f.Name())
+	}
+	texts := make([]string, 0, len(files)+len(inputs))
+	for _, file := range files {
+		b, err := os.ReadFile(ResolvePath(rt, file))
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, string(b))
+	}
+	for _, in := range inputs {
+		i := len(texts)
+		texts = append(texts, "")
+		switch v := in.(type) {
+		case string:
+			texts[i] = v
+		case io.Reader:
+			b, err := io.ReadAll(v)
+			if rc, ok := v.(io.Closer); ok {
+				rc.Close()
+			}
+			if err != nil {
+				return nil, err
+			}
+			texts[i] = string(b)
+		default:
+			rv := reflect.ValueOf(in)
+			if rv.Kind() != reflect.Slice {
+				s, err := toString(in)
+				if err != nil {
+					return nil, err
+				}
+				texts[i] = s
+				continue
+			}
+			sb := &strings.Builder{}
+			for j := 0; j < rv.Len(); j++ {
+				s, err := toString(rv.Index(j).Interface())
+				if err != nil {
+					return nil, err
+				}
+				sb.WriteString(s)
+				sb.WriteByte('\n')
+			}
+			texts[i] = sb.String()
+		}
+	}
+	return texts, nil
+}
+
+// splitText split text into lines without the line terminator.
+func splitText(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+func readLines(rt Runtime, f Function, files []string, inputs []any) ([]string, error) {
+	texts, err := readInputs(rt, f, files, inputs)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, t := range texts {
+		lines = append(lines, splitText(t)...)
+	}
+	return lines, nil
+}
+
+func toArray(lines []string) []any {
+	result := make([]any, len(lines))
+	for i, l := range lines {
+		result[i] = l
+	}
+	return result
+}
+
+func grep(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*grepOption)
+	if len(opts.Args) == 0 {
+		return nil, fmt.Errorf("function %s required PATTERN", f.Name())
+	}
+	pattern, ok := opts.Args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("pattern %v is not a string", opts.Args[0])
+	} else if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s: %w", pattern, err)
+	}
+	texts, err := readInputs(rt, f, opts.Files, opts.Args[1:])
+	if err != nil {
+		return nil, err
+	}
+	count, result := int64(0), make([]any, 0)
+	for _, t := range texts {
+		for n, line := range splitText(t) {
+			if reg.MatchString(line) == opts.Invert {
+				continue
+			}
+			count++
+			if opts.Number {
+				line = strconv.Itoa(n+1) + ":" + line
+			}
+			result = append(result, line)
+		}
+	}
+	if opts.Count {
+		return count, nil
+	}
+	return result, nil
+}
+
+func first(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*firstOption)
+	if opts.Lines < 0 {
+		return nil, fmt.Errorf("number of lines must not be negative")
+	}
+	lines, err := readLines(rt, f, opts.Files, opts.Args)
+	if err != nil {
+		return nil, err
+	}
+	return toArray(lines[:min(int(opts.Lines), len(lines))]), nil
+}
+
+func tail(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*tailOption)
+	n, err := strconv.ParseInt(strings.TrimPrefix(opts.Lines, "+"), 10, 32)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid number of lines %s", opts.Lines)
+	}
+	lines, err := readLines(rt, f, opts.Files, opts.Args)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(opts.Lines, "+") {
+		return toArray(lines[min(max(int(n)-1, 0), len(lines)):]), nil
+	}
+	return toArray(lines[len(lines)-min(int(n), len(lines)):]), nil
+}
+
+func (so *sortOption) key(line string) string {
+	if so.Key > 0 {
+		var fields []string
+		if so.Separator != "" {
+			fields = strings.Split(line, so.Separator)
+		} else {
+			fields = strings.Fields(line)
+		}
+		if int(so.Key) > len(fields) {
+			return ""
+		}
+		line = fields[so.Key-1]
+	}
+	if so.IgnoreCase {
+		line = strings.ToLower(line)
+	}
+	return line
+}
+
+// compare return -1, 0 or 1 if key a is less than, equal or greater than key b.
+func (so *sortOption) compare(a, b string) int {
+	if so.Numeric {
+		fa, _ := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		fb, _ := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func sortLines(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*sortOption)
+	if opts.Key < 0 {
+		return nil, fmt.Errorf("key must be a field number starting from 1")
+	}
+	lines, err := readLines(rt, f, opts.Files, opts.Args)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(lines))
+	indexes := make([]int, len(lines))
+	for i, l := range lines {
+		keys[i], indexes[i] = opts.key(l), i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		c := opts.compare(keys[indexes[i]], keys[indexes[j]])
+		if opts.Reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	result := make([]any, 0, len(lines))
+	for i, index := range indexes {
+		if opts.Unique && i > 0 && opts.compare(keys[indexes[i-1]], keys[index]) == 0 {
+			continue
+		}
+		result = append(result, lines[index])
+	}
+	return result, nil
+}
+
+func uniq(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*uniqOption)
+	lines, err := readLines(rt, f, opts.Files, opts.Args)
+	if err != nil {
+		return nil, err
+	}
+	equal := func(a, b string) bool {
+		if opts.IgnoreCase {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+	result := make([]any, 0)
+	for i := 0; i < len(lines); {
+		j := i + 1
+		for j < len(lines) && equal(lines[i], lines[j]) {
+			j++
+		}
+		if count := j - i; (!opts.Repeated || count > 1) && (!opts.Unique || count == 1) {
+			if opts.Count {
+				result = append(result, []any{int64(count), lines[i]})
+			} else {
+				result = append(result, lines[i])
+			}
+		}
+		i = j
+	}
+	return result, nil
+}
+
+func wc(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*wcOption)
+	texts, err := readInputs(rt, f, opts.Files, opts.Args)
+	if err != nil {
+		return nil, err
+	}
+	var lines, words, bytes int64
+	for _, t := range texts {
+		lines += int64(len(splitText(t)))
+		words += int64(len(strings.Fields(t)))
+		bytes += int64(len(t))
+	}
+	counts := map[any]any{"lines": lines, "words": words, "bytes": bytes}
+	if !opts.Lines && !opts.Words && !opts.Bytes {
+		return counts, nil
+	}
+	for name, ok := range map[string]bool{"lines": opts.Lines, "words": opts.Words, "bytes": opts.Bytes} {
+		if !ok {
+			delete(counts, name)
+		}
+	}
+	if len(counts) == 1 {
+		for _, v := range counts {
+			return v, nil
+		}
+	}
+	return counts, nil
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(grepFlags, grep))
+	registerFunction(NewRuntimeFunction(firstFlags, first))
+	registerFunction(NewRuntimeFunction(tailFlags, tail, "last"))
+	registerFunction(NewRuntimeFunction(sortFlags, sortLines))
+	registerFunction(NewRuntimeFunction(uniqFlags, uniq))
+	registerFunction(NewRuntimeFunction(wcFlags, wc))
+}
@@ -0,0 +1,78 @@
+package function
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type linesCase struct {
+	name   string
+	args   []any
+	result any
+	fail   bool
+}
+
+func TestLineFunctions(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "build.log")
+	require.NoError(t, os.WriteFile(log, []byte("info start\r\nERROR disk\ninfo step\nerror net\ninfo done\n"), 0600))
+	csv := "bob,30\nalice,9\ncarol,100\ndave\n"
+	cases := []*linesCase{
+		{name: "grep", args: []any{"-i", "error", "--file", log}, result: []any{"ERROR disk", "error net"}},
+		{name: "grep", args: []any{"-v", "-n", "info", "--file", log}, result: []any{"2:ERROR disk", "4:error net"}},
+		{name: "grep", args: []any{"-c", "^info", "--file", log, "info again"}, result: int64(4)},
+		{name: "grep", args: []any{"x", []any{"ax", "b", int64(1), "x"}}, result: []any{"ax", "x"}},
+		{name: "grep", args: []any{"no match", "text"}, result: []any{}},
+		{name: "grep", args: []any{"(", "text"}, fail: true},
+		{name: "grep", args: []any{"text"}, fail: true},
+		{name: "grep", args: []any{"foo", "@abc\nfoo"}, result: []any{"foo"}},
+		{name: "grep", args: []any{"-c", "--file", log, "--file", log, "^info"}, result: int64(6)},
+		{name: "grep", args: []any{"--file", filepath.Join(dir, "none.log"), "text"}, fail: true},
+		{name: "first", args: []any{"-n", int64(2), "--file", log}, result: []any{"info start", "ERROR disk"}},
+		{name: "first", args: []any{"a\nb\n"}, result: []any{"a", "b"}},
+		{name: "first", args: []any{}, fail: true},
+		{name: "tail", args: []any{"-n", "2", "--file", log}, result: []any{"error net", "info done"}},
+		{name: "last", args: []any{"-n", "+4", "--file", log}, result: []any{"error net", "info done"}},
+		{name: "tail", args: []any{"-n", "+9", "a"}, result: []any{}},
+		{name: "tail", args: []any{"-n", "x", "a"}, fail: true},
+		{name: "sort", args: []any{csv}, result: []any{"alice,9", "bob,30", "carol,100", "dave"}},
+		{name: "sort", args: []any{"-n", "-r", "-k", int64(2), "-t", ",", csv}, result: []any{"carol,100", "bob,30", "alice,9", "dave"}},
+		{name: "sort", args: []any{"-k", int64(2), "-t", ",", csv}, result: []any{"dave", "carol,100", "bob,30", "alice,9"}},
+		{name: "sort", args: []any{"-u", "-f", "b\nA\na\nB\n"}, result: []any{"A", "b"}},
+		{name: "sort", args: []any{"-k", int64(-1), "b 2\na 1\n"}, fail: true},
+		{name: "uniq", args: []any{"a\na\nb\na\n"}, result: []any{"a", "b", "a"}},
+		{name: "uniq", args: []any{"-c", "a\na\nb\n"}, result: []any{[]any{int64(2), "a"}, []any{int64(1), "b"}}},
+		{name: "uniq", args: []any{"-d", "-i", "a\nA\nb\n"}, result: []any{"a"}},
+		{name: "uniq", args: []any{"-u", "a\na\nb\n"}, result: []any{"b"}},
+		{name: "wc", args: []any{"one two\nthree", "four\n"}, result: map[any]any{"lines": int64(3), "words": int64(4), "bytes": int64(18)}},
+		{name: "wc", args: []any{"-l", "--file", log}, result: int64(5)},
+		{name: "wc", args: []any{"-w", "-c", "a b"}, result: map[any]any{"words": int64(2), "bytes": int64(3)}},
+	}
+	for i, tc := range cases {
+		t.Logf("TestLineFunctions case #%d @%s", i+1, tc.name)
+		fargs := make([]*args.FunctionArg, len(tc.args))
+		for j, a := range tc.args {
+			fargs[j] = &args.FunctionArg{Val: a, Kind: reflect.ValueOf(a).Kind()}
+		}
+		result, err := GetFunction(tc.name).Apply(fargs)
+		if tc.fail {
+			assert.Error(t, err)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, tc.result, result)
+		}
+	}
+	// a reader is consumed and closed
+	rc := io.NopCloser(strings.NewReader("b\na\n"))
+	result, err := GetFunction("sort").Apply([]*args.FunctionArg{{Val: rc, Kind: reflect.Struct}})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"a", "b"}, result)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/cozees/cook/pkg/runtime/glob"
 )
 
 func AllPathFlags() []*args.Flags {
@@ -38,6 +39,7 @@ var pathOptsType = reflect.TypeOf((*pathOptions)(nil)).Elem()
 var pabsFlags = &args.Flags{
 	Result:    pathOptsType,
 	FuncName:  "pabs",
+	Return:    reflect.String,
 	ShortDesc: "convert relative path to an absolute path",
 	Usage:     "@pabs FILEPATH",
 	Example:   "@pabs dir/file.txt",
@@ -49,6 +51,7 @@ var pabsFlags = &args.Flags{
 var pbaseFlags = &args.Flags{
 	Result:    pathOptsType,
 	FuncName:  "pbase",
+	Return:    reflect.String,
 	ShortDesc: "return last element path.",
 	Usage:     "@pbase FILEPATH",
 	Example:   "@pbase dir/file.txt",
@@ -60,6 +63,7 @@ var pbaseFlags = &args.Flags{
 var pextFlags = &args.Flags{
 	Result:    pathOptsType,
 	FuncName:  "pext",
+	Return:    reflect.String,
 	ShortDesc: "return file extension.",
 	Usage:     "@pext FILEPATH",
 	Example:   "@pext dir/file.txt",
@@ -70,6 +74,7 @@ var pextFlags = &args.Flags{
 var pdirFlags = &args.Flags{
 	Result:    pathOptsType,
 	FuncName:  "pdir",
+	Return:    reflect.String,
 	ShortDesc: "return parent directory.",
 	Usage:     "@pdir FILEPATH",
 	Example:   "@pdir dir/file.txt",
@@ -82,6 +87,7 @@ var pdirFlags = &args.Flags{
 var pcleanFlags = &args.Flags{
 	Result:      pathOptsType,
 	FuncName:    "pclean",
+	Return:      reflect.String,
 	ShortDesc:   `return a path without "." or ".."`,
 	Usage:       "@pclean FILEPATH",
 	Example:     "@pclean ./dir/../file.txt",
@@ -91,6 +97,7 @@ var pcleanFlags = &args.Flags{
 var psplitFlags = &args.Flags{
 	Result:      pathOptsType,
 	FuncName:    "psplit",
+	Return:      reflect.Slice,
 	ShortDesc:   `return array string split by path separtor`,
 	Usage:       "@psplit FILEPATH",
 	Example:     "@psplit dir/file.txt",
@@ -100,17 +107,20 @@ var psplitFlags = &args.Flags{
 var pglobFlags = &args.Flags{
 	Result:    pathOptsType,
 	FuncName:  "pglob",
+	Return:    reflect.Slice,
 	ShortDesc: `return array file path that match the pattern`,
 	Usage:     "@pglob GLOB_PATTERN",
 	Example:   "@pglob dir/*.txt",
-	Description: `Returns the names of all files matching pattern or nil if there is no matching file.
-				  The syntax of patterns is the same as in Match. The pattern may describe hierarchical
-				  names such as /usr/*/bin/ed (assuming the Separator is '/').`,
+	Description: `Returns the sorted names of all files matching pattern or nil if there is no matching file.
+				  The syntax of patterns is the same as in Match with addition of "**" which match any number
+				  of directory and alternative such as *.{go,md}. The pattern may describe hierarchical
+				  names such as /usr/*/bin/ed or src/**/*_test.go.`,
 }
 
 var prelFlags = &args.Flags{
 	Result:    pathOptsType,
 	FuncName:  "prel",
+	Return:    reflect.String,
 	ShortDesc: `return path relative`,
 	Usage:     "@prel REFERENCE_PATH TO_PATH",
 	Example:   "@prel dir/a dir/sample/../a/file.txt",
@@ -120,8 +130,8 @@ var prelFlags = &args.Flags{
 }
 
 func init() {
-	registerFunction(NewBaseFunction(pabsFlags, func(f Function, i any) (any, error) {
-		return dHandler(f, i, 1, filepath.Abs)
+	registerFunction(NewRuntimeFunction(pabsFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return dHandler(f, i, 1, func(s string) (string, error) { return filepath.Abs(ResolvePath(rt, s)) })
 	}))
 
 	registerFunction(NewBaseFunction(pbaseFlags, func(f Function, i any) (any, error) {
@@ -146,9 +156,9 @@ func init() {
 		})
 	}))
 
-	registerFunction(NewBaseFunction(pglobFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(pglobFlags, func(rt Runtime, f Function, i any) (any, error) {
 		return validate(f, i.(*pathOptions), 1, func(s ...string) (any, error) {
-			return filepath.Glob(s[0])
+			return glob.GlobIn(rt.WorkingDir(), s[0])
 		})
 	}))
 
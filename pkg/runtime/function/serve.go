@@ -0,0 +1,187 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+)
+
+type serveOption struct {
+	Port       int64       `flag:"port,8000"`
+	Host       string      `flag:"host,localhost"`
+	Route      map[any]any `flag:"route"`
+	Background bool        `flag:"background"`
+	Wait       bool        `flag:"wait"`
+	Args       []string
+}
+
+const (
+	portDesc  = `a port number to listen on, use 0 to let the system choose an available port.`
+	hostDesc  = `a host name or ip address to listen on. Use 0.0.0.0 to accept connection from other machine.`
+	routeDesc = `a route in form of pattern:function where pattern is a path such as /health or method and path
+				 such as "POST /api/items" and function is a name of function declared in Cookfile. The flag also
+				 accept a map of pattern to a function name or a function value, e.g. {"/health": fn (req) => "ok"}.
+				 The function receive a single argument, a map of "method", "path", "query", "headers" and "body"
+				 of the request. The function can return a map of "status", "headers" and "body" or any other value
+				 which is sent as the response body with status 200. The function is executed in the working
+				 directory where @serve is called, one request at a time and only while the Cookfile is waiting
+				 for a built-in function or an external command, e.g. @sleep, @http or @serve --wait.`
+	backgroundDesc = `keep the server running after the current target is completed, the server is stopped
+					  after the finalize target instead.`
+	waitDesc    = `block until the program is interrupted instead of returning immediately.`
+	serveFnDesc = `Start an http server which serve static file from [DIR] and/or the given routes. By default, the server
+				   is running in the background until the current target is completed. The function return the base url
+				   of the server, e.g. http://localhost:8000.`
+)
+
+var serveFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "p", Long: "port", Description: portDesc},
+		{Long: "host", Description: hostDesc},
+		{Short: "r", Long: "route", Description: routeDesc},
+		{Long: "background", Description: backgroundDesc},
+		{Long: "wait", Description: waitDesc},
+	},
+	Result:      reflect.TypeOf((*serveOption)(nil)).Elem(),
+	FuncName:    "serve",
+	Return:      reflect.String,
+	ShortDesc:   "start an http server to serve static file and stub route",
+	Usage:       "@serve [-p port] [--host host] [-r pattern:function ...] [--background] [--wait] [DIR]",
+	Example:     "@serve -p 8080 -r /health:healthHandler dist",
+	Description: serveFnDesc,
+}
+
+func serve(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*serveOption)
+	if len(opts.Args) > 1 {
+		return nil, fmt.Errorf("function %s accept only one directory to serve", f.Name())
+	} else if len(opts.Args) == 0 && len(opts.Route) == 0 {
+		return nil, fmt.Errorf("function %s required a directory or at least one route", f.Name())
+	}
+
+	mux, err := serveMux(rt, opts)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(opts.Host, strconv.FormatInt(opts.Port, 10)))
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+	rt.OnExit(opts.Background, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			return server.Close()
+		}
+		return nil
+	})
+
+	if opts.Wait {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-ctx.Done()
+	}
+	return "http://" + ln.Addr().String(), nil
+}
+
+func serveMux(rt Runtime, opts *serveOption) (mux *http.ServeMux, err error) {
+	// ServeMux panic on invalid or conflict pattern
+	defer func() {
+		if r := recover(); r != nil {
+			mux, err = nil, fmt.Errorf("invalid route: %v", r)
+		}
+	}()
+	mux = http.NewServeMux()
+	// route is called later by the server thus it must keep the working directory as of now
+	drt := rt.Detach()
+	for key, fn := range opts.Route {
+		pattern, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid route: pattern %v is not a string", key)
+		} else if kind := reflect.ValueOf(fn).Kind(); kind != reflect.String && kind != reflect.Func {
+			return nil, fmt.Errorf("invalid route: %v of pattern %s is not a function", fn, pattern)
+		}
+		mux.HandleFunc(pattern, routeHandler(drt, fn))
+	}
+	if len(opts.Args) == 1 && opts.Route["/"] == nil {
+		mux.Handle("/", http.FileServer(http.Dir(ResolvePath(rt, opts.Args[0]))))
+	}
+	return mux, nil
+}
+
+func routeHandler(rt Runtime, fn any) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query := make(map[any]any)
+		for k, vs := range r.URL.Query() {
+			query[k] = vs[0]
+		}
+		headers := make(map[any]any)
+		for k, vs := range r.Header {
+			headers[k] = vs[0]
+		}
+		result, err := rt.Call(fn, map[any]any{
+			"method":  r.Method,
+			"path":    r.URL.Path,
+			"query":   query,
+			"headers": headers,
+			"body":    string(body),
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeRouteResult(rw, result)
+	}
+}
+
+func writeRouteResult(rw http.ResponseWriter, result any) {
+	status, body := http.StatusOK, result
+	if m, ok := result.(map[any]any); ok {
+		if headers, ok := m["headers"].(map[any]any); ok {
+			for k, v := range headers {
+				ks, kerr := toString(k)
+				vs, verr := toString(v)
+				if kerr == nil && verr == nil {
+					rw.Header().Set(ks, vs)
+				}
+			}
+		}
+		if s, ok := m["status"].(int64); ok {
+			status = int(s)
+		}
+		body = m["body"]
+	}
+	rw.WriteHeader(status)
+	switch v := body.(type) {
+	case nil:
+	case io.Reader:
+		io.Copy(rw, v)
+	default:
+		if s, err := toString(v); err == nil {
+			io.WriteString(rw, s)
+		} else {
+			fmt.Fprint(rw, v)
+		}
+	}
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(serveFlags, serve))
+}
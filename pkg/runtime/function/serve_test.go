@@ -0,0 +1,169 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRuntime struct {
+	exits   []func() error
+	globals []func() error
+	fns     map[string]func(args ...any) (any, error)
+	dir     string
+	ctx     context.Context
+}
+
+func (tr *testRuntime) WorkingDir() string { return tr.dir }
+
+func (tr *testRuntime) Detach() Runtime { return tr }
+
+func (tr *testRuntime) Context() context.Context {
+	if tr.ctx == nil {
+		return context.Background()
+	}
+	return tr.ctx
+}
+
+func (tr *testRuntime) OnExit(global bool, fn func() error) {
+	if global {
+		tr.globals = append(tr.globals, fn)
+	} else {
+		tr.exits = append(tr.exits, fn)
+	}
+}
+
+func (tr *testRuntime) Call(fn any, args ...any) (any, error) {
+	if name, ok := fn.(string); ok && tr.fns[name] != nil {
+		return tr.fns[name](args...)
+	} else if f, ok := fn.(func(args ...any) (any, error)); ok {
+		return f(args...)
+	}
+	return nil, fmt.Errorf("function %v is not exist", fn)
+}
+
+func (tr *testRuntime) exit(global bool) {
+	exits := tr.exits
+	if global {
+		exits, tr.globals = tr.globals, nil
+	} else {
+		tr.exits = nil
+	}
+	for i := len(exits) - 1; i >= 0; i-- {
+		exits[i]()
+	}
+}
+
+func TestServe(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>cook</h1>"), 0600))
+	rt := &testRuntime{fns: map[string]func(args ...any) (any, error){
+		"created": func(args ...any) (any, error) {
+			req := args[0].(map[any]any)
+			return map[any]any{
+				"status":  int64(201),
+				"headers": map[any]any{"X-Method": req["method"]},
+				"body":    req["body"],
+			}, nil
+		},
+		"plain": func(args ...any) (any, error) {
+			return args[0].(map[any]any)["query"].(map[any]any)["id"], nil
+		},
+	}}
+	fn := GetFunction("serve").(RuntimeFunction)
+
+	get := func(method, url, body string) (int, http.Header, string) {
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, url, r)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header, string(b)
+	}
+
+	result, err := fn.ApplyWithRuntime(rt, convertToFunctionArgs([]string{
+		"-p", "0", "-r", "POST /items:created", "-r", "/plain:plain", "-r", "/missing:missing", dir,
+	}))
+	require.NoError(t, err)
+	url := result.(string)
+
+	status, _, body := get(http.MethodGet, url+"/", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "<h1>cook</h1>", body)
+	status, header, body := get(http.MethodPost, url+"/items", "payload")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "POST", header.Get("X-Method"))
+	assert.Equal(t, "payload", body)
+	status, _, body = get(http.MethodGet, url+"/plain?id=12", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "12", body)
+	status, _, _ = get(http.MethodGet, url+"/missing", "")
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	// server stop once the target exit
+	rt.exit(false)
+	_, err = http.Get(url + "/")
+	assert.Error(t, err)
+
+	// background server survive the target
+	result, err = fn.ApplyWithRuntime(rt, convertToFunctionArgs([]string{"-p", "0", "--background", dir}))
+	require.NoError(t, err)
+	url = result.(string)
+	rt.exit(false)
+	status, _, _ = get(http.MethodGet, url+"/index.html", "")
+	assert.Equal(t, http.StatusOK, status)
+	rt.exit(true)
+	_, err = http.Get(url + "/")
+	assert.Error(t, err)
+
+	// relative directory is resolved against the working directory
+	wrt := &testRuntime{dir: filepath.Dir(dir)}
+	result, err = fn.ApplyWithRuntime(wrt, convertToFunctionArgs([]string{"-p", "0", filepath.Base(dir)}))
+	require.NoError(t, err)
+	status, _, body = get(http.MethodGet, result.(string)+"/", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "<h1>cook</h1>", body)
+	wrt.exit(false)
+
+	_, err = fn.ApplyWithRuntime(rt, convertToFunctionArgs([]string{"-p", "0"}))
+	assert.Error(t, err)
+	_, err = fn.ApplyWithRuntime(rt, convertToFunctionArgs([]string{"-p", "0", "-r", "bad pattern here:x"}))
+	assert.Error(t, err)
+
+	// route given as a map of pattern to a function name or a function value
+	routes := func(m map[any]any) []*args.FunctionArg {
+		return append(convertToFunctionArgs([]string{"-p", "0", "-r"}), &args.FunctionArg{Val: m, Kind: reflect.Map})
+	}
+	result, err = fn.ApplyWithRuntime(rt, routes(map[any]any{
+		"/plain": "plain",
+		"/value": func(args ...any) (any, error) { return "value", nil },
+	}))
+	require.NoError(t, err)
+	status, _, body = get(http.MethodGet, result.(string)+"/plain?id=7", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "7", body)
+	status, _, body = get(http.MethodGet, result.(string)+"/value", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "value", body)
+	rt.exit(false)
+	_, err = fn.ApplyWithRuntime(rt, routes(map[any]any{"/bad": int64(1)}))
+	assert.Error(t, err)
+	_, err = fn.ApplyWithRuntime(rt, routes(map[any]any{int64(1): "plain"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+)
+
+type sleepOption struct {
+	Args []any
+}
+
+const sleepDesc = `Pause the execution for the given duration. The duration is either a duration literal such as 500ms,
+				   2s or 1h30m, which can also be given as a string, or a number of seconds. The function return early with
+				   an error if the execution is cancelled.`
+
+var sleepFlags = &args.Flags{
+	Result:      reflect.TypeOf((*sleepOption)(nil)).Elem(),
+	FuncName:    "sleep",
+	ShortDesc:   "pause the execution for a duration",
+	Usage:       "@sleep DURATION",
+	Example:     "@sleep 1.5s",
+	Description: sleepDesc,
+}
+
+// ParseDuration return the duration given by v which is either a duration string such as 1m30s or
+// a number of seconds.
+func ParseDuration(v any) (d time.Duration, err error) {
+	switch dv := v.(type) {
+	case int64:
+		d = time.Duration(dv) * time.Second
+	case float64:
+		d = time.Duration(dv * float64(time.Second))
+	case string:
+		if f, ferr := strconv.ParseFloat(dv, 64); ferr == nil {
+			d = time.Duration(f * float64(time.Second))
+		} else if d, err = time.ParseDuration(dv); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("%v is not a duration", v)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration %v must not be negative", v)
+	}
+	return d, nil
+}
+
+// Sleep pause the current goroutine for duration d or until ctx is cancelled.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(sleepFlags, func(rt Runtime, f Function, i any) (any, error) {
+		opts := i.(*sleepOption)
+		if len(opts.Args) != 1 {
+			return nil, fmt.Errorf("%s require exactly one duration argument", f.Name())
+		}
+		d, err := ParseDuration(opts.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return nil, Sleep(rt.Context(), d)
+	}))
+}
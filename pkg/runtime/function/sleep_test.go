@@ -0,0 +1,52 @@
+package function
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	for i, tc := range []struct {
+		in  any
+		out time.Duration
+	}{
+		{int64(2), 2 * time.Second},
+		{1.5, 1500 * time.Millisecond},
+		{"250ms", 250 * time.Millisecond},
+		{"1m30s", 90 * time.Second},
+		{"3", 3 * time.Second},
+	} {
+		t.Logf("TestParseDuration case #%d", i+1)
+		d, err := ParseDuration(tc.in)
+		require.NoError(t, err)
+		assert.Equal(t, tc.out, d)
+	}
+	for _, in := range []any{"soon", int64(-1), true} {
+		_, err := ParseDuration(in)
+		assert.Error(t, err)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	fn := GetFunction("sleep").(RuntimeFunction)
+	start := time.Now()
+	_, err := fn.ApplyWithRuntime(&testRuntime{}, []*args.FunctionArg{{Val: "20ms", Kind: reflect.String}})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start = time.Now()
+	_, err = fn.ApplyWithRuntime(&testRuntime{ctx: ctx}, []*args.FunctionArg{{Val: int64(10), Kind: reflect.Int64}})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	_, err = fn.ApplyWithRuntime(&testRuntime{}, nil)
+	assert.Error(t, err)
+}
@@ -442,7 +442,7 @@ func init() {
 		}
 	}))
 
-	registerFunction(NewBaseFunction(sreplaceFlags, func(f Function, i any) (any, error) {
+	registerFunction(NewRuntimeFunction(sreplaceFlags, func(rt Runtime, f Function, i any) (any, error) {
 		opts := i.(*sReplaceOption)
 		numArgs := len(opts.Args)
 		if numArgs != 3 && numArgs != 4 {
@@ -486,13 +486,13 @@ func init() {
 		// a file input
 		if strings.HasPrefix(sargs[2], "@") {
 			// result is alway written to a newfile
-			var file = sargs[2][1:]
+			var file = ResolvePath(rt, sargs[2][1:])
 			var fileBC string
 			if inPlace {
-				fileBC = filepath.Join(os.TempDir(), ".cook-replace."+file)
+				fileBC = filepath.Join(os.TempDir(), ".cook-replace."+filepath.Base(file))
 				defer os.Remove(fileBC)
 			} else {
-				fileBC = sargs[3][1:]
+				fileBC = ResolvePath(rt, sargs[3][1:])
 			}
 			// open file read & fileBC write
 			f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0700)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -335,3 +336,22 @@ func TestReplace(t *testing.T) {
 		}
 	}
 }
+
+func TestReplaceWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "doc"), 0700))
+	f := filepath.Join(dir, "doc", "a.txt")
+	require.NoError(t, os.WriteFile(f, []byte("hello world\n"), 0600))
+	rt := &testRuntime{dir: dir}
+	fn := GetFunction("sreplace").(RuntimeFunction)
+	_, err := fn.ApplyWithRuntime(rt, convertToFunctionArgs([]string{"world", "cook", "@doc/a.txt"}))
+	require.NoError(t, err)
+	b, err := os.ReadFile(f)
+	require.NoError(t, err)
+	assert.Equal(t, "hello cook\n", string(b))
+	_, err = fn.ApplyWithRuntime(rt, convertToFunctionArgs([]string{"cook", "go", "@doc/a.txt", "@doc/b.txt"}))
+	require.NoError(t, err)
+	b, err = os.ReadFile(filepath.Join(dir, "doc", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello go\n", string(b))
+}
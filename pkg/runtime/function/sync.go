@@ -0,0 +1,260 @@
+package function
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/cozees/cook/pkg/runtime/glob"
+)
+
+type syncOption struct {
+	Checksum bool     `flag:"checksum"`
+	Delete   bool     `flag:"delete"`
+	Exclude  []string `flag:"exclude"`
+	DryRun   bool     `flag:"dry-run"`
+	Args     []string
+
+	copied, deleted, skipped int64
+}
+
+const (
+	syncChecksumDesc = `compare file content using sha256 checksum instead of size and modification time.`
+	syncDeleteDesc   = `delete file or directory in DST which does not exist in SRC.`
+	syncExcludeDesc  = `a glob pattern, using the same syntax as @find flag name, of the entry to be skipped. Excluded entry is neither
+						copied nor deleted. The flag can be given multiple time.`
+	syncDryRunDesc = `report what would be done without modifying DST.`
+	syncDesc       = `Mirror the content of directory SRC into directory DST. A file is copied only if it does not exist in DST or it
+					  has a different size or modification time, or a different checksum when --checksum is given. Copied file keep
+					  the permission and modification time of the source file. DST is created if it does not exist and it must
+					  not be SRC itself or a directory inside SRC. A symbolic link is copied as a link, the file or directory it
+					  point to is not copied.
+					  The function return a map with key copied, deleted and skipped holding the number of file or directory in
+					  each category.`
+)
+
+var syncFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "c", Long: "checksum", Description: syncChecksumDesc},
+		{Long: "delete", Description: syncDeleteDesc},
+		{Short: "e", Long: "exclude", Description: syncExcludeDesc},
+		{Short: "n", Long: "dry-run", Description: syncDryRunDesc},
+	},
+	Result:      reflect.TypeOf((*syncOption)(nil)).Elem(),
+	FuncName:    "sync",
+	Return:      reflect.Map,
+	ShortDesc:   "mirror a directory into another directory",
+	Usage:       "@sync [-c] [--delete] [-e pattern] [-n] SRC DST",
+	Example:     "@sync --delete -e '*.tmp' -e .git build /mnt/staging/app",
+	Description: syncDesc,
+}
+
+func (so *syncOption) validate(f Function) error {
+	if len(so.Args) != 2 {
+		return fmt.Errorf("function %s required SRC and DST directory", f.Name())
+	}
+	for _, p := range so.Exclude {
+		if _, err := glob.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", p, err)
+		}
+	}
+	if stat, err := os.Stat(so.Args[0]); err != nil {
+		return err
+	} else if !stat.IsDir() {
+		return fmt.Errorf("source %s is not a directory", so.Args[0])
+	}
+	if stat, err := os.Stat(so.Args[1]); err == nil && !stat.IsDir() {
+		return fmt.Errorf("destination %s is not a directory", so.Args[1])
+	}
+	src, err := realPath(so.Args[0])
+	if err != nil {
+		return err
+	}
+	dst, err := realPath(so.Args[1])
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(src, dst); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("destination %s is inside source %s", so.Args[1], so.Args[0])
+	}
+	return nil
+}
+
+// realPath return absolute path of p with symbolic link resolved in the part of p that exist.
+func realPath(p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	if rp, err := filepath.EvalSymlinks(p); err == nil {
+		return rp, nil
+	} else if dir := filepath.Dir(p); dir != p {
+		if dir, err = realPath(dir); err == nil {
+			return filepath.Join(dir, filepath.Base(p)), nil
+		}
+	}
+	return p, nil
+}
+
+// unchanged report whether file b has the same content as file a.
+func (so *syncOption) unchanged(a, b string, sa, sb os.FileInfo) (bool, error) {
+	if sa.Size() != sb.Size() {
+		return false, nil
+	} else if !so.Checksum {
+		return sa.ModTime().Equal(sb.ModTime()), nil
+	}
+	ha, err := fileSHA256(a)
+	if err != nil {
+		return false, err
+	}
+	hb, err := fileSHA256(b)
+	if err != nil {
+		return false, err
+	}
+	return ha == hb, nil
+}
+
+func (so *syncOption) copy(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if path != src && matchAny(so.Exclude, d.Name(), filepath.ToSlash(rel)) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		sa, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		sb, err := os.Lstat(target)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		// an entry of different kind in DST is always replaced
+		if sb != nil && sa.Mode().Type() != sb.Mode().Type() {
+			so.deleted++
+			if !so.DryRun {
+				if err = os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+			sb = nil
+		}
+		if sa.Mode()&fs.ModeSymlink != 0 {
+			return so.copyLink(path, target, sb)
+		} else if sa.IsDir() {
+			if sb == nil && !so.DryRun {
+				return os.MkdirAll(target, sa.Mode().Perm())
+			}
+			return nil
+		}
+		if sb != nil {
+			if ok, err := so.unchanged(path, target, sa, sb); err != nil {
+				return err
+			} else if ok {
+				so.skipped++
+				return nil
+			}
+		}
+		so.copied++
+		if so.DryRun {
+			return nil
+		}
+		if err = copyFile(path, target, false); err != nil {
+			return err
+		} else if err = os.Chmod(target, sa.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(target, sa.ModTime(), sa.ModTime())
+	})
+}
+
+// copyLink copy the symbolic link itself rather than the file or directory it point to.
+func (so *syncOption) copyLink(path, target string, sb os.FileInfo) error {
+	link, err := os.Readlink(path)
+	if err != nil {
+		return err
+	}
+	if sb != nil {
+		if cur, err := os.Readlink(target); err == nil && cur == link {
+			so.skipped++
+			return nil
+		}
+	}
+	so.copied++
+	if so.DryRun {
+		return nil
+	}
+	if sb != nil {
+		if err = os.Remove(target); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(link, target)
+}
+
+func (so *syncOption) delete(src, dst string) error {
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if path == dst {
+			return nil
+		}
+		rel, _ := filepath.Rel(dst, path)
+		if matchAny(so.Exclude, d.Name(), filepath.ToSlash(rel)) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if _, err = os.Lstat(filepath.Join(src, rel)); err == nil {
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		so.deleted++
+		if !so.DryRun {
+			if err = os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+}
+
+func syncDir(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*syncOption)
+	opts.Args = resolvePaths(rt, opts.Args)
+	if err := opts.validate(f); err != nil {
+		return nil, err
+	}
+	src, dst := opts.Args[0], opts.Args[1]
+	if err := opts.copy(src, dst); err != nil {
+		return nil, err
+	}
+	if opts.Delete {
+		if err := opts.delete(src, dst); err != nil {
+			return nil, err
+		}
+	}
+	return map[any]any{"copied": opts.copied, "deleted": opts.deleted, "skipped": opts.skipped}, nil
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(syncFlags, syncDir))
+}
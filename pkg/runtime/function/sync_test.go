@@ -0,0 +1,108 @@
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "dst")
+	write := func(dir, name, content string) {
+		f := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0700))
+		require.NoError(t, os.WriteFile(f, []byte(content), 0640))
+	}
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		return string(b)
+	}
+	summary := func(copied, deleted, skipped int64) map[any]any {
+		return map[any]any{"copied": copied, "deleted": deleted, "skipped": skipped}
+	}
+	sync := func(flags ...string) any {
+		result, err := GetFunction("sync").Apply(convertToFunctionArgs(append(flags, src, dst)))
+		require.NoError(t, err)
+		return result
+	}
+	write(src, "a.txt", "content a")
+	write(src, "b/c.txt", "content c")
+	write(src, "b/d.tmp", "temporary")
+	write(src, "e/f.txt", "content f")
+
+	assert.Equal(t, summary(3, 0, 0), sync("-e", "*.tmp"))
+	assert.Equal(t, "content a", read("a.txt"))
+	assert.Equal(t, "content c", read("b/c.txt"))
+	assert.NoFileExists(t, filepath.Join(dst, "b", "d.tmp"))
+	// nothing change
+	assert.Equal(t, summary(0, 0, 3), sync("-e", "*.tmp"))
+	// modify a file with the same size but different time
+	write(src, "a.txt", "updated a")
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "a.txt"), old, old))
+	// shorter content must not leave trailing data
+	write(src, "b/c.txt", "c")
+	write(dst, "extra/g.txt", "extra")
+	write(dst, "h.tmp", "kept")
+	assert.Equal(t, summary(1, 0, 1), sync("-n", "-e", "*.tmp", "-e", "b"))
+	assert.Equal(t, summary(2, 1, 1), sync("-n", "--delete", "-e", "*.tmp"))
+	assert.Equal(t, "content a", read("a.txt"))
+	assert.DirExists(t, filepath.Join(dst, "extra"))
+
+	assert.Equal(t, summary(2, 1, 1), sync("--delete", "-e", "*.tmp"))
+	assert.Equal(t, "updated a", read("a.txt"))
+	assert.Equal(t, "c", read("b/c.txt"))
+	assert.NoDirExists(t, filepath.Join(dst, "extra"))
+	assert.FileExists(t, filepath.Join(dst, "h.tmp"))
+	stat, err := os.Stat(filepath.Join(dst, "a.txt"))
+	require.NoError(t, err)
+	assert.True(t, old.Equal(stat.ModTime()))
+
+	// checksum ignore modification time
+	now := time.Now()
+	require.NoError(t, os.Chtimes(filepath.Join(dst, "a.txt"), now, now))
+	assert.Equal(t, summary(0, 0, 3), sync("-c", "-e", "*.tmp"))
+	// entry of different kind is replaced
+	require.NoError(t, os.RemoveAll(filepath.Join(src, "e")))
+	write(src, "e", "now a file")
+	assert.Equal(t, summary(1, 1, 2), sync("-c", "-e", "*.tmp"))
+	assert.Equal(t, "now a file", read("e"))
+
+	// symbolic link is copied as a link
+	require.NoError(t, os.Symlink("b", filepath.Join(src, "l")))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(src, "m")))
+	assert.Equal(t, summary(2, 0, 3), sync("-c", "-e", "*.tmp"))
+	for name, link := range map[string]string{"l": "b", "m": "a.txt"} {
+		target, err := os.Readlink(filepath.Join(dst, name))
+		require.NoError(t, err)
+		assert.Equal(t, link, target)
+	}
+	assert.Equal(t, summary(0, 0, 5), sync("-c", "-e", "*.tmp"))
+	require.NoError(t, os.Remove(filepath.Join(src, "l")))
+	require.NoError(t, os.Symlink("e", filepath.Join(src, "l")))
+	assert.Equal(t, summary(1, 0, 4), sync("-c", "-e", "*.tmp"))
+	target, err := os.Readlink(filepath.Join(dst, "l"))
+	require.NoError(t, err)
+	assert.Equal(t, "e", target)
+
+	// destination must not be the source or inside it
+	for _, d := range []string{src, filepath.Join(src, "backup"), filepath.Join(src, "b", "backup")} {
+		_, err = GetFunction("sync").Apply(convertToFunctionArgs([]string{src, d}))
+		assert.Error(t, err)
+		assert.NoDirExists(t, filepath.Join(src, "backup"))
+	}
+	_, err = GetFunction("sync").Apply(convertToFunctionArgs([]string{src, filepath.Join(src, "l", "backup")}))
+	assert.Error(t, err)
+
+	_, err = GetFunction("sync").Apply(convertToFunctionArgs([]string{src}))
+	assert.Error(t, err)
+	_, err = GetFunction("sync").Apply(convertToFunctionArgs([]string{filepath.Join(src, "a.txt"), dst}))
+	assert.Error(t, err)
+	_, err = GetFunction("sync").Apply(convertToFunctionArgs([]string{src, filepath.Join(dst, "a.txt")}))
+	assert.Error(t, err)
+}
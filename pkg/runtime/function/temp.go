@@ -0,0 +1,100 @@
+package function
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+)
+
+type tempOption struct {
+	Prefix string `flag:"prefix,cook"`
+	Suffix string `flag:"suffix"`
+	Dir    string `flag:"dir"`
+	Global bool   `flag:"global"`
+	Args   []string
+}
+
+const (
+	tempPrefixDesc = `a prefix of the generated name.`
+	tempSuffixDesc = `a suffix of the generated name such as a file extension .json.`
+	tempDirDesc    = `a directory to create the temporary file or directory in, by default the system temporary directory is used.`
+	tempGlobalDesc = `keep the temporary file or directory until the end of the run rather than the end of the current target.`
+	tempCleanDesc  = `The path is removed automatically when the target calling the function is completed, including when the
+					  target fail, or at the end of the run if the function is called outside of a target.`
+	tempDirFnDesc  = `Create a new empty temporary directory and return its path. ` + tempCleanDesc
+	tempFileFnDesc = `Create a new empty temporary file and return its path. ` + tempCleanDesc
+)
+
+var tempFlags = []*args.Flag{
+	{Long: "prefix", Description: tempPrefixDesc},
+	{Long: "dir", Description: tempDirDesc},
+	{Short: "g", Long: "global", Description: tempGlobalDesc},
+}
+
+var tempOptionType = reflect.TypeOf((*tempOption)(nil)).Elem()
+
+var tempdirFlags = &args.Flags{
+	Flags:       tempFlags,
+	Result:      tempOptionType,
+	FuncName:    "tempdir",
+	Return:      reflect.String,
+	ShortDesc:   "create a temporary directory",
+	Usage:       "@tempdir [--prefix PREFIX] [--dir DIR] [-g]",
+	Example:     "@tempdir --prefix build",
+	Description: tempDirFnDesc,
+}
+
+var tempfileFlags = &args.Flags{
+	Flags:       append([]*args.Flag{{Long: "suffix", Description: tempSuffixDesc}}, tempFlags...),
+	Result:      tempOptionType,
+	FuncName:    "tempfile",
+	Return:      reflect.String,
+	ShortDesc:   "create a temporary file",
+	Usage:       "@tempfile [--suffix SUFFIX] [--prefix PREFIX] [--dir DIR] [-g]",
+	Example:     "@tempfile --suffix .json",
+	Description: tempFileFnDesc,
+}
+
+func createTemp(dir bool, rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*tempOption)
+	if len(opts.Args) > 0 {
+		return nil, fmt.Errorf("function %s does not accept any argument", f.Name())
+	} else if strings.ContainsAny(opts.Prefix+opts.Suffix, `/\`) {
+		return nil, fmt.Errorf("prefix and suffix must not contain path separator")
+	}
+	if opts.Dir != "" {
+		opts.Dir = ResolvePath(rt, opts.Dir)
+	}
+	var path string
+	if dir {
+		d, err := os.MkdirTemp(opts.Dir, opts.Prefix+"*"+opts.Suffix)
+		if err != nil {
+			return nil, err
+		}
+		path = d
+	} else {
+		fd, err := os.CreateTemp(opts.Dir, opts.Prefix+"*"+opts.Suffix)
+		if err != nil {
+			return nil, err
+		}
+		path = fd.Name()
+		if err = fd.Close(); err != nil {
+			os.Remove(path)
+			return nil, err
+		}
+	}
+	rt.OnExit(opts.Global, func() error { return os.RemoveAll(path) })
+	return path, nil
+}
+
+func init() {
+	registerFunction(NewRuntimeFunction(tempdirFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return createTemp(true, rt, f, i)
+	}))
+	registerFunction(NewRuntimeFunction(tempfileFlags, func(rt Runtime, f Function, i any) (any, error) {
+		return createTemp(false, rt, f, i)
+	}))
+}
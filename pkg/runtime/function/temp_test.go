@@ -0,0 +1,48 @@
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemp(t *testing.T) {
+	dir := t.TempDir()
+	rt := &testRuntime{}
+	apply := func(name string, flags ...string) (string, error) {
+		fn := GetFunction(name).(RuntimeFunction)
+		v, err := fn.ApplyWithRuntime(rt, convertToFunctionArgs(append(flags, "--dir", dir)))
+		if err != nil {
+			return "", err
+		}
+		return v.(string), nil
+	}
+	d, err := apply("tempdir", "--prefix", "build")
+	require.NoError(t, err)
+	assert.DirExists(t, d)
+	assert.True(t, strings.HasPrefix(filepath.Base(d), "build"))
+	f, err := apply("tempfile", "--suffix", ".json")
+	require.NoError(t, err)
+	assert.FileExists(t, f)
+	assert.Equal(t, ".json", filepath.Ext(f))
+	assert.True(t, strings.HasPrefix(filepath.Base(f), "cook"))
+	g, err := apply("tempfile", "-g")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(d, "a.txt"), nil, 0600))
+
+	rt.exit(false)
+	assert.NoDirExists(t, d)
+	assert.NoFileExists(t, f)
+	assert.FileExists(t, g)
+	rt.exit(true)
+	assert.NoFileExists(t, g)
+
+	_, err = apply("tempfile", "--suffix", "a/b")
+	assert.Error(t, err)
+	_, err = apply("tempdir", "extra")
+	assert.Error(t, err)
+}
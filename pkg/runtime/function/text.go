@@ -0,0 +1,157 @@
+package function
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/cozees/cook/pkg/runtime/args"
+	"github.com/cozees/cook/pkg/runtime/diff"
+)
+
+func AllTextFlags() []*args.Flags {
+	return []*args.Flags{diffFlags, applyPatchFlags, grepFlags, firstFlags, tailFlags, sortFlags, uniqFlags, wcFlags}
+}
+
+type diffOption struct {
+	Quiet   bool  `flag:"quiet"`
+	Text    bool  `flag:"text"`
+	Unified int64 `flag:"unified,3"`
+	Args    []string
+}
+
+type applyPatchOption struct {
+	Fuzz int64 `flag:"fuzz,2"`
+	Text bool  `flag:"text"`
+	Args []string
+}
+
+const (
+	diffQuietDesc   = `return a boolean true if A and B are different instead of the diff.`
+	diffTextDesc    = `treat A and B as the text to be compared rather than file path.`
+	diffUnifiedDesc = `number of unchanged line to show around each change, default is 3.`
+	diffDesc        = `Compare file A and B line by line and return the difference in unified diff format, an empty string is
+					   returned if both are identical. When executed directly from command line, cook exit with status 1 if A
+					   and B are different.`
+	applyPatchFuzzDesc = `maximum number of leading and trailing context line which can be ignored when the context of a hunk does
+					 not match, default is 2.`
+	applyPatchTextDesc = `treat FILE and PATCH as text rather than file path, the patched text is returned instead of writing to FILE.`
+	applyPatchDesc     = `Apply the unified diff PATCH, such as one produced by @diff, to FILE. A hunk is searched near
+						  the line number given in the hunk header thus the patch still apply if lines were added or removed
+						  elsewhere. If any hunk cannot be applied the function fail with the list of failed hunks and FILE
+						  is left untouched. Note that @patch is the http function.`
+)
+
+var diffFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "q", Long: "quiet", Description: diffQuietDesc},
+		{Short: "t", Long: "text", Description: diffTextDesc},
+		{Short: "U", Long: "unified", Description: diffUnifiedDesc},
+	},
+	Result:      reflect.TypeOf((*diffOption)(nil)).Elem(),
+	FuncName:    "diff",
+	TextArgs:    true,
+	ShortDesc:   "compare two files or text line by line",
+	Usage:       "@diff [-q] [-t] [-U N] A B",
+	Example:     "@diff -q generated.go api.go",
+	Description: diffDesc,
+}
+
+var applyPatchFlags = &args.Flags{
+	Flags: []*args.Flag{
+		{Short: "F", Long: "fuzz", Description: applyPatchFuzzDesc},
+		{Short: "t", Long: "text", Description: applyPatchTextDesc},
+	},
+	Result:      reflect.TypeOf((*applyPatchOption)(nil)).Elem(),
+	FuncName:    "applypatch",
+	TextArgs:    true,
+	ShortDesc:   "apply a unified diff to a file or text",
+	Usage:       "@applypatch [-F N] [-t] FILE PATCH",
+	Example:     "@applypatch vendor/lib/client.go patches/client.patch",
+	Description: applyPatchDesc,
+}
+
+// readTexts return the content of the given paths or the paths themselves if text is true.
+func readTexts(rt Runtime, text bool, paths ...string) ([]string, error) {
+	if text {
+		return paths, nil
+	}
+	texts := make([]string, len(paths))
+	for i, p := range paths {
+		b, err := os.ReadFile(ResolvePath(rt, p))
+		if err != nil {
+			return nil, err
+		}
+		texts[i] = string(b)
+	}
+	return texts, nil
+}
+
+func diffText(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*diffOption)
+	if len(opts.Args) != 2 {
+		return nil, fmt.Errorf("function %s required A and B", f.Name())
+	} else if opts.Unified < 0 {
+		return nil, fmt.Errorf("number of context line must not be negative")
+	}
+	texts, err := readTexts(rt, opts.Text, opts.Args...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Quiet {
+		return texts[0] != texts[1], nil
+	}
+	a, b := "a", "b"
+	if !opts.Text {
+		a, b = opts.Args[0], opts.Args[1]
+	}
+	return diff.Unified(a, b, texts[0], texts[1], int(opts.Unified)), nil
+}
+
+func applyPatch(rt Runtime, f Function, i any) (any, error) {
+	opts := i.(*applyPatchOption)
+	if len(opts.Args) != 2 {
+		return nil, fmt.Errorf("function %s required FILE and PATCH", f.Name())
+	} else if opts.Fuzz < 0 {
+		return nil, fmt.Errorf("fuzz must not be negative")
+	}
+	texts, err := readTexts(rt, opts.Text, opts.Args...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := diff.Apply(texts[0], texts[1], int(opts.Fuzz))
+	if err != nil {
+		return nil, err
+	} else if opts.Text {
+		return result, nil
+	}
+	af, err := CreateAtomicFile(ResolvePath(rt, opts.Args[0]), false, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer af.Discard()
+	if _, err = af.WriteString(result); err != nil {
+		return nil, err
+	}
+	return nil, af.Commit()
+}
+
+func init() {
+	df := NewRuntimeFunction(diffFlags, diffText)
+	// exit with status 1 like diff command if there is a difference
+	df.status = func(result any) int {
+		switch v := result.(type) {
+		case bool:
+			if v {
+				return 1
+			}
+		case string:
+			if v != "" {
+				return 1
+			}
+		}
+		return 0
+	}
+	registerFunction(df)
+	registerFunction(NewRuntimeFunction(applyPatchFlags, applyPatch))
+}
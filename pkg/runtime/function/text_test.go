@@ -0,0 +1,53 @@
+package function
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAndApplyPatch(t *testing.T) {
+	dir := t.TempDir()
+	a, b, p := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), filepath.Join(dir, "a.patch")
+	require.NoError(t, os.WriteFile(a, []byte("1\n2\n3\n"), 0600))
+	require.NoError(t, os.WriteFile(b, []byte("1\ntwo\n3\n"), 0600))
+	apply := func(name string, params ...string) (any, error) {
+		return GetFunction(name).Apply(convertToFunctionArgs(params))
+	}
+
+	fn := GetFunction("diff").(StatusFunction)
+	result, err := apply("diff", a, b)
+	require.NoError(t, err)
+	assert.Equal(t, "--- "+a+"\n+++ "+b+"\n@@ -1,3 +1,3 @@\n 1\n-2\n+two\n 3\n", result)
+	assert.Equal(t, 1, fn.ExitStatus(result))
+	result, err = apply("diff", "-q", a, a)
+	require.NoError(t, err)
+	assert.Equal(t, false, result)
+	assert.Equal(t, 0, fn.ExitStatus(result))
+	result, err = apply("diff", "-t", "-U", "0", "x\ny\n", "x\nz\n")
+	require.NoError(t, err)
+	assert.Equal(t, "--- a\n+++ b\n@@ -2 +2 @@\n-y\n+z\n", result)
+	_, err = apply("diff", a)
+	assert.Error(t, err)
+
+	patch, err := apply("diff", a, b)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(p, []byte(patch.(string)), 0600))
+	result, err = apply("applypatch", "-t", "0\n1\n2\n3\n", patch.(string))
+	require.NoError(t, err)
+	assert.Equal(t, "0\n1\ntwo\n3\n", result)
+	_, err = apply("applypatch", a, p)
+	require.NoError(t, err)
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "1\ntwo\n3\n", string(content))
+	// the patch no longer apply, the file is left untouched
+	_, err = apply("applypatch", "-F", "0", a, p)
+	assert.Error(t, err)
+	content, err = os.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "1\ntwo\n3\n", string(content))
+}
@@ -0,0 +1,210 @@
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// doublestar segment match zero or more directory
+const doublestar = "**"
+
+var ErrBadPattern = errors.New("syntax error in pattern")
+
+// HasMeta report whether pattern contain any of the special character recognized by Match.
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[{\`)
+}
+
+// Expand expand brace alternative in the pattern, e.g. "a/{b,c}/*.{go,md}" result in
+// "a/b/*.go", "a/b/*.md", "a/c/*.go" and "a/c/*.md". Nested brace is supported.
+func Expand(pattern string) ([]string, error) {
+	lb, rb, depth := -1, -1, 0
+	commas := []int{}
+	for i := 0; i < len(pattern) && rb == -1; i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				lb = i
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			// unbalanced closing brace is treated as a literal character
+			if depth == 0 {
+				continue
+			}
+			if depth--; depth == 0 {
+				rb = i
+			}
+		}
+	}
+	if depth > 0 {
+		return nil, ErrBadPattern
+	} else if lb == -1 {
+		return []string{pattern}, nil
+	}
+
+	prefix, suffix := pattern[:lb], pattern[rb+1:]
+	result := []string{}
+	start := lb + 1
+	for _, end := range append(commas, rb) {
+		sub, err := Expand(prefix + pattern[start:end] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sub...)
+		start = end + 1
+	}
+	return result, nil
+}
+
+// Match report whether name match the pattern. Beside the syntax supported by path.Match,
+// the pattern can contain "**" segment which match zero or more directory and brace
+// alternative such as "*.{go,md}". Both pattern and name use forward slash as separator.
+func Match(pattern, name string) (bool, error) {
+	patterns, err := Expand(pattern)
+	if err != nil {
+		return false, err
+	}
+	nsegs := strings.Split(name, "/")
+	for _, p := range patterns {
+		if ok, err := matchSegments(strings.Split(p, "/"), nsegs); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchSegments(psegs, nsegs []string) (bool, error) {
+	for len(psegs) > 0 {
+		if psegs[0] == doublestar {
+			// consecutive doublestar is the same as a single one
+			for len(psegs) > 1 && psegs[1] == doublestar {
+				psegs = psegs[1:]
+			}
+			for i := 0; i <= len(nsegs); i++ {
+				if ok, err := matchSegments(psegs[1:], nsegs[i:]); err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(nsegs) == 0 {
+			return false, nil
+		}
+		if ok, err := path.Match(psegs[0], nsegs[0]); err != nil || !ok {
+			return false, err
+		}
+		psegs, nsegs = psegs[1:], nsegs[1:]
+	}
+	return len(nsegs) == 0, nil
+}
+
+// Glob return a sorted list of file path matching the pattern or nil if there is no matching
+// file. The pattern syntax is the same as Match, the result path use os specific separator.
+func Glob(pattern string) ([]string, error) {
+	patterns, err := Expand(filepath.ToSlash(pattern))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, p := range patterns {
+		if err = glob(p, found); err != nil {
+			return nil, err
+		}
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	result := make([]string, 0, len(found))
+	for p := range found {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// GlobIn is the same as Glob except a relative pattern is matched against dir rather than the
+// working directory, the result path is relative to dir as well.
+func GlobIn(dir, pattern string) ([]string, error) {
+	if dir == "" || filepath.IsAbs(pattern) {
+		return Glob(pattern)
+	}
+	matches, err := Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matches {
+		if matches[i], err = filepath.Rel(dir, m); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+func glob(pattern string, found map[string]bool) error {
+	if !HasMeta(pattern) {
+		if _, err := os.Lstat(filepath.FromSlash(pattern)); err == nil {
+			found[filepath.FromSlash(pattern)] = true
+		}
+		return nil
+	}
+	// split the pattern into a static directory to walk from and the rest of the pattern
+	segs := strings.Split(pattern, "/")
+	i := 0
+	for ; i < len(segs)-1 && !HasMeta(segs[i]); i++ {
+	}
+	root, rest := strings.Join(segs[:i], "/"), segs[i:]
+	switch {
+	case root == "" && i > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	maxDepth := len(rest)
+	for _, seg := range rest {
+		if seg == doublestar {
+			maxDepth = -1
+			break
+		}
+	}
+	// validate the pattern up front since walk ignore match error
+	for _, seg := range rest {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+
+	froot := filepath.FromSlash(root)
+	return filepath.WalkDir(froot, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// unreadable file or directory is skipped
+			if d != nil && d.IsDir() && fpath != froot {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if fpath == froot {
+			return nil
+		}
+		rel, _ := filepath.Rel(froot, fpath)
+		nsegs := strings.Split(filepath.ToSlash(rel), "/")
+		if ok, _ := matchSegments(rest, nsegs); ok {
+			found[fpath] = true
+		}
+		if d.IsDir() && maxDepth >= 0 && len(nsegs) >= maxDepth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+}
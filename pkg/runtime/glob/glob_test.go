@@ -0,0 +1,96 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	cases := []struct {
+		in  string
+		out []string
+		err bool
+	}{
+		{in: "a/*.go", out: []string{"a/*.go"}},
+		{in: "a/{b,c}", out: []string{"a/b", "a/c"}},
+		{in: "{a,b}/*.{go,md}", out: []string{"a/*.go", "a/*.md", "b/*.go", "b/*.md"}},
+		{in: "a{b,c{d,e}}f", out: []string{"abf", "acdf", "acef"}},
+		{in: `a\{b,c}`, out: []string{`a\{b,c}`}},
+		{in: "a{b,c", err: true},
+		{in: "a}b", out: []string{"a}b"}},
+	}
+	for i, tc := range cases {
+		t.Logf("TestExpand case #%d", i+1)
+		out, err := Expand(tc.in)
+		if tc.err {
+			assert.Error(t, err)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, out)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.go", "a.go", true},
+		{"*.go", "a/b.go", false},
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"a/**/test/*.txt", "a/test/x.txt", true},
+		{"a/**/test/*.txt", "a/b/c/test/x.txt", true},
+		{"a/**/test/*.txt", "a/b/c/test/d/x.txt", false},
+		{"a/**", "a/b/c", true},
+		{"a/**/**/b", "a/b", true},
+		{"*.{go,md}", "README.md", true},
+		{"*.{go,md}", "README.txt", false},
+	}
+	for i, tc := range cases {
+		t.Logf("TestMatch case #%d", i+1)
+		ok, err := Match(tc.pattern, tc.name)
+		require.NoError(t, err)
+		assert.Equal(t, tc.match, ok)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.txt", "b.md", "x/a.txt", "x/y/test/b.txt", "x/y/test/c.md", "z/test/d.txt"} {
+		f = filepath.Join(dir, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), 0700))
+		require.NoError(t, os.WriteFile(f, nil, 0600))
+	}
+	join := func(files ...string) []string {
+		for i, f := range files {
+			files[i] = filepath.Join(dir, filepath.FromSlash(f))
+		}
+		return files
+	}
+	cases := []struct {
+		pattern string
+		out     []string
+	}{
+		{"*.txt", join("a.txt")},
+		{"**/*.txt", join("a.txt", "x/a.txt", "x/y/test/b.txt", "z/test/d.txt")},
+		{"**/test/*.{txt,md}", join("x/y/test/b.txt", "x/y/test/c.md", "z/test/d.txt")},
+		{"x/**/*.md", join("x/y/test/c.md")},
+		{"*/test", join("z/test")},
+		{"{a.txt,b.md}", join("a.txt", "b.md")},
+		{"*.go", nil},
+	}
+	for i, tc := range cases {
+		t.Logf("TestGlob case #%d", i+1)
+		out, err := Glob(filepath.Join(dir, tc.pattern))
+		require.NoError(t, err)
+		assert.Equal(t, tc.out, out)
+	}
+	_, err := Glob(filepath.Join(dir, "[a"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cozees/cook/pkg/runtime/glob"
+)
+
+const (
+	// DefaultDebounce is the quiet period after the last change before a change is reported.
+	DefaultDebounce = 300 * time.Millisecond
+	// DefaultInterval is how often the watched files are polled.
+	DefaultInterval = 200 * time.Millisecond
+)
+
+type fileState struct {
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// Watcher poll the files matching a list of glob patterns and report when any of them is
+// created, modified or removed. A pattern matching a directory watch every file inside it.
+// Polling is used rather than a platform notification thus it work on any platform and file
+// system without any external service.
+type Watcher struct {
+	patterns []string
+	interval time.Duration
+	files    map[string]fileState
+}
+
+// New create a watcher of the given patterns using the same syntax as glob.Match. The current
+// state of the files is recorded right away, thus only a change made afterward is reported.
+func New(interval time.Duration, patterns ...string) (*Watcher, error) {
+	for _, p := range patterns {
+		if _, err := glob.Match(filepath.ToSlash(p), ""); err != nil {
+			return nil, err
+		}
+	}
+	w := &Watcher{patterns: patterns, interval: interval}
+	var err error
+	if w.files, err = w.snapshot(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Watcher) snapshot() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	add := func(path string, stat fs.FileInfo) {
+		files[path] = fileState{size: stat.Size(), mode: stat.Mode(), modTime: stat.ModTime()}
+	}
+	for _, p := range w.patterns {
+		matches, err := glob.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			err = filepath.WalkDir(m, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					// the file has been removed while walking, it's reported on the next poll
+					if os.IsNotExist(err) {
+						return nil
+					}
+					return err
+				} else if d.IsDir() {
+					return nil
+				}
+				if stat, err := d.Info(); err == nil {
+					add(path, stat)
+				} else if !os.IsNotExist(err) {
+					return err
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// changes return the path which is different between a and b.
+func changes(a, b map[string]fileState) []string {
+	var paths []string
+	for p, sa := range a {
+		if sb, ok := b[p]; !ok || sa.size != sb.size || sa.mode != sb.mode || !sa.modTime.Equal(sb.modTime) {
+			paths = append(paths, p)
+		}
+	}
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Wait block until at least one file is changed and no further change happen for the debounce
+// duration then return the sorted list of changed file. It return an error if ctx is done first.
+func (w *Watcher) Wait(ctx context.Context, debounce time.Duration) ([]string, error) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	changed := make(map[string]bool)
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		files, err := w.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		if paths := changes(w.files, files); len(paths) > 0 {
+			for _, p := range paths {
+				changed[p] = true
+			}
+			w.files, last = files, time.Now()
+		} else if len(changed) > 0 && time.Since(last) >= debounce {
+			result := make([]string, 0, len(changed))
+			for p := range changed {
+				result = append(result, p)
+			}
+			sort.Strings(result)
+			return result, nil
+		}
+	}
+}
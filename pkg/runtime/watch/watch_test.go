@@ -0,0 +1,47 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a.go"), filepath.Join(dir, "src", "b.go"), filepath.Join(dir, "c.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(b), 0700))
+	require.NoError(t, os.WriteFile(a, []byte("a"), 0600))
+	require.NoError(t, os.WriteFile(c, []byte("c"), 0600))
+
+	w, err := New(10*time.Millisecond, filepath.Join(dir, "*.go"), filepath.Join(dir, "src"))
+	require.NoError(t, err)
+	wait := func(change func()) []string {
+		time.AfterFunc(30*time.Millisecond, change)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		paths, err := w.Wait(ctx, 50*time.Millisecond)
+		require.NoError(t, err)
+		return paths
+	}
+	// create and modify within the debounce duration is reported once
+	assert.Equal(t, []string{a, b}, wait(func() {
+		os.WriteFile(b, []byte("b"), 0600)
+		os.WriteFile(a, []byte("modified"), 0600)
+	}))
+	assert.Equal(t, []string{b}, wait(func() { os.Remove(b) }))
+
+	// file not matching the pattern is ignored
+	require.NoError(t, os.WriteFile(c, []byte("modified"), 0600))
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = w.Wait(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = New(time.Second, "[a")
+	assert.Error(t, err)
+}
@@ -29,6 +29,7 @@ const (
 	logDesc      = `Log functions provide several pre-define functionality print or format variable to the standard output.`
 	pathDesc     = `Path functions provide several pre-define functionality that can be use to manipulate or extract metadata from file path.`
 	fdDesc       = `File and Directory functions provide several pre-define functionality create, delete or modified ones or more files and directories.`
+	textDesc     = `Text functions provide several pre-define functionality to compare, patch or process the content of a file or a text.`
 )
 
 var functions = []*functionGroup{
@@ -38,6 +39,7 @@ var functions = []*functionGroup{
 	{Name: "Log Functions", File: "log", Flags: function.AllLogFlags, Description: logDesc},
 	{Name: "Path Functions", File: "path", Flags: function.AllPathFlags, Description: pathDesc},
 	{Name: "File and Directory Functions", File: "fd", Flags: function.AllFileDirectoryFlags, Description: fdDesc},
+	{Name: "Text Functions", File: "text", Flags: function.AllTextFlags, Description: textDesc},
 }
 
 func main() {